@@ -0,0 +1,86 @@
+package constructs
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// ExpensesTableProps defines the properties for the ExpensesTable construct
+type ExpensesTableProps struct {
+	// TableName is the name of the DynamoDB table
+	TableName string
+	// RemovalPolicy is applied when the table is removed from the stack (default: RETAIN)
+	RemovalPolicy awscdk.RemovalPolicy
+}
+
+// ExpensesTable represents the single table of the expenses repository
+type ExpensesTable struct {
+	constructs.Construct
+	Table awsdynamodb.Table
+}
+
+// NewExpensesTable creates the table used by expenses.DynamoDBRepository, with its
+// category and tag indexes, point in time recovery and TTL on "expires_at"
+func NewExpensesTable(scope constructs.Construct, id *string, props *ExpensesTableProps) *ExpensesTable {
+	construct := constructs.NewConstruct(scope, id)
+
+	// Set defaults
+	removalPolicy := props.RemovalPolicy
+	if removalPolicy == "" {
+		removalPolicy = awscdk.RemovalPolicy_RETAIN
+	}
+
+	// Items are partitioned per user and sorted by date
+	table := awsdynamodb.NewTable(construct, jsii.String("Table"), &awsdynamodb.TableProps{
+		TableName: jsii.String(props.TableName),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("pk"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		SortKey: &awsdynamodb.Attribute{
+			Name: jsii.String("sk"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		PointInTimeRecoverySpecification: &awsdynamodb.PointInTimeRecoverySpecification{
+			PointInTimeRecoveryEnabled: jsii.Bool(true),
+		},
+		TimeToLiveAttribute: jsii.String("expires_at"),
+		RemovalPolicy:       removalPolicy,
+	})
+
+	// Expenses of a user in a category, sorted by date
+	table.AddGlobalSecondaryIndex(&awsdynamodb.GlobalSecondaryIndexProps{
+		IndexName: jsii.String("category"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("category_pk"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		SortKey: &awsdynamodb.Attribute{
+			Name: jsii.String("category_sk"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		ProjectionType: awsdynamodb.ProjectionType_ALL,
+	})
+
+	// Expenses of a user with a tag, sorted by date
+	table.AddGlobalSecondaryIndex(&awsdynamodb.GlobalSecondaryIndexProps{
+		IndexName: jsii.String("tag"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("tag_pk"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		SortKey: &awsdynamodb.Attribute{
+			Name: jsii.String("tag_sk"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		ProjectionType: awsdynamodb.ProjectionType_ALL,
+	})
+
+	return &ExpensesTable{
+		Construct: construct,
+		Table:     table,
+	}
+}
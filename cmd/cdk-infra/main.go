@@ -28,5 +28,14 @@ func main() {
 	})
 	apiStack.AddDependency(stepMachineResult.Stack, jsii.String("Requires Step Machine Stack to connect the API"))
 
+	reportsStack := stacks.ReportsStack(app, "ReportsStack", &stacks.ReportsStackProps{
+		StackProps:    stackProps,
+		Roles:         permissionsResult.Roles,
+		SendMessage:   stepMachineResult.SendMessage,
+		ExpensesTable: stepMachineResult.ExpensesTable,
+		SettingsTable: stepMachineResult.SettingsTable,
+	})
+	reportsStack.AddDependency(stepMachineResult.Stack, jsii.String("Requires the send message Lambda and the tables of Step Machine Stack"))
+
 	app.Synth(nil)
 }
@@ -31,7 +31,13 @@ func PermissionsStack(scope constructs.Construct, id string, props *PermissionsS
 		"TelegramApiAuthorizerRole",
 		"TelegramApiGatewayRole",
 		"TelegramSendMessageRole",
+		"TelegramCommandHandlerRole",
+		"TelegramReceiptProcessorRole",
+		"BudgetEvaluatorRole",
 		"TelegramBotStateMachineRole",
+		"ReportBuilderRole",
+		"ReportsStateMachineRole",
+		"ReportsSchedulerRole",
 	}
 	for _, roleLogicalId := range roleLogicalIds {
 		resource := cfnTemplate.GetResource(jsii.String(roleLogicalId))
@@ -0,0 +1,96 @@
+package stacks
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsscheduler"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsschedulertargets"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+	customConstructs "github.com/betofloresbaca/expenses-manager/cmd/cdk-infra/constructs"
+)
+
+// DefaultTimeZone is the time zone of the users that did not choose one, their reports and budgets are in it
+const DefaultTimeZone = "America/Mexico_City"
+
+// ReportsStackProps defines the properties for the Reports stack
+type ReportsStackProps struct {
+	awscdk.StackProps
+	Roles map[string]awsiam.IRole
+	// SendMessage sends each report, ExpensesTable and SettingsTable are read to build them
+	SendMessage   awslambda.IFunction
+	ExpensesTable awsdynamodb.ITable
+	SettingsTable awsdynamodb.ITable
+	// TimeZone of the users who did not choose one (default: DefaultTimeZone)
+	TimeZone string
+}
+
+// ReportsStack creates the hourly schedule that sends the daily, weekly and
+// monthly reports to the users subscribed at that hour
+func ReportsStack(scope constructs.Construct, id string, props *ReportsStackProps) awscdk.Stack {
+	stack := awscdk.NewStack(scope, &id, &props.StackProps)
+
+	timeZone := props.TimeZone
+	if timeZone == "" {
+		timeZone = DefaultTimeZone
+	}
+
+	reportBuilder := customConstructs.NewLambdaFunction(
+		stack,
+		jsii.String("ReportBuilder"),
+		&customConstructs.LambdaFunctionProps{
+			FunctionName: "EM-ReportBuilder",
+			ZipPath:      "bin/report-builder.zip",
+			Environment: map[string]*string{
+				"EXPENSES_TABLE": props.ExpensesTable.TableName(),
+				"SETTINGS_TABLE": props.SettingsTable.TableName(),
+				"TIME_ZONE":      jsii.String(timeZone),
+			},
+			Timeout: awscdk.Duration_Minutes(jsii.Number(5)),
+			Role:    props.Roles["ReportBuilderRole"],
+		},
+	)
+
+	stateMachine := customConstructs.NewStateMachine(
+		stack,
+		jsii.String("ReportsStateMachine"),
+		&customConstructs.StateMachineProps{
+			StateMachineName: jsii.String("EM-ReportsStateMachine"),
+			AslFilePath:      "cmd/cdk-infra/resources/reports-state-machine.asl.json",
+			ARNReplacements: map[string]string{
+				"{% <REPORT_BUILDER> %}":        *reportBuilder.Function.FunctionArn(),
+				"{% <TELEGRAM_SEND_MESSAGE> %}": *props.SendMessage.FunctionArn(),
+			},
+			Role: props.Roles["ReportsStateMachineRole"],
+		},
+	)
+
+	// permissions-cfn.yaml already allows the role to start the state machine, imported
+	// as immutable so the target does not add the policy to the PermissionsStack
+	schedulerRole := awsiam.Role_FromRoleArn(stack, jsii.String("ImportedReportsSchedulerRole"),
+		props.Roles["ReportsSchedulerRole"].RoleArn(), &awsiam.FromRoleArnOptions{Mutable: jsii.Bool(false)})
+
+	// Every hour, each user chooses the hour of their reports. The builder
+	// receives the scheduled time so a late start builds the same reports
+	awsscheduler.NewSchedule(stack, jsii.String("ReportsSchedule"), &awsscheduler.ScheduleProps{
+		ScheduleName: jsii.String("EM-ReportsSchedule"),
+		Description:  jsii.String("Sends the expense reports due at each hour"),
+		Schedule: awsscheduler.ScheduleExpression_Cron(&awsscheduler.CronOptionsWithTimezone{
+			Minute:   jsii.String("0"),
+			TimeZone: awscdk.TimeZone_Of(jsii.String(timeZone)),
+		}),
+		Target: awsschedulertargets.NewStepFunctionsStartExecution(stateMachine, &awsschedulertargets.ScheduleTargetBaseProps{
+			Input: awsscheduler.ScheduleTargetInput_FromObject(map[string]*string{
+				"Time": awsscheduler.ContextAttribute_ScheduledTime(),
+			}),
+			// A report sent hours late is worse than a missing one
+			MaxEventAge:   awscdk.Duration_Minutes(jsii.Number(30)),
+			RetryAttempts: jsii.Number(2),
+			Role:          schedulerRole,
+		}),
+	})
+
+	return stack
+}
@@ -1,30 +1,159 @@
 package stacks
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsstepfunctions"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
 	customConstructs "github.com/betofloresbaca/expenses-manager/cmd/cdk-infra/constructs"
 )
 
+// DefaultDedupTTL is how long an update_id is remembered to drop the webhook retries of Telegram
+const DefaultDedupTTL = 24 * time.Hour
+
 // Define the properties for your stack
 type LambdasStackProps struct {
 	awscdk.StackProps
 	Roles map[string]awsiam.IRole
+	// DedupTTL is how long processed updates are remembered (default: DefaultDedupTTL)
+	DedupTTL time.Duration
+	// TimeZone of the users that did not choose one (default: DefaultTimeZone)
+	TimeZone string
 }
 
 // StepMachineStackResult contains the stack and its resources
 type StepMachineStackResult struct {
 	Stack        awscdk.Stack
 	StateMachine awsstepfunctions.StateMachine
+	// SendMessage, ExpensesTable and SettingsTable are shared with the ReportsStack
+	SendMessage   awslambda.IFunction
+	ExpensesTable awsdynamodb.ITable
+	SettingsTable awsdynamodb.ITable
 }
 
 // StepMachineStack creates the CloudFormation Stack
 func StepMachineStack(scope constructs.Construct, id string, props *LambdasStackProps) *StepMachineStackResult {
 	stack := awscdk.NewStack(scope, &id, &props.StackProps)
 
+	dedupTTL := props.DedupTTL
+	if dedupTTL <= 0 {
+		dedupTTL = DefaultDedupTTL
+	}
+	timeZone := props.TimeZone
+	if timeZone == "" {
+		timeZone = DefaultTimeZone
+	}
+
+	// Processed update_ids, the state machine drops an update if it is already here
+	updatesTable := awsdynamodb.NewTable(stack, jsii.String("TelegramUpdates"), &awsdynamodb.TableProps{
+		TableName: jsii.String("EM-TelegramUpdates"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("pk"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode:         awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		TimeToLiveAttribute: jsii.String("expires_at"),
+		RemovalPolicy:       awscdk.RemovalPolicy_DESTROY,
+	})
+
+	// Shared token buckets so every send message instance respects Telegram limits
+	rateLimitsTable := awsdynamodb.NewTable(stack, jsii.String("TelegramRateLimits"), &awsdynamodb.TableProps{
+		TableName: jsii.String("EM-TelegramRateLimits"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("pk"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode:         awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		TimeToLiveAttribute: jsii.String("expires_at"),
+		RemovalPolicy:       awscdk.RemovalPolicy_DESTROY,
+	})
+
+	// Per chat state of the multi-step conversations, abandoned ones expire with the TTL
+	conversationsTable := awsdynamodb.NewTable(stack, jsii.String("TelegramConversations"), &awsdynamodb.TableProps{
+		TableName: jsii.String("EM-TelegramConversations"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("pk"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode:         awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		TimeToLiveAttribute: jsii.String("expires_at"),
+		RemovalPolicy:       awscdk.RemovalPolicy_DESTROY,
+	})
+
+	// Expenses of every user, kept when the stack is removed
+	expensesTable := customConstructs.NewExpensesTable(stack, jsii.String("Expenses"), &customConstructs.ExpensesTableProps{
+		TableName: "EM-Expenses",
+	})
+
+	// Categories and categorization rules of every user, kept when the stack is removed
+	categoriesTable := awsdynamodb.NewTable(stack, jsii.String("Categories"), &awsdynamodb.TableProps{
+		TableName: jsii.String("EM-Categories"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("pk"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		PointInTimeRecoverySpecification: &awsdynamodb.PointInTimeRecoverySpecification{
+			PointInTimeRecoveryEnabled: jsii.Bool(true),
+		},
+		RemovalPolicy: awscdk.RemovalPolicy_RETAIN,
+	})
+
+	// Budgets of every user and the alerts already sent in each period, kept when the stack is removed
+	budgetsTable := awsdynamodb.NewTable(stack, jsii.String("Budgets"), &awsdynamodb.TableProps{
+		TableName: jsii.String("EM-Budgets"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("pk"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		PointInTimeRecoverySpecification: &awsdynamodb.PointInTimeRecoverySpecification{
+			PointInTimeRecoveryEnabled: jsii.Bool(true),
+		},
+		RemovalPolicy: awscdk.RemovalPolicy_RETAIN,
+	})
+
+	// Settings of every user, like the reports they receive, kept when the stack is removed
+	settingsTable := awsdynamodb.NewTable(stack, jsii.String("Settings"), &awsdynamodb.TableProps{
+		TableName: jsii.String("EM-Settings"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("pk"),
+			Type: awsdynamodb.AttributeType_STRING,
+		},
+		BillingMode: awsdynamodb.BillingMode_PAY_PER_REQUEST,
+		PointInTimeRecoverySpecification: &awsdynamodb.PointInTimeRecoverySpecification{
+			PointInTimeRecoveryEnabled: jsii.Bool(true),
+		},
+		RemovalPolicy: awscdk.RemovalPolicy_RETAIN,
+	})
+
+	// Users subscribed to reports by the hour in UTC they are sent in standard time, see reports.ReportHourIndex
+	settingsTable.AddGlobalSecondaryIndex(&awsdynamodb.GlobalSecondaryIndexProps{
+		IndexName: jsii.String("report_hour"),
+		PartitionKey: &awsdynamodb.Attribute{
+			Name: jsii.String("report_hour"),
+			Type: awsdynamodb.AttributeType_NUMBER,
+		},
+		ProjectionType:   awsdynamodb.ProjectionType_INCLUDE,
+		NonKeyAttributes: jsii.Strings("reports", "reports_version"),
+	})
+
+	// Pictures of the receipts, the roles in permissions-cfn.yaml grant access by this name
+	receiptsBucket := awss3.NewBucket(stack, jsii.String("Receipts"), &awss3.BucketProps{
+		BucketName:        jsii.String("em-receipts-" + *stack.Account() + "-" + *stack.Region()),
+		Encryption:        awss3.BucketEncryption_S3_MANAGED,
+		BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+		EnforceSSL:        jsii.Bool(true),
+		RemovalPolicy:     awscdk.RemovalPolicy_RETAIN,
+	})
+
 	// Deploy lambda functions
 	telegramSendMessage := customConstructs.NewLambdaFunction(
 		stack,
@@ -34,11 +163,67 @@ func StepMachineStack(scope constructs.Construct, id string, props *LambdasStack
 			ZipPath:      "bin/telegram-send-message.zip",
 			Environment: map[string]*string{
 				"TELEGRAM_TOKEN_PARAM": jsii.String("/em/TelegramToken"),
+				"RATE_LIMIT_TABLE":     rateLimitsTable.TableName(),
 			},
 			Role: props.Roles["TelegramSendMessageRole"],
 		},
 	)
 
+	telegramCommandHandler := customConstructs.NewLambdaFunction(
+		stack,
+		jsii.String("TelegramCommandHandler"),
+		&customConstructs.LambdaFunctionProps{
+			FunctionName: "EM-TelegramCommandHandler",
+			ZipPath:      "bin/telegram-command-handler.zip",
+			Environment: map[string]*string{
+				"TELEGRAM_TOKEN_PARAM": jsii.String("/em/TelegramToken"),
+				"RATE_LIMIT_TABLE":     rateLimitsTable.TableName(),
+				"CONVERSATIONS_TABLE":  conversationsTable.TableName(),
+				"EXPENSES_TABLE":       expensesTable.Table.TableName(),
+				"CATEGORIES_TABLE":     categoriesTable.TableName(),
+				"BUDGETS_TABLE":        budgetsTable.TableName(),
+				"SETTINGS_TABLE":       settingsTable.TableName(),
+				"RECEIPTS_BUCKET":      receiptsBucket.BucketName(),
+				"TIME_ZONE":            jsii.String(timeZone),
+			},
+			Role: props.Roles["TelegramCommandHandlerRole"],
+		},
+	)
+
+	telegramReceiptProcessor := customConstructs.NewLambdaFunction(
+		stack,
+		jsii.String("TelegramReceiptProcessor"),
+		&customConstructs.LambdaFunctionProps{
+			FunctionName: "EM-TelegramReceiptProcessor",
+			ZipPath:      "bin/telegram-receipt-processor.zip",
+			Environment: map[string]*string{
+				"TELEGRAM_TOKEN_PARAM": jsii.String("/em/TelegramToken"),
+				"RATE_LIMIT_TABLE":     rateLimitsTable.TableName(),
+				"EXPENSES_TABLE":       expensesTable.Table.TableName(),
+				"CATEGORIES_TABLE":     categoriesTable.TableName(),
+				"RECEIPTS_BUCKET":      receiptsBucket.BucketName(),
+				"TIME_ZONE":            jsii.String(timeZone),
+			},
+			Role: props.Roles["TelegramReceiptProcessorRole"],
+		},
+	)
+
+	budgetEvaluator := customConstructs.NewLambdaFunction(
+		stack,
+		jsii.String("BudgetEvaluator"),
+		&customConstructs.LambdaFunctionProps{
+			FunctionName: "EM-BudgetEvaluator",
+			ZipPath:      "bin/budget-evaluator.zip",
+			Environment: map[string]*string{
+				"EXPENSES_TABLE": expensesTable.Table.TableName(),
+				"BUDGETS_TABLE":  budgetsTable.TableName(),
+				"SETTINGS_TABLE": settingsTable.TableName(),
+				"TIME_ZONE":      jsii.String(timeZone),
+			},
+			Role: props.Roles["BudgetEvaluatorRole"],
+		},
+	)
+
 	// Create the state machine
 	stateMachine := customConstructs.NewStateMachine(
 		stack,
@@ -47,7 +232,12 @@ func StepMachineStack(scope constructs.Construct, id string, props *LambdasStack
 			StateMachineName: jsii.String("EM-TelegramBotStateMachine"),
 			AslFilePath:      "cmd/cdk-infra/resources/telegram-bot-state-machine.asl.json",
 			ARNReplacements: map[string]string{
-				"{% <TELEGRAM_SEND_MESSAGE> %}": *telegramSendMessage.Function.FunctionArn(),
+				"{% <TELEGRAM_SEND_MESSAGE> %}":      *telegramSendMessage.Function.FunctionArn(),
+				"{% <TELEGRAM_COMMAND_HANDLER> %}":   *telegramCommandHandler.Function.FunctionArn(),
+				"{% <TELEGRAM_RECEIPT_PROCESSOR> %}": *telegramReceiptProcessor.Function.FunctionArn(),
+				"{% <BUDGET_EVALUATOR> %}":           *budgetEvaluator.Function.FunctionArn(),
+				"{% <TELEGRAM_UPDATES_TABLE> %}":     *updatesTable.TableName(),
+				"<DEDUP_TTL_SECONDS>":                strconv.Itoa(int(dedupTTL.Seconds())),
 			},
 
 			Role: props.Roles["TelegramBotStateMachineRole"],
@@ -55,7 +245,10 @@ func StepMachineStack(scope constructs.Construct, id string, props *LambdasStack
 	)
 
 	return &StepMachineStackResult{
-		Stack:        stack,
-		StateMachine: stateMachine,
+		Stack:         stack,
+		StateMachine:  stateMachine,
+		SendMessage:   telegramSendMessage.Function,
+		ExpensesTable: expensesTable.Table,
+		SettingsTable: settingsTable,
 	}
 }
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/budgets"
+)
+
+// BudgetRequest es la respuesta de telegram-command-handler, ExpenseID es el gasto recién registrado
+type BudgetRequest struct {
+	ExpenseID string `json:"ExpenseID"`
+	UserID    int64  `json:"UserID"`
+	ChatID    int64  `json:"ChatID"`
+	ChatType  string `json:"ChatType,omitempty"`
+}
+
+// BudgetResponse es la respuesta de budget-evaluator.
+// Text es la alerta para enviar a ChatID con telegram-send-message, vacío si no se cruzó ningún umbral.
+// ChatType siempre se incluye (vacío si no se conoce) para pasarlo a telegram-send-message.
+type BudgetResponse struct {
+	Ok       bool   `json:"Ok"`
+	ChatID   int64  `json:"ChatID,omitempty"`
+	ChatType string `json:"ChatType"`
+	Text     string `json:"Text,omitempty"`
+}
+
+// errNoAlerts evita guardar los presupuestos cuando no cambiaron
+var errNoAlerts = errors.New("no budget alerts")
+
+// EvaluateBudgets evalúa los presupuestos de la categoría del gasto registrado,
+// lo que budget-evaluator recibe de telegram-command-handler
+func (s *Services) EvaluateBudgets(ctx context.Context, request BudgetRequest) (BudgetResponse, error) {
+	if request.ExpenseID == "" {
+		return BudgetResponse{Ok: true}, nil
+	}
+	expense, err := s.Expenses.Get(ctx, request.UserID, request.ExpenseID)
+	if errors.Is(err, expenses.ErrNotFound) {
+		return BudgetResponse{Ok: true}, nil
+	}
+	if err != nil {
+		return BudgetResponse{Ok: false}, err
+	}
+	if expense.IsDraft() || expense.Category == "" {
+		return BudgetResponse{Ok: true}, nil
+	}
+
+	alerts, err := s.evaluate(ctx, expense, time.Now().In(s.userLocation(ctx, expense.UserID)))
+	if err != nil {
+		return BudgetResponse{Ok: false}, err
+	}
+	if len(alerts) == 0 {
+		return BudgetResponse{Ok: true}, nil
+	}
+	return BudgetResponse{Ok: true, ChatID: request.ChatID, ChatType: request.ChatType, Text: strings.Join(alerts, "\n\n")}, nil
+}
+
+// evaluate regresa las alertas de los umbrales que el gasto hizo cruzar por primera vez
+// en el periodo actual y las anota en los presupuestos. Los gastos de periodos
+// anteriores no generan alertas, esos periodos ya terminaron. now está en la zona horaria del usuario
+func (s *Services) evaluate(ctx context.Context, expense *expenses.Expense, now time.Time) ([]string, error) {
+	var alerts []string
+	_, err := budgets.Update(ctx, s.Budgets, expense.UserID, func(plan *budgets.Plan) error {
+		alerts = nil
+		for _, budget := range plan.Of(expense.Category) {
+			if budget.Period.Key(expense.Date) != budget.Period.Key(now) {
+				continue
+			}
+			spent, err := budgets.Spent(ctx, s.Expenses, expense.UserID, *budget, now, expense)
+			if err != nil {
+				return err
+			}
+			if threshold, ok := budget.Cross(spent, now); ok {
+				alerts = append(alerts, alertText(budget, spent, threshold))
+			}
+		}
+		if len(alerts) == 0 {
+			return errNoAlerts
+		}
+		return nil
+	})
+	if errors.Is(err, errNoAlerts) {
+		return nil, nil
+	}
+	return alerts, err
+}
+
+// alertText avisa cuánto se lleva gastado del presupuesto
+func alertText(budget *budgets.Budget, spent int64, threshold int) string {
+	amounts := fmt.Sprintf("%s de %s", expenses.FormatAmount(spent, budget.Currency), expenses.FormatAmount(budget.Limit, budget.Currency))
+	if threshold >= 100 {
+		return fmt.Sprintf("🚨 Superaste el %d%% de tu presupuesto %s de %s: llevas %s (%d%%).",
+			threshold, budget.Period.Name(), budget.Category, amounts, budget.Percent(spent))
+	}
+	return fmt.Sprintf("⚠️ Llevas el %d%% de tu presupuesto %s de %s: %s.",
+		budget.Percent(spent), budget.Period.Name(), budget.Category, amounts)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/budgets"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/telegramtest"
+)
+
+// staleIndex no lista por categoría los gastos en hidden, como el índice de DynamoDB antes de actualizarse
+type staleIndex struct {
+	expenses.Repository
+	hidden []string
+}
+
+func (r *staleIndex) List(ctx context.Context, userID int64, query expenses.Query) ([]*expenses.Expense, error) {
+	list, err := r.Repository.List(ctx, userID, query)
+	if query.Category != "" {
+		list = slices.DeleteFunc(list, func(expense *expenses.Expense) bool { return slices.Contains(r.hidden, expense.ID) })
+	}
+	return list, err
+}
+
+func TestEvaluateBudgetsCountsRecordedExpense(t *testing.T) {
+	ctx := context.Background()
+	services := NewMemoryServices(time.UTC)
+	repository := &staleIndex{Repository: services.Expenses}
+	services.Expenses = repository
+
+	_, err := budgets.Update(ctx, services.Budgets, 42, func(plan *budgets.Plan) error {
+		return plan.Set(budgets.Budget{Category: "Comida", Period: budgets.Monthly, Limit: 10000, Currency: expenses.DefaultCurrency})
+	})
+	if err != nil {
+		t.Fatalf("budgets.Update() error = %v", err)
+	}
+	expense := &expenses.Expense{UserID: 42, ChatID: 42, Amount: 15000, Currency: expenses.DefaultCurrency, Category: "Comida", Date: time.Now().UTC()}
+	if err := services.Expenses.Create(ctx, expense); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	repository.hidden = []string{expense.ID}
+
+	response, err := services.EvaluateBudgets(ctx, BudgetRequest{ExpenseID: expense.ID, UserID: 42, ChatID: 42, ChatType: "private"})
+	if err != nil {
+		t.Fatalf("EvaluateBudgets() error = %v", err)
+	}
+	if !response.Ok || response.ChatID != 42 || !strings.Contains(response.Text, "Superaste el 100%") || !strings.Contains(response.Text, "150.00") {
+		t.Errorf("EvaluateBudgets() = %+v, want the alert of the recorded expense", response)
+	}
+}
+
+func TestHandleTimeZone(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	services := NewMemoryServices(time.UTC)
+
+	if location := services.userLocation(ctx, telegramtest.User.ID); location != time.UTC {
+		t.Errorf("userLocation() without a time zone = %s, want UTC", location)
+	}
+	for _, text := range []string{"/timezone Asia/Tokyo", "/timezone Marte/Olympus", "/timezone Local"} {
+		update := server.InjectMessage(telegramtest.User.ID, text)
+		if _, err := services.HandleUpdate(ctx, server.Client(), &update); err != nil {
+			t.Fatalf("HandleUpdate(%q) error = %v", text, err)
+		}
+	}
+	if location := services.userLocation(ctx, telegramtest.User.ID); location.String() != "Asia/Tokyo" {
+		t.Errorf("userLocation() = %s, want Asia/Tokyo", location)
+	}
+	var replies []string
+	for _, call := range server.CallsTo("sendMessage") {
+		var sent struct {
+			Text string `json:"text"`
+		}
+		if err := call.Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		replies = append(replies, sent.Text)
+	}
+	if len(replies) != 3 || !strings.Contains(replies[0], "Asia/Tokyo") || !strings.Contains(replies[1], "No conozco") || !strings.Contains(replies[2], "No conozco") {
+		t.Errorf("replies = %q, want the new time zone and two rejections", replies)
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/bot"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/budgets"
+)
+
+// budgetUsage explica los subcomandos de /budget
+const budgetUsage = "Usa /budget set <categoría> <monto> [mensual|semanal] [50,80,100], /budget show o /budget clear <categoría> [mensual|semanal]."
+
+// handleBudget atiende /budget set|show|clear
+func (s *Services) handleBudget(ctx context.Context, req *bot.Request) error {
+	switch strings.ToLower(req.Arg(0)) {
+	case "set", "fijar":
+		return s.setBudget(ctx, req)
+	case "show", "ver", "":
+		return s.showBudgets(ctx, req)
+	case "clear", "borrar":
+		return s.clearBudget(ctx, req)
+	}
+	return req.Reply(ctx, budgetUsage)
+}
+
+// setBudget fija el presupuesto de una categoría, por ejemplo "/budget set Comida 3000 mensual 50,80,100"
+func (s *Services) setBudget(ctx context.Context, req *bot.Request) error {
+	if len(req.Args) < 3 || len(req.Args) > 5 {
+		return req.Reply(ctx, budgetUsage)
+	}
+	profile, err := s.Categories.Load(ctx, req.UserID())
+	if err != nil {
+		return err
+	}
+	category, ok := profile.Find(req.Arg(1))
+	if !ok {
+		return req.Reply(ctx, "No tienes esa categoría, usa /categories para ver tus categorías.")
+	}
+	limit, err := expenses.ParseAmount(req.Arg(2), expenses.DefaultCurrency)
+	if err != nil {
+		return req.Reply(ctx, "Escribe el monto como un número mayor a cero, por ejemplo 3000.")
+	}
+	budget := budgets.Budget{Category: category, Period: budgets.Monthly, Limit: limit, Currency: expenses.DefaultCurrency}
+	for _, arg := range req.Args[3:] {
+		if period, ok := budgets.ParsePeriod(arg); ok {
+			budget.Period = period
+			continue
+		}
+		if budget.Thresholds, err = parseThresholds(arg); err != nil {
+			return req.Reply(ctx, "Escribe los porcentajes de las alertas separados por comas, por ejemplo 50,80,100.")
+		}
+	}
+
+	_, err = budgets.Update(ctx, s.Budgets, req.UserID(), func(plan *budgets.Plan) error {
+		return plan.Set(budget)
+	})
+	switch {
+	case errors.Is(err, budgets.ErrInvalidBudget):
+		return req.Reply(ctx, "Los porcentajes de las alertas deben estar entre 1 y 1000.")
+	case errors.Is(err, budgets.ErrTooMany):
+		return req.Reply(ctx, fmt.Sprintf("Puedes tener hasta %d presupuestos.", budgets.MaxBudgets))
+	case err != nil:
+		return err
+	}
+	return req.Reply(ctx, fmt.Sprintf("Presupuesto %s de %s: %s.", budget.Period.Name(), category, expenses.FormatAmount(limit, budget.Currency)))
+}
+
+// parseThresholds interpreta los porcentajes de las alertas, por ejemplo "50,80,100%"
+func parseThresholds(text string) ([]int, error) {
+	var thresholds []int
+	for _, field := range strings.Split(text, ",") {
+		threshold, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(field), "%"))
+		if err != nil {
+			return nil, err
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	return thresholds, nil
+}
+
+// showBudgets muestra lo gastado en el periodo actual de cada presupuesto
+func (s *Services) showBudgets(ctx context.Context, req *bot.Request) error {
+	plan, err := s.Budgets.Load(ctx, req.UserID())
+	if err != nil {
+		return err
+	}
+	if len(plan.Budgets) == 0 {
+		return req.Reply(ctx, "No tienes presupuestos. "+budgetUsage)
+	}
+	now := time.Now().In(s.userLocation(ctx, req.UserID()))
+	lines := []string{"Tus presupuestos:"}
+	for _, budget := range plan.Budgets {
+		spent, err := budgets.Spent(ctx, s.Expenses, req.UserID(), budget, now, nil)
+		if err != nil {
+			return err
+		}
+		var thresholds []string
+		for _, threshold := range budget.Thresholds {
+			thresholds = append(thresholds, strconv.Itoa(threshold)+"%")
+		}
+		lines = append(lines, fmt.Sprintf("%s %s: %s de %s (%d%%), alertas en %s",
+			budget.Category, budget.Period.Name(),
+			expenses.FormatAmount(spent, budget.Currency), expenses.FormatAmount(budget.Limit, budget.Currency),
+			budget.Percent(spent), strings.Join(thresholds, ", ")))
+	}
+	return req.Reply(ctx, strings.Join(lines, "\n"))
+}
+
+// clearBudget elimina los presupuestos de una categoría, de un periodo o de todos
+func (s *Services) clearBudget(ctx context.Context, req *bot.Request) error {
+	if len(req.Args) < 2 || len(req.Args) > 3 {
+		return req.Reply(ctx, budgetUsage)
+	}
+	var period budgets.Period
+	if len(req.Args) == 3 {
+		var ok bool
+		if period, ok = budgets.ParsePeriod(req.Arg(2)); !ok {
+			return req.Reply(ctx, budgetUsage)
+		}
+	}
+	removed := 0
+	_, err := budgets.Update(ctx, s.Budgets, req.UserID(), func(plan *budgets.Plan) error {
+		removed = plan.Clear(req.Arg(1), period)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return req.Reply(ctx, "No tienes presupuestos de esa categoría.")
+	}
+	return req.Reply(ctx, fmt.Sprintf("Presupuestos eliminados: %d.", removed))
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/bot"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/budgets"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/categorize"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/format"
+)
+
+// categoryError explica al usuario por qué no se pudo cambiar una categoría
+func categoryError(err error) string {
+	switch {
+	case errors.Is(err, categorize.ErrInvalidName):
+		return fmt.Sprintf("El nombre de la categoría solo puede tener letras, números, - y _, hasta %d caracteres.", categorize.MaxNameLength)
+	case errors.Is(err, categorize.ErrUnknownCategory):
+		return "No tienes esa categoría, usa /categories para ver tus categorías."
+	case errors.Is(err, categorize.ErrDuplicateCategory):
+		return "Ya tienes una categoría con ese nombre."
+	case errors.Is(err, categorize.ErrTooMany):
+		return fmt.Sprintf("Puedes tener hasta %d categorías y %d reglas.", categorize.MaxCategories, categorize.MaxRules)
+	case errors.Is(err, categorize.ErrInvalidRule):
+		return "No entendí la regla, escribe una palabra, una #etiqueta o un rango de montos como <100, >1000 o 100-500."
+	}
+	return ""
+}
+
+// handleCategories lista las categorías del usuario con sus reglas
+func (s *Services) handleCategories(ctx context.Context, req *bot.Request) error {
+	profile, err := s.Categories.Load(ctx, req.UserID())
+	if err != nil {
+		return err
+	}
+	if _, err := s.finishRenames(ctx, profile); err != nil {
+		return err
+	}
+	text := format.New("").Text("Tus categorías:")
+	for _, category := range profile.Categories {
+		text.Newline().Bold(category)
+		var rules []string
+		for _, rule := range profile.RulesOf(category) {
+			if rule.Learned {
+				rules = append(rules, rule.String()+" (aprendida)")
+			} else {
+				rules = append(rules, rule.String())
+			}
+		}
+		if len(rules) > 0 {
+			text.Text(": " + strings.Join(rules, ", "))
+		}
+	}
+	text.Newline().Newline().Text("Agrega categorías o reglas con /addcategory, cambia su nombre con /rename y elimínalas con /removecategory.")
+	reply := &telegram.SendMessageRequest{}
+	text.Apply(reply)
+	return req.ReplyWith(ctx, reply)
+}
+
+// handleAddCategory agrega una categoría, o reglas a una existente, por ejemplo
+// "/addcategory Café starbucks, #cafe, <100"
+func (s *Services) handleAddCategory(ctx context.Context, req *bot.Request) error {
+	name := req.Arg(0)
+	var rules []categorize.Rule
+	for _, text := range strings.Split(req.Rest(1), ",") {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		rule, err := categorize.ParseRule(text, name, expenses.DefaultCurrency)
+		if err != nil {
+			return req.Reply(ctx, categoryError(err))
+		}
+		rules = append(rules, rule)
+	}
+
+	var category string
+	existed := false
+	_, err := categorize.Update(ctx, s.Categories, req.UserID(), func(profile *categorize.Profile) error {
+		var err error
+		_, existed = profile.Find(name)
+		category, err = profile.Add(name, rules...)
+		return err
+	})
+	if text := categoryError(err); text != "" {
+		return req.Reply(ctx, text)
+	}
+	if err != nil {
+		return err
+	}
+	if existed {
+		return req.Reply(ctx, fmt.Sprintf("Reglas agregadas a %s.", category))
+	}
+	return req.Reply(ctx, fmt.Sprintf("Categoría %s agregada.", category))
+}
+
+// handleRename cambia el nombre de una categoría, también en sus reglas, sus presupuestos y en los gastos ya registrados
+func (s *Services) handleRename(ctx context.Context, req *bot.Request) error {
+	var renamed categorize.Renamed
+	profile, err := categorize.Update(ctx, s.Categories, req.UserID(), func(profile *categorize.Profile) error {
+		var err error
+		renamed, err = profile.Rename(req.Arg(0), req.Arg(1))
+		return err
+	})
+	if text := categoryError(err); text != "" {
+		return req.Reply(ctx, text)
+	}
+	if err != nil {
+		return err
+	}
+	moved, err := s.finishRenames(ctx, profile)
+	if err != nil {
+		return err
+	}
+	return req.Reply(ctx, fmt.Sprintf("Categoría %s renombrada a %s, %d gastos actualizados.", renamed.Old, renamed.New, moved))
+}
+
+// finishRenames mueve los presupuestos y los gastos de las categorías renombradas y las quita de las pendientes.
+// Si se interrumpe, el siguiente /rename o /categories continúa con los gastos que faltan
+func (s *Services) finishRenames(ctx context.Context, profile *categorize.Profile) (int, error) {
+	moved := 0
+	for _, renamed := range profile.Renames {
+		_, err := budgets.Update(ctx, s.Budgets, profile.UserID, func(plan *budgets.Plan) error {
+			plan.Rename(renamed.Old, renamed.New)
+			return nil
+		})
+		if err != nil {
+			return moved, err
+		}
+		pending, err := s.Expenses.List(ctx, profile.UserID, expenses.Query{Category: renamed.Old})
+		if err != nil {
+			return moved, err
+		}
+		for _, expense := range pending {
+			// Cuando solo cambian las mayúsculas o los acentos la consulta también regresa los ya movidos
+			if expense.Category == renamed.New {
+				continue
+			}
+			expense.Category = renamed.New
+			err := s.Expenses.Update(ctx, expense)
+			if errors.Is(err, expenses.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return moved, fmt.Errorf("renaming the category of expense %s: %w", expense.ID, err)
+			}
+			moved++
+		}
+		_, err = categorize.Update(ctx, s.Categories, profile.UserID, func(profile *categorize.Profile) error {
+			profile.Done(renamed)
+			return nil
+		})
+		if err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
+
+// handleRemoveCategory elimina una categoría con sus reglas y sus presupuestos, los gastos ya registrados la conservan
+func (s *Services) handleRemoveCategory(ctx context.Context, req *bot.Request) error {
+	var removed string
+	_, err := categorize.Update(ctx, s.Categories, req.UserID(), func(profile *categorize.Profile) error {
+		var err error
+		removed, err = profile.Remove(req.Arg(0))
+		return err
+	})
+	if text := categoryError(err); text != "" {
+		return req.Reply(ctx, text)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = budgets.Update(ctx, s.Budgets, req.UserID(), func(plan *budgets.Plan) error {
+		plan.Clear(removed, "")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return req.Reply(ctx, fmt.Sprintf("Categoría %s eliminada, los gastos ya registrados la conservan.", removed))
+}
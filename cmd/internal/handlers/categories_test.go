@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/budgets"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/categorize"
+)
+
+// failingRepository falla la actualización número failAt, como un Lambda que se interrumpe
+type failingRepository struct {
+	expenses.Repository
+	updates, failAt int
+}
+
+func (r *failingRepository) Update(ctx context.Context, expense *expenses.Expense) error {
+	r.updates++
+	if r.updates == r.failAt {
+		return errors.New("interrupted")
+	}
+	return r.Repository.Update(ctx, expense)
+}
+
+func TestFinishRenamesResumes(t *testing.T) {
+	ctx := context.Background()
+	services := NewMemoryServices(time.UTC)
+	repository := &failingRepository{Repository: services.Expenses, failAt: 2}
+	services.Expenses = repository
+
+	for day := 1; day <= 3; day++ {
+		expense := &expenses.Expense{UserID: 42, ChatID: 42, Amount: 1000, Currency: expenses.DefaultCurrency, Category: "Comida", Date: time.Date(2024, time.March, day, 0, 0, 0, 0, time.UTC)}
+		if err := services.Expenses.Create(ctx, expense); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	_, err := budgets.Update(ctx, services.Budgets, 42, func(plan *budgets.Plan) error {
+		return plan.Set(budgets.Budget{Category: "Comida", Period: budgets.Monthly, Limit: 100000, Currency: expenses.DefaultCurrency})
+	})
+	if err != nil {
+		t.Fatalf("budgets.Update() error = %v", err)
+	}
+	profile, err := categorize.Update(ctx, services.Categories, 42, func(profile *categorize.Profile) error {
+		_, err := profile.Rename("comida", "Alimentos")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("categorize.Update() error = %v", err)
+	}
+
+	if moved, err := services.finishRenames(ctx, profile); err == nil || moved != 1 {
+		t.Fatalf("finishRenames() = %d, %v, want 1 moved and the interruption", moved, err)
+	}
+	profile, _ = services.Categories.Load(ctx, 42)
+	if len(profile.Renames) != 1 {
+		t.Fatalf("renames after the interruption = %+v, want the rename pending", profile.Renames)
+	}
+
+	moved, err := services.finishRenames(ctx, profile)
+	if err != nil || moved != 2 {
+		t.Fatalf("finishRenames() resumed = %d, %v, want the other 2 moved", moved, err)
+	}
+	if profile, _ = services.Categories.Load(ctx, 42); len(profile.Renames) != 0 {
+		t.Errorf("renames = %+v, want none", profile.Renames)
+	}
+	list, _ := services.Expenses.List(ctx, 42, expenses.Query{})
+	for _, expense := range list {
+		if expense.Category != "Alimentos" {
+			t.Errorf("category of %s = %s, want Alimentos", expense.ID, expense.Category)
+		}
+	}
+	if plan, _ := services.Budgets.Load(ctx, 42); len(plan.Of("Alimentos")) != 1 {
+		t.Errorf("budgets = %+v, want the budget of Alimentos", plan.Budgets)
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/bot"
+	"github.com/betofloresbaca/expenses-manager/pkg/chart"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/reports"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// chartUsage explica los subcomandos de /chart
+const chartUsage = "Usa /chart month para ver tus gastos del mes por categoría, /chart year para ver los de cada mes del año o /chart category <categoría> para ver una categoría en los últimos 12 meses."
+
+// monthNames son los meses en español, enero es el primero
+var monthNames = []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio",
+	"agosto", "septiembre", "octubre", "noviembre", "diciembre"}
+
+// handleChart atiende /chart month|year|category <categoría> y responde con la gráfica como foto
+func (s *Services) handleChart(ctx context.Context, req *bot.Request) error {
+	now := time.Now().In(s.Location)
+	var graph *chart.Chart
+	var err error
+	switch strings.ToLower(req.Arg(0)) {
+	case "month", "mes":
+		graph, err = s.monthChart(ctx, req.UserID(), now)
+	case "year", "año", "ano":
+		graph, err = s.yearChart(ctx, req.UserID(), now)
+	case "category", "categoria", "categoría":
+		if len(req.Args) < 2 {
+			return req.Reply(ctx, chartUsage)
+		}
+		profile, loadErr := s.Categories.Load(ctx, req.UserID())
+		if loadErr != nil {
+			return loadErr
+		}
+		category, ok := profile.Find(req.Rest(1))
+		if !ok {
+			return req.Reply(ctx, "No tienes esa categoría, usa /categories para ver tus categorías.")
+		}
+		graph, err = s.categoryChart(ctx, req.UserID(), category, now)
+	default:
+		return req.Reply(ctx, chartUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(graph.Items) == 0 {
+		return req.Reply(ctx, "No tienes gastos para graficar en ese periodo.")
+	}
+	// La gráfica tarda en dibujarse y subirse, el usuario ve que se está enviando
+	if err := req.Client.SendChatAction(ctx, &telegram.SendChatActionRequest{ChatID: req.ChatID(), Action: telegram.ChatActionUploadPhoto}); err != nil {
+		log.Println("Error sending chat action:", err)
+	}
+	var image bytes.Buffer
+	if err := graph.WritePNG(&image); errors.Is(err, chart.ErrNoData) {
+		return req.Reply(ctx, "No tienes gastos para graficar en ese periodo.")
+	} else if err != nil {
+		return err
+	}
+	var total float64
+	for _, item := range graph.Items {
+		total += item.Value
+	}
+	_, err = req.Client.SendPhoto(ctx, &telegram.SendPhotoRequest{
+		ChatID:  req.ChatID(),
+		Photo:   telegram.FileFromReader("chart.png", &image),
+		Caption: fmt.Sprintf("%s: %s", graph.Title, expenses.FormatAmount(minorUnits(total), expenses.DefaultCurrency)),
+	})
+	return err
+}
+
+// monthChart reparte por categoría los gastos del mes en curso
+func (s *Services) monthChart(ctx context.Context, userID int64, now time.Time) (*chart.Chart, error) {
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	list, err := s.Expenses.List(ctx, userID, expenses.Query{From: from, To: from.AddDate(0, 1, 0)})
+	if err != nil {
+		return nil, err
+	}
+	graph := &chart.Chart{
+		Type:   chart.Pie,
+		Title:  fmt.Sprintf("Gastos de %s %d", monthNames[now.Month()-1], now.Year()),
+		Format: chartAmount,
+		Others: "Otras",
+	}
+	for _, total := range reports.ByCategory(list, expenses.DefaultCurrency) {
+		graph.Items = append(graph.Items, chart.Item{Label: total.Name, Value: majorUnits(total.Total)})
+	}
+	return graph, nil
+}
+
+// yearChart muestra los gastos de cada mes del año en curso, hasta el mes actual
+func (s *Services) yearChart(ctx context.Context, userID int64, now time.Time) (*chart.Chart, error) {
+	from := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+	graph, err := s.monthlyChart(ctx, userID, "", from, int(now.Month()))
+	if err != nil {
+		return nil, err
+	}
+	graph.Type = chart.Bar
+	graph.Title = fmt.Sprintf("Gastos de %d", now.Year())
+	return graph, nil
+}
+
+// categoryChart muestra los gastos de category en cada uno de los últimos 12 meses
+func (s *Services) categoryChart(ctx context.Context, userID int64, category string, now time.Time) (*chart.Chart, error) {
+	from := time.Date(now.Year(), now.Month()-11, 1, 0, 0, 0, 0, now.Location())
+	graph, err := s.monthlyChart(ctx, userID, category, from, 12)
+	if err != nil {
+		return nil, err
+	}
+	graph.Type = chart.Line
+	graph.Title = fmt.Sprintf("%s en los últimos 12 meses", category)
+	return graph, nil
+}
+
+// monthlyChart suma los gastos de cada mes desde from, de una categoría o de todas si está vacía
+func (s *Services) monthlyChart(ctx context.Context, userID int64, category string, from time.Time, months int) (*chart.Chart, error) {
+	list, err := s.Expenses.List(ctx, userID, expenses.Query{From: from, To: from.AddDate(0, months, 0), Category: category})
+	if err != nil {
+		return nil, err
+	}
+	graph := &chart.Chart{Format: chartAmount}
+	var total int64
+	for i, amount := range reports.ByMonth(list, expenses.DefaultCurrency, from, months) {
+		month := from.AddDate(0, i, 0).Month()
+		graph.Items = append(graph.Items, chart.Item{Label: strings.ToUpper(monthNames[month-1][:1]) + monthNames[month-1][1:3], Value: majorUnits(amount)})
+		total += amount
+	}
+	if total == 0 {
+		// Una gráfica en ceros no dice nada
+		graph.Items = nil
+	}
+	return graph, nil
+}
+
+// majorUnits convierte un monto en unidades menores de expenses.DefaultCurrency (centavos) a unidades mayores (pesos)
+func majorUnits(amount int64) float64 {
+	return float64(amount) / math.Pow10(expenses.Exponent(expenses.DefaultCurrency))
+}
+
+// minorUnits es la inversa de majorUnits, redondea a la unidad menor
+func minorUnits(value float64) int64 {
+	return int64(math.Round(value * math.Pow10(expenses.Exponent(expenses.DefaultCurrency))))
+}
+
+// chartAmount da formato a los pesos de las gráficas, sin moneda ni centavos en ceros para que los ejes sean legibles
+func chartAmount(value float64) string {
+	formatted := strings.TrimSuffix(expenses.FormatAmount(minorUnits(value), expenses.DefaultCurrency), " "+expenses.DefaultCurrency)
+	if exponent := expenses.Exponent(expenses.DefaultCurrency); exponent > 0 {
+		formatted = strings.TrimSuffix(formatted, "."+strings.Repeat("0", exponent))
+	}
+	return formatted
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/bot"
+	"github.com/betofloresbaca/expenses-manager/pkg/conversation"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// CommandResponse es la respuesta de telegram-command-handler.
+// Command es el comando recibido y Handled indica si está registrado en el router
+// o si el mensaje fue la respuesta de una conversación en curso (Conversation).
+// Callback es la acción del botón presionado, para los callback_query.
+// ExpenseID es el gasto registrado, si hubo, y UserID, ChatID y ChatType de quién, para evaluar sus presupuestos.
+type CommandResponse struct {
+	Ok           bool   `json:"Ok"`
+	Command      string `json:"Command,omitempty"`
+	Callback     string `json:"Callback,omitempty"`
+	Conversation bool   `json:"Conversation,omitempty"`
+	Handled      bool   `json:"Handled"`
+	ExpenseID    string `json:"ExpenseID,omitempty"`
+	UserID       int64  `json:"UserID,omitempty"`
+	ChatID       int64  `json:"ChatID,omitempty"`
+	ChatType     string `json:"ChatType,omitempty"`
+}
+
+// record anota el gasto registrado en la respuesta, la máquina de estados lo pasa a budget-evaluator
+func (r *CommandResponse) record(expenseID string, userID, chatID int64, chat *telegram.Chat) {
+	r.ExpenseID, r.UserID, r.ChatID = expenseID, userID, chatID
+	if chat != nil && chat.ID == chatID {
+		r.ChatType = chat.Type
+	}
+}
+
+// botTexts traduce los textos del router para los usuarios con Telegram en inglés,
+// los demás idiomas usan los textos en español de bot.DefaultTexts
+var botTexts = bot.Catalog{
+	"en": {
+		bot.TextUnknownCommand: "Unsupported command, use /help to see the available commands.",
+		bot.TextUsage:          "Usage: %s",
+		bot.TextWrongChatType:  "This command is not available in this chat.",
+		bot.TextForbidden:      "You are not allowed to use this bot.",
+		bot.TextHelpHeader:     "Available commands:",
+	},
+}
+
+// newRouter registra los comandos del bot
+func (s *Services) newRouter(client *telegram.Client, conversations *conversation.Manager) *bot.Router {
+	router := bot.NewRouter(client, s.botUsername)
+	router.Use(bot.Logging(), bot.I18n(botTexts, "es"))
+
+	router.Handle(bot.Command{
+		Name:        "start",
+		Description: "Inicia el bot",
+		Handler: func(ctx context.Context, req *bot.Request) error {
+			return req.Reply(ctx, "Bienvenido al chatbot de gastos!")
+		},
+	})
+	// /portfolio está reservado y no responde nada, como en el estado "Command Type" de la máquina de estados.
+	// Sin descripción no aparece en la ayuda ni en el menú
+	router.Handle(bot.Command{
+		Name: "portfolio",
+		Handler: func(ctx context.Context, req *bot.Request) error {
+			return nil
+		},
+	})
+	router.Handle(bot.Command{
+		Name:        "help",
+		Aliases:     []string{"ayuda"},
+		Description: "Muestra los comandos disponibles",
+		Handler:     router.Help(),
+	})
+	router.Handle(bot.Command{
+		Name:        "gasto",
+		Description: "Captura un gasto, por ejemplo /gasto 23.50 tacos #comida ayer",
+		Handler: func(ctx context.Context, req *bot.Request) error {
+			key := conversation.KeyOf(req.Message)
+			if len(req.Args) == 0 {
+				return s.startExpenseFlow(ctx, conversations, key, req.Message.MessageID, map[string]string{"payer": expenses.PayerOf(req.Message.From)}, nil, "")
+			}
+			expense, err := s.parser().ParseMessage(req.Message)
+			if err != nil {
+				return req.Reply(ctx, "No entendí el monto del gasto, escríbelo por ejemplo como /gasto 23.50 tacos #comida ayer")
+			}
+			return s.startExpenseFlow(ctx, conversations, key, req.Message.MessageID, expenseData(expense), expense, "")
+		},
+	})
+	router.Handle(bot.Command{
+		Name:        "categories",
+		Aliases:     []string{"categorias"},
+		Description: "Muestra tus categorías y sus reglas",
+		Handler:     s.handleCategories,
+	})
+	router.Handle(bot.Command{
+		Name:        "addcategory",
+		Aliases:     []string{"agregarcategoria"},
+		Description: "Agrega una categoría o reglas, por ejemplo /addcategory Café starbucks, #cafe, <100",
+		Usage:       "<categoría> [palabra, #etiqueta, <monto, >monto, mínimo-máximo...]",
+		MinArgs:     1,
+		Handler:     s.handleAddCategory,
+	})
+	router.Handle(bot.Command{
+		Name:        "rename",
+		Aliases:     []string{"renombrar"},
+		Description: "Cambia el nombre de una categoría",
+		Usage:       "<categoría> <nuevo nombre>",
+		MinArgs:     2,
+		MaxArgs:     2,
+		Handler:     s.handleRename,
+	})
+	router.Handle(bot.Command{
+		Name:        "removecategory",
+		Aliases:     []string{"eliminarcategoria"},
+		Description: "Elimina una categoría con sus reglas y presupuestos",
+		Usage:       "<categoría>",
+		MinArgs:     1,
+		MaxArgs:     1,
+		Handler:     s.handleRemoveCategory,
+	})
+	router.Handle(bot.Command{
+		Name:        "budget",
+		Aliases:     []string{"presupuesto"},
+		Description: "Fija, muestra o elimina presupuestos por categoría",
+		Usage:       "set <categoría> <monto> [mensual|semanal] [50,80,100] | show | clear <categoría> [mensual|semanal]",
+		Handler:     s.handleBudget,
+	})
+	router.Handle(bot.Command{
+		Name:        "reports",
+		Aliases:     []string{"reportes"},
+		Description: "Suscríbete a resúmenes diarios, semanales o mensuales de tus gastos",
+		Usage:       "diario|semanal|mensual si|no | hora <0-23> | show",
+		Handler:     s.handleReports,
+	})
+	router.Handle(bot.Command{
+		Name:        "timezone",
+		Aliases:     []string{"zonahoraria"},
+		Description: "Muestra o cambia tu zona horaria, en ella empiezan los periodos de tus presupuestos",
+		Usage:       "[zona, por ejemplo America/Mexico_City]",
+		MaxArgs:     1,
+		Handler:     s.handleTimeZone,
+	})
+	router.Handle(bot.Command{
+		Name:        "chart",
+		Aliases:     []string{"grafica"},
+		Description: "Grafica tus gastos del mes, del año o de una categoría",
+		Usage:       "month | year | category <categoría>",
+		Handler:     s.handleChart,
+	})
+	router.Handle(bot.Command{
+		Name:        "cancelar",
+		Aliases:     []string{"cancel"},
+		Description: "Cancela la operación en curso",
+		Handler: func(ctx context.Context, req *bot.Request) error {
+			return req.Reply(ctx, "No hay ninguna operación en curso.")
+		},
+	})
+	return router
+}
+
+// HandleUpdate atiende los mensajes de texto y los botones presionados, los updates que
+// telegram-command-handler recibe de la máquina de estados
+func (s *Services) HandleUpdate(ctx context.Context, client *telegram.Client, update *telegram.Update) (CommandResponse, error) {
+	// El limitador aplica los límites del tipo real del chat del update
+	ctx = telegram.WithChat(ctx, update.Chat())
+
+	if s.botUsername == "" {
+		if me, err := client.GetMe(ctx); err != nil {
+			log.Println("Error getting bot username:", err)
+		} else {
+			s.botUsername = me.Username
+		}
+	}
+	conversations := conversation.NewManager(client, s.Conversations)
+	conversations.Register(s.expenseFlow())
+	router := s.newRouter(client, conversations)
+
+	// Un error al sincronizar el menú no debe impedir responder al comando
+	if !s.commandsSynced {
+		if err := router.SyncCommands(ctx); err != nil {
+			log.Println("Error syncing bot commands:", err)
+		} else {
+			s.commandsSynced = true
+		}
+	}
+
+	response, err := s.route(ctx, client, router, conversations, update)
+	if err != nil {
+		return CommandResponse{Ok: false}, err
+	}
+	return response, nil
+}
+
+// route entrega el mensaje a la conversación en curso del usuario o, si no hay, al router de comandos.
+// Los botones presionados (callback_query) son los de los borradores de los tickets
+func (s *Services) route(ctx context.Context, client *telegram.Client, router *bot.Router, conversations *conversation.Manager, update *telegram.Update) (CommandResponse, error) {
+	if update.CallbackQuery != nil {
+		action, recorded, err := s.handleDraftCallback(ctx, client, conversations, update.CallbackQuery)
+		response := CommandResponse{Ok: true, Callback: action, Handled: action != ""}
+		if recorded != nil {
+			response.record(recorded.ID, recorded.UserID, recorded.ChatID, update.Chat())
+		}
+		return response, err
+	}
+	if update.Message == nil {
+		return CommandResponse{Ok: true}, nil
+	}
+	handled, completed, err := conversations.HandleMessage(ctx, update.Message)
+	if err != nil || handled {
+		response := CommandResponse{Ok: true, Conversation: true, Handled: true}
+		if completed != nil && completed.Flow == expenseFlowName && completed.Data[recordedExpenseKey] != "" {
+			response.record(completed.Data[recordedExpenseKey], completed.Key.UserID, completed.Key.ChatID, update.Chat())
+		}
+		return response, err
+	}
+
+	response := CommandResponse{Ok: true}
+	if command, ok := router.Match(update); ok {
+		invocation, _ := update.Message.Command()
+		response.Command = invocation.Name
+		response.Handled = command != nil
+	}
+	return response, router.HandleUpdate(ctx, update)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/conversation"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// handleDraftCallback atiende los botones Confirmar, Editar y Descartar del borrador de un ticket.
+// Los botones se firman para el usuario del borrador, que además se busca con el usuario que presionó
+// el botón, nadie más puede afectarlos.
+// Regresa la acción del botón y, si se confirmó el borrador, el gasto registrado
+func (s *Services) handleDraftCallback(ctx context.Context, client *telegram.Client, conversations *conversation.Manager, query *telegram.CallbackQuery) (string, *expenses.Expense, error) {
+	answer := func(text string, alert bool) error {
+		return client.AnswerCallbackQuery(ctx, &telegram.AnswerCallbackQueryRequest{CallbackQueryID: query.ID, Text: text, ShowAlert: alert})
+	}
+	data, err := s.Callbacks.Decode(query.From.ID, query.Data)
+	if err != nil || !strings.HasPrefix(data.Action, "draft.") || len(data.Args) != 1 || query.Message == nil {
+		return "", nil, answer("", false)
+	}
+
+	draft, err := s.Expenses.Get(ctx, query.From.ID, data.Args[0])
+	if errors.Is(err, expenses.ErrNotFound) || err == nil && !draft.IsDraft() {
+		return data.Action, nil, answer("Este borrador ya no existe.", true)
+	}
+	if err != nil {
+		return data.Action, nil, err
+	}
+
+	// Al terminar la captura se actualiza el borrador en lugar de crear otro gasto
+	key := conversation.Key{ChatID: query.Message.Chat.ID, UserID: query.From.ID}
+	// El borrador responde a la foto del ticket, las preguntas también para que en grupos solo el usuario vea el teclado
+	var replyTo int64
+	if photo := query.Message.ReplyToMessage; photo != nil && photo.From != nil && photo.From.ID == query.From.ID {
+		replyTo = photo.MessageID
+	}
+	editDraft := func(keepAmount bool) error {
+		values := expenseData(draft)
+		delete(values, "category")
+		if !keepAmount {
+			delete(values, "amount")
+		}
+		values["draft_id"] = draft.ID
+		return s.startExpenseFlow(ctx, conversations, key, replyTo, values, nil, draft.Category)
+	}
+	editMessage := func(text string) error {
+		_, err := client.EditMessageText(ctx, &telegram.EditMessageTextRequest{
+			ChatID:    query.Message.Chat.ID,
+			MessageID: query.Message.MessageID,
+			Text:      text,
+		})
+		return err
+	}
+
+	switch data.Action {
+	case expenses.ActionConfirmDraft:
+		if draft.Amount == 0 {
+			return data.Action, nil, answer("Falta el monto, usa Editar para capturarlo.", true)
+		}
+		if draft.Category == "" {
+			if err := answer("Elige la categoría del gasto.", false); err != nil {
+				return data.Action, nil, err
+			}
+			return data.Action, nil, editDraft(true)
+		}
+		draft.ExpiresAt = time.Time{}
+		if err := s.Expenses.Update(ctx, draft); err != nil {
+			return data.Action, nil, err
+		}
+		s.learnDraftCategory(ctx, draft)
+		if err := answer("Gasto registrado.", false); err != nil {
+			return data.Action, draft, err
+		}
+		return data.Action, draft, editMessage("Gasto de " + draft.Summary() + " registrado.")
+	case expenses.ActionEditDraft:
+		if err := answer("", false); err != nil {
+			return data.Action, nil, err
+		}
+		return data.Action, nil, editDraft(false)
+	case expenses.ActionDiscardDraft:
+		if err := s.Expenses.Delete(ctx, draft.UserID, draft.ID); err != nil {
+			return data.Action, nil, err
+		}
+		if draft.ReceiptKey != "" {
+			if err := s.Receipts.DeleteObject(ctx, s.ReceiptsBucket, draft.ReceiptKey); err != nil {
+				log.Println("Error deleting receipt:", err)
+			}
+		}
+		if err := answer("Borrador descartado.", false); err != nil {
+			return data.Action, nil, err
+		}
+		return data.Action, nil, editMessage("Borrador descartado.")
+	}
+	return data.Action, nil, answer("", false)
+}
+
+// learnDraftCategory aprende la categoría de un borrador confirmado cuando las reglas ya no la sugieren,
+// para que los siguientes tickets del comercio la conserven
+func (s *Services) learnDraftCategory(ctx context.Context, draft *expenses.Expense) {
+	if draft.Merchant == "" {
+		return
+	}
+	profile, err := s.Categories.Load(ctx, draft.UserID)
+	if err != nil {
+		log.Println("Error loading categories:", err)
+		return
+	}
+	suggested, _ := profile.Categorize(draft)
+	s.learnCategory(ctx, draft, suggested)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/textract"
+	"github.com/betofloresbaca/expenses-manager/pkg/clients"
+	"github.com/betofloresbaca/expenses-manager/pkg/conversation"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/budgets"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/categorize"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/reports"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// MustGetenv regresa la variable de entorno name. Si no está configurada el Lambda no arranca,
+// en lugar de guardar los datos en memoria y perderlos: NewMemoryServices es solo para el bot local y las pruebas
+func MustGetenv(name string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		panic(name + " is not set")
+	}
+	return value
+}
+
+func dynamoDBClient() *dynamodb.Client {
+	return clients.GetClient(func(cfg aws.Config) *dynamodb.Client {
+		return dynamodb.NewFromConfig(cfg)
+	})
+}
+
+// rateLimitShards es el número de items entre los que se reparte el límite global en DynamoDB
+const rateLimitShards = 8
+
+// NewRateLimiter usa la tabla RATE_LIMIT_TABLE para compartir el estado entre instancias,
+// o un estado en memoria si no está configurada
+func NewRateLimiter() *telegram.RateLimiter {
+	tableName := os.Getenv("RATE_LIMIT_TABLE")
+	if tableName == "" {
+		return telegram.NewRateLimiter(telegram.NewMemoryBucketStore())
+	}
+	limiter := telegram.NewRateLimiter(telegram.NewDynamoDBBucketStore(dynamoDBClient(), tableName))
+	// Repartir el límite global en varios items para no saturar una sola partición
+	limiter.GlobalShards = rateLimitShards
+	return limiter
+}
+
+// NewExpenseRepository usa la tabla EXPENSES_TABLE
+func NewExpenseRepository() expenses.Repository {
+	return expenses.NewDynamoDBRepository(dynamoDBClient(), MustGetenv("EXPENSES_TABLE"))
+}
+
+// NewCategoryStore usa la tabla CATEGORIES_TABLE
+func NewCategoryStore() categorize.Store {
+	return categorize.NewDynamoDBStore(dynamoDBClient(), MustGetenv("CATEGORIES_TABLE"))
+}
+
+// NewBudgetStore usa la tabla BUDGETS_TABLE
+func NewBudgetStore() budgets.Store {
+	return budgets.NewDynamoDBStore(dynamoDBClient(), MustGetenv("BUDGETS_TABLE"))
+}
+
+// NewReportStore usa la tabla SETTINGS_TABLE, location es la zona horaria de los usuarios que no eligieron una
+func NewReportStore(location *time.Location) reports.Store {
+	return reports.NewDynamoDBStore(dynamoDBClient(), MustGetenv("SETTINGS_TABLE"), location)
+}
+
+// NewConversationStore usa la tabla CONVERSATIONS_TABLE
+func NewConversationStore() conversation.Store {
+	return conversation.NewDynamoDBStore(dynamoDBClient(), MustGetenv("CONVERSATIONS_TABLE"))
+}
+
+// NewReceiptExtractor lee los tickets con Textract, o con datos fijos si RECEIPT_EXTRACTOR es "stub"
+func NewReceiptExtractor(location *time.Location) expenses.ReceiptExtractor {
+	if os.Getenv("RECEIPT_EXTRACTOR") == "stub" {
+		return StubExtractor()
+	}
+	textractClient := clients.GetClient(func(cfg aws.Config) *textract.Client {
+		return textract.NewFromConfig(cfg)
+	})
+	extractor := expenses.NewTextractExtractor(textractClient)
+	extractor.Location = location
+	return extractor
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/conversation"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/categorize"
+)
+
+// changeCategoryText es la opción de la confirmación para corregir la categoría sugerida
+const changeCategoryText = "Cambiar categoría"
+
+// dateLayout es el formato de la fecha del gasto en los datos de la conversación
+const dateLayout = "2006-01-02"
+
+// expenseData convierte un gasto en los datos de la conversación, para saltar los pasos ya contestados
+func expenseData(expense *expenses.Expense) map[string]string {
+	data := map[string]string{
+		"amount":   strconv.FormatInt(expense.Amount, 10),
+		"currency": expense.Currency,
+		"date":     expense.Date.Format(dateLayout),
+		"tags":     strings.Join(expense.Tags, ","),
+		"merchant": expense.Merchant,
+		"notes":    expense.Notes,
+		"payer":    expense.Payer,
+	}
+	if expense.Category != "" {
+		data["category"] = expense.Category
+	}
+	return data
+}
+
+// startExpenseFlow inicia la captura de un gasto con las categorías del usuario. Si expense
+// no tiene categoría se sugiere una con las reglas del usuario, y si la cambia se aprende
+// en OnComplete. suggested es la categoría ya sugerida, por ejemplo la de un borrador.
+// Las preguntas responden al mensaje replyTo del usuario, si no es 0, para que en grupos solo él vea el teclado
+func (s *Services) startExpenseFlow(ctx context.Context, conversations *conversation.Manager, key conversation.Key, replyTo int64, data map[string]string, expense *expenses.Expense, suggested string) error {
+	profile, err := s.Categories.Load(ctx, key.UserID)
+	if err != nil {
+		return err
+	}
+	if expense != nil && data["category"] == "" {
+		suggested, _ = profile.Categorize(expense)
+		if suggested != "" {
+			data["category"] = suggested
+		}
+	}
+	data["suggested_category"] = suggested
+	data["categories"] = strings.Join(profile.Categories, ",")
+	return conversations.StartReply(ctx, key, replyTo, expenseFlowName, data)
+}
+
+// learnCategory aprende la categoría elegida para el comercio cuando no es la sugerida.
+// Es opcional, un error no impide registrar el gasto
+func (s *Services) learnCategory(ctx context.Context, expense *expenses.Expense, suggested string) {
+	if expense.Merchant == "" || strings.EqualFold(expense.Category, suggested) {
+		return
+	}
+	_, err := categorize.Update(ctx, s.Categories, expense.UserID, func(profile *categorize.Profile) error {
+		profile.Learn(expense, expense.Category)
+		return nil
+	})
+	if err != nil {
+		log.Println("Error learning category:", err)
+	}
+}
+
+// stateExpense reconstruye el gasto capturado en la conversación
+func (s *Services) stateExpense(state *conversation.State) (*expenses.Expense, error) {
+	amount, err := strconv.ParseInt(state.Data["amount"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q: %w", state.Data["amount"], err)
+	}
+	expense := &expenses.Expense{
+		UserID:   state.Key.UserID,
+		ChatID:   state.Key.ChatID,
+		Amount:   amount,
+		Currency: state.Data["currency"],
+		Category: state.Data["category"],
+		Merchant: state.Data["merchant"],
+		Notes:    state.Data["notes"],
+		Payer:    state.Data["payer"],
+	}
+	if expense.Currency == "" {
+		expense.Currency = expenses.DefaultCurrency
+	}
+	if tags := state.Data["tags"]; tags != "" {
+		expense.Tags = strings.Split(tags, ",")
+	}
+	date := state.Data["date"]
+	if date == "" {
+		date = time.Now().In(s.Location).Format(dateLayout)
+	}
+	if expense.Date, err = time.ParseInLocation(dateLayout, date, s.Location); err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", date, err)
+	}
+	return expense, nil
+}
+
+// expenseFlowName es el nombre de la conversación que captura un gasto
+const expenseFlowName = "expense"
+
+// recordedExpenseKey es el dato de la conversación terminada con el ID del gasto registrado
+const recordedExpenseKey = "expense_id"
+
+// expenseFlow captura un gasto preguntando el monto, la categoría y una confirmación
+func (s *Services) expenseFlow() conversation.Flow {
+	return conversation.Flow{
+		Name: expenseFlowName,
+		Steps: []conversation.Step{
+			{
+				Name:        "amount",
+				Prompt:      "¿Cuánto gastaste?",
+				Placeholder: "150.50",
+				Validate: func(answer string, state *conversation.State) (string, error) {
+					currency := state.Data["currency"]
+					if currency == "" {
+						currency = expenses.DefaultCurrency
+					}
+					amount, err := expenses.ParseAmount(answer, currency)
+					if err != nil {
+						return "", errors.New("escribe el monto como un número mayor a cero, por ejemplo 150.50")
+					}
+					return strconv.FormatInt(amount, 10), nil
+				},
+			},
+			{
+				Name:   "category",
+				Prompt: "¿En qué categoría?",
+				OptionsFunc: func(state *conversation.State) []string {
+					if categories := state.Data["categories"]; categories != "" {
+						return strings.Split(categories, ",")
+					}
+					return categorize.DefaultCategories
+				},
+			},
+			{
+				Name: "confirm",
+				PromptFunc: func(state *conversation.State) string {
+					expense, err := s.stateExpense(state)
+					if err != nil {
+						return "¿Registrar el gasto?"
+					}
+					return fmt.Sprintf("¿Registrar un gasto de %s?", expense.Summary())
+				},
+				Options: []string{"Sí", changeCategoryText, "No"},
+				Validate: func(answer string, state *conversation.State) (string, error) {
+					if answer == "No" {
+						return "", conversation.ErrCancel
+					}
+					return answer, nil
+				},
+				Next: func(state *conversation.State) string {
+					if state.Data["confirm"] == changeCategoryText {
+						return "category"
+					}
+					return ""
+				},
+			},
+		},
+		OnComplete: func(ctx context.Context, state *conversation.State) (string, error) {
+			expense, err := s.stateExpense(state)
+			if err != nil {
+				return "", err
+			}
+			// Los gastos de un ticket (draft_id) actualizan su borrador, si aún existe
+			var draft *expenses.Expense
+			if draftID := state.Data["draft_id"]; draftID != "" {
+				if draft, err = s.Expenses.Get(ctx, expense.UserID, draftID); err != nil && !errors.Is(err, expenses.ErrNotFound) {
+					return "", err
+				}
+			}
+			if draft != nil && draft.IsDraft() {
+				expense.ID, expense.ReceiptKey, expense.CreatedAt, expense.Version = draft.ID, draft.ReceiptKey, draft.CreatedAt, draft.Version
+				err = s.Expenses.Update(ctx, expense)
+			} else {
+				err = s.Expenses.Create(ctx, expense)
+			}
+			if err != nil {
+				return "", err
+			}
+			// La respuesta del Lambda lleva el gasto registrado para evaluar los presupuestos
+			state.Data[recordedExpenseKey] = expense.ID
+			s.learnCategory(ctx, expense, state.Data["suggested_category"])
+			return fmt.Sprintf("Gasto de %s registrado.", expense.Summary()), nil
+		},
+	}
+}
@@ -0,0 +1,147 @@
+// Package handlers tiene el código de los Lambdas del bot. Los Lambdas lo usan con las
+// tablas de DynamoDB y el bucket de S3, y el bot local con gastos y preferencias en memoria.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/conversation"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/budgets"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/categorize"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/reports"
+	"github.com/betofloresbaca/expenses-manager/pkg/quick"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/keyboard"
+)
+
+// Services son las dependencias de los handlers, se conservan entre invocaciones del mismo contenedor
+type Services struct {
+	// Expenses guarda los gastos y los borradores de los tickets
+	Expenses expenses.Repository
+	// Categories guarda las categorías y reglas de cada usuario
+	Categories categorize.Store
+	// Budgets guarda los presupuestos y las alertas ya enviadas en cada periodo
+	Budgets budgets.Store
+	// Reports guarda los reportes a los que está suscrito cada usuario, junto a sus ajustes
+	Reports reports.Store
+	// Conversations guarda el estado de las conversaciones entre invocaciones
+	Conversations conversation.Store
+	// Receipts guarda las fotos de los tickets en el bucket ReceiptsBucket
+	Receipts       ObjectStore
+	ReceiptsBucket string
+	// Extractor lee los tickets guardados en Receipts
+	Extractor expenses.ReceiptExtractor
+	// Callbacks codifica los botones de los borradores, el usuario solo puede afectar sus propios gastos.
+	// Los Lambdas los firman con NewCallbackCodec
+	Callbacks *keyboard.CallbackCodec
+	// Location es la zona horaria de los usuarios, las fechas relativas y los periodos se calculan en ella
+	Location *time.Location
+
+	// botUsername se obtiene con getMe una vez, para ignorar los comandos dirigidos a otros bots
+	botUsername string
+	// commandsSynced evita actualizar el menú de comandos de Telegram en cada invocación,
+	// se actualiza una vez para que siempre coincida con el código desplegado
+	commandsSynced bool
+}
+
+// NewMemoryServices guarda todo en memoria y lee los tickets con datos fijos, para el bot local y las pruebas
+func NewMemoryServices(location *time.Location) *Services {
+	return &Services{
+		Expenses:      expenses.NewMemoryRepository(),
+		Categories:    categorize.NewMemoryStore(),
+		Budgets:       budgets.NewMemoryStore(),
+		Reports:       reports.NewMemoryStore(),
+		Conversations: conversation.NewMemoryStore(),
+		Receipts:      NewMemoryObjectStore(),
+		Extractor:     StubExtractor(),
+		Callbacks:     &keyboard.CallbackCodec{},
+		Location:      location,
+	}
+}
+
+// StubExtractor regresa siempre el mismo ticket, para probar el flujo sin Textract
+func StubExtractor() *expenses.StubExtractor {
+	return &expenses.StubExtractor{Receipt: expenses.Receipt{
+		Merchant: "Tienda de prueba",
+		Total:    12350,
+		Currency: expenses.DefaultCurrency,
+		Items:    []expenses.ReceiptItem{{Description: "Artículo de prueba", Price: 12350}},
+	}}
+}
+
+// NewCallbackCodec firma los botones con una clave derivada del token del bot, que los Lambdas
+// cargan de SSM, para que nadie más pueda crear botones que afecten los gastos
+func NewCallbackCodec(token string) *keyboard.CallbackCodec {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte("callback_data"))
+	return &keyboard.CallbackCodec{Key: mac.Sum(nil)}
+}
+
+// parser interpreta los gastos escritos como "/gasto 23.50 tacos #comida ayer" en la zona horaria de los usuarios
+func (s *Services) parser() *expenses.Parser {
+	return &expenses.Parser{Location: s.Location}
+}
+
+// ObjectStore guarda las fotos de los tickets
+type ObjectStore interface {
+	PutObject(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string) error
+	ObjectExists(ctx context.Context, bucket, key string) (bool, error)
+	DeleteObject(ctx context.Context, bucket, key string) error
+}
+
+// S3ObjectStore guarda las fotos en S3
+type S3ObjectStore struct{}
+
+func (S3ObjectStore) PutObject(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string) error {
+	return quick.PutObject(ctx, bucket, key, body, size, contentType)
+}
+
+func (S3ObjectStore) ObjectExists(ctx context.Context, bucket, key string) (bool, error) {
+	return quick.ObjectExists(ctx, bucket, key)
+}
+
+func (S3ObjectStore) DeleteObject(ctx context.Context, bucket, key string) error {
+	return quick.DeleteObject(ctx, bucket, key)
+}
+
+// MemoryObjectStore guarda las fotos en memoria
+type MemoryObjectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+// NewMemoryObjectStore crea un MemoryObjectStore vacío
+func NewMemoryObjectStore() *MemoryObjectStore {
+	return &MemoryObjectStore{objects: map[string][]byte{}}
+}
+
+func (m *MemoryObjectStore) PutObject(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string) error {
+	var content bytes.Buffer
+	if _, err := io.Copy(&content, body); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[bucket+"/"+key] = content.Bytes()
+	return nil
+}
+
+func (m *MemoryObjectStore) ObjectExists(ctx context.Context, bucket, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.objects[bucket+"/"+key]
+	return ok, nil
+}
+
+func (m *MemoryObjectStore) DeleteObject(ctx context.Context, bucket, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, bucket+"/"+key)
+	return nil
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"path"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// ReceiptResponse es la respuesta de telegram-receipt-processor.
+// ExpenseID es el borrador creado con el ticket y MessageID el mensaje con los botones para confirmarlo.
+type ReceiptResponse struct {
+	Ok        bool   `json:"Ok"`
+	ExpenseID string `json:"ExpenseID,omitempty"`
+	MessageID int64  `json:"MessageID,omitempty"`
+}
+
+// ProcessReceipt guarda la foto del ticket, la lee y responde con el borrador del gasto para confirmarlo.
+// Es el update con foto que telegram-receipt-processor recibe de la máquina de estados
+func (s *Services) ProcessReceipt(ctx context.Context, client *telegram.Client, update *telegram.Update) (ReceiptResponse, error) {
+	msg := update.Message
+	if msg == nil || len(msg.Photo) == 0 || msg.From == nil {
+		return ReceiptResponse{Ok: true}, nil
+	}
+	// El limitador aplica los límites del tipo real del chat del update
+	ctx = telegram.WithChat(ctx, msg.Chat)
+
+	reply := func(text string, markup telegram.ReplyMarkup) (*telegram.Message, error) {
+		return client.SendMessage(ctx, &telegram.SendMessageRequest{
+			ChatID:          msg.Chat.ID,
+			Text:            text,
+			ReplyParameters: &telegram.ReplyParameters{MessageID: int(msg.MessageID), AllowSendingWithoutReply: true},
+			ReplyMarkup:     markup,
+		})
+	}
+
+	// Leer el ticket tarda unos segundos
+	if err := client.SendChatAction(ctx, &telegram.SendChatActionRequest{ChatID: msg.Chat.ID, Action: telegram.ChatActionTyping}); err != nil {
+		log.Println("Error sending chat action:", err)
+	}
+
+	photo, ok := telegram.BestPhotoSize(msg.Photo, 0)
+	if !ok {
+		_, err := reply("La foto es demasiado grande, envíala con menor resolución.", nil)
+		return ReceiptResponse{Ok: err == nil}, err
+	}
+
+	// La máquina de estados reintenta el mensaje si algo falla. El borrador se identifica con el
+	// mensaje para no duplicarlo, y si ya existe no se vuelve a leer el ticket
+	year, month, day := time.Unix(msg.Date, 0).In(s.Location).Date()
+	sentOn := time.Date(year, month, day, 0, 0, 0, 0, s.Location)
+	id := expenses.DraftID(sentOn, msg.Chat.ID, msg.MessageID)
+	expense, err := s.Expenses.Get(ctx, msg.From.ID, id)
+	switch {
+	case errors.Is(err, expenses.ErrNotFound):
+		if expense, err = s.createDraft(ctx, client, msg, photo, id, sentOn); err != nil {
+			return ReceiptResponse{Ok: false}, err
+		}
+	case err != nil:
+		return ReceiptResponse{Ok: false}, err
+	case !expense.IsDraft():
+		// Se confirmó después de un intento anterior, ya tiene su respuesta
+		return ReceiptResponse{Ok: true, ExpenseID: expense.ID}, nil
+	}
+
+	text := fmt.Sprintf("Leí este gasto del ticket:\n%s\n\n¿Lo registro?", expense.Summary())
+	if expense.Amount == 0 {
+		text = "No pude leer el total del ticket, usa Editar para capturar el gasto."
+	}
+	sent, err := reply(text, expenses.DraftKeyboard(s.Callbacks, expense.UserID, expense.ID))
+	if err != nil {
+		return ReceiptResponse{Ok: false, ExpenseID: expense.ID}, err
+	}
+	return ReceiptResponse{Ok: true, ExpenseID: expense.ID, MessageID: sent.MessageID}, nil
+}
+
+// createDraft guarda la foto del ticket, la lee y crea el borrador id del mensaje enviado el día sentOn.
+// La foto de un intento anterior no se vuelve a subir
+func (s *Services) createDraft(ctx context.Context, client *telegram.Client, msg *telegram.Message, photo telegram.PhotoSize, id string, sentOn time.Time) (*expenses.Expense, error) {
+	// El texto de la foto se interpreta como un gasto, por ejemplo "23.50 #trabajo"
+	caption, captionErr := s.parser().ParseMessage(msg)
+	expense := &expenses.Expense{
+		ID:        id,
+		UserID:    msg.From.ID,
+		ChatID:    msg.Chat.ID,
+		Currency:  expenses.DefaultCurrency,
+		Date:      sentOn,
+		Payer:     expenses.PayerOf(msg.From),
+		Tags:      msg.Hashtags(),
+		ExpiresAt: time.Now().Add(expenses.DraftTTL),
+	}
+	if captionErr == nil {
+		expense.Date = caption.Date
+	}
+
+	file, err := client.GetFile(ctx, &telegram.GetFileRequest{FileID: photo.FileID})
+	if err != nil {
+		return nil, err
+	}
+	extension := path.Ext(file.FilePath)
+	if extension == "" {
+		extension = ".jpg"
+	}
+	expense.ReceiptKey = expenses.ReceiptObjectKey(expense.UserID, id, extension)
+	exists, err := s.Receipts.ObjectExists(ctx, s.ReceiptsBucket, expense.ReceiptKey)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := s.uploadReceipt(ctx, client, file, expense.ReceiptKey, mime.TypeByExtension(extension)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Si no se puede leer el ticket el usuario aún puede capturar el gasto con Editar
+	receipt, err := s.Extractor.Extract(ctx, s.ReceiptsBucket, expense.ReceiptKey)
+	if err != nil {
+		log.Println("Error extracting receipt:", err)
+	} else {
+		receipt.Apply(expense)
+	}
+	// Lo escrito por el usuario tiene prioridad sobre lo leído del ticket
+	if captionErr == nil {
+		expense.Amount, expense.Currency = caption.Amount, caption.Currency
+		if caption.Merchant != "" {
+			expense.Merchant = caption.Merchant
+		}
+		if caption.Notes != "" {
+			expense.Notes = caption.Notes
+		}
+	}
+	// Sin categoría el usuario la elige al confirmar
+	if profile, err := s.Categories.Load(ctx, expense.UserID); err != nil {
+		log.Println("Error loading categories:", err)
+	} else if category, ok := profile.Categorize(expense); ok {
+		expense.Category = category
+	}
+
+	// Create no reemplaza un borrador existente, si otro intento lo creó mientras tanto se usa ese
+	err = s.Expenses.Create(ctx, expense)
+	if errors.Is(err, expenses.ErrConflict) {
+		return s.Expenses.Get(ctx, expense.UserID, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return expense, nil
+}
+
+// uploadReceipt descarga la foto de Telegram y la guarda en key
+func (s *Services) uploadReceipt(ctx context.Context, client *telegram.Client, file *telegram.File, key, contentType string) error {
+	body, err := client.DownloadFile(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	size := file.FileSize
+	if size == 0 {
+		// PutObject necesita el tamaño, se lee completo (como máximo 20 MB)
+		content, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		return s.Receipts.PutObject(ctx, s.ReceiptsBucket, key, bytes.NewReader(content), int64(len(content)), contentType)
+	}
+	return s.Receipts.PutObject(ctx, s.ReceiptsBucket, key, body, size, contentType)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/keyboard"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/telegramtest"
+)
+
+// countingObjects anota las fotos subidas a un MemoryObjectStore
+type countingObjects struct {
+	*MemoryObjectStore
+	puts         int
+	contentTypes []string
+}
+
+func (c *countingObjects) PutObject(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string) error {
+	c.puts++
+	c.contentTypes = append(c.contentTypes, contentType)
+	return c.MemoryObjectStore.PutObject(ctx, bucket, key, body, size, contentType)
+}
+
+// countingExtractor cuenta los tickets leídos
+type countingExtractor struct {
+	expenses.ReceiptExtractor
+	calls int
+}
+
+func (c *countingExtractor) Extract(ctx context.Context, bucket, key string) (*expenses.Receipt, error) {
+	c.calls++
+	return c.ReceiptExtractor.Extract(ctx, bucket, key)
+}
+
+// newReceiptTest crea los servicios en memoria con las fotos y los tickets leídos contados
+func newReceiptTest(t *testing.T) (*telegramtest.Server, *Services, *countingObjects, *countingExtractor) {
+	t.Helper()
+	server := telegramtest.NewServer()
+	t.Cleanup(server.Close)
+	services := NewMemoryServices(time.UTC)
+	objects := &countingObjects{MemoryObjectStore: NewMemoryObjectStore()}
+	extractor := &countingExtractor{ReceiptExtractor: StubExtractor()}
+	services.Receipts, services.Extractor = objects, extractor
+	services.ReceiptsBucket = "receipts"
+	services.Callbacks = NewCallbackCodec(telegramtest.Token)
+	return server, services, objects, extractor
+}
+
+// injectPhoto envía la foto name como si el usuario la mandara al chat privado con el bot
+func injectPhoto(server *telegramtest.Server, name string) telegram.Update {
+	fileID := server.AddFile(name, []byte("picture"))
+	return server.InjectUpdate(telegram.Update{Message: &telegram.Message{
+		From:  &telegramtest.User,
+		Chat:  telegram.ChatFromID(telegramtest.User.ID),
+		Date:  time.Date(2024, time.March, 20, 18, 0, 0, 0, time.UTC).Unix(),
+		Photo: []telegram.PhotoSize{{FileID: fileID, Width: 800, Height: 600}},
+	}})
+}
+
+func TestProcessReceiptRetry(t *testing.T) {
+	server, services, objects, extractor := newReceiptTest(t)
+	ctx := context.Background()
+	update := injectPhoto(server, "ticket.png")
+
+	// La respuesta falla después de crear el borrador, la máquina de estados reintenta el mensaje
+	server.FailNext("sendMessage", telegramtest.BlockedByUser())
+	if _, err := services.ProcessReceipt(ctx, server.Client(), &update); err == nil {
+		t.Fatal("ProcessReceipt() error = nil, want the failed reply")
+	}
+	response, err := services.ProcessReceipt(ctx, server.Client(), &update)
+	if err != nil {
+		t.Fatalf("ProcessReceipt() retry error = %v", err)
+	}
+
+	list, err := services.Expenses.List(ctx, telegramtest.User.ID, expenses.Query{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 1 || list[0].ID != response.ExpenseID || !list[0].IsDraft() {
+		t.Fatalf("expenses = %+v, want only the draft %s", list, response.ExpenseID)
+	}
+	if want := expenses.DraftID(time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC), update.Message.Chat.ID, update.Message.MessageID); response.ExpenseID != want {
+		t.Errorf("ExpenseID = %s, want %s", response.ExpenseID, want)
+	}
+	if want := expenses.ReceiptObjectKey(telegramtest.User.ID, response.ExpenseID, ".png"); list[0].ReceiptKey != want {
+		t.Errorf("ReceiptKey = %s, want %s", list[0].ReceiptKey, want)
+	}
+	if objects.puts != 1 || objects.contentTypes[0] != "image/png" {
+		t.Errorf("uploads = %d with content types %q, want 1 image/png", objects.puts, objects.contentTypes)
+	}
+	if extractor.calls != 1 {
+		t.Errorf("Extract() calls = %d, want 1", extractor.calls)
+	}
+	if sent, ok := server.Message(update.Message.Chat.ID, response.MessageID); !ok || sent.ReplyMarkup == nil {
+		t.Errorf("reply = %+v, want the draft with its buttons", sent)
+	}
+}
+
+func TestProcessReceiptUploadedBefore(t *testing.T) {
+	server, services, objects, extractor := newReceiptTest(t)
+	ctx := context.Background()
+	update := injectPhoto(server, "ticket.jpg")
+
+	// Un intento anterior subió la foto pero no creó el borrador
+	id := expenses.DraftID(time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC), update.Message.Chat.ID, update.Message.MessageID)
+	key := expenses.ReceiptObjectKey(telegramtest.User.ID, id, ".jpg")
+	if err := objects.MemoryObjectStore.PutObject(ctx, "receipts", key, strings.NewReader("picture"), 7, "image/jpeg"); err != nil {
+		t.Fatalf("PutObject() error = %v", err)
+	}
+
+	response, err := services.ProcessReceipt(ctx, server.Client(), &update)
+	if err != nil {
+		t.Fatalf("ProcessReceipt() error = %v", err)
+	}
+	if objects.puts != 0 {
+		t.Errorf("uploads = %d, want 0", objects.puts)
+	}
+	if extractor.calls != 1 {
+		t.Errorf("Extract() calls = %d, want 1", extractor.calls)
+	}
+	draft, err := services.Expenses.Get(ctx, telegramtest.User.ID, response.ExpenseID)
+	if err != nil || draft.ReceiptKey != key || draft.Amount != 12350 {
+		t.Errorf("Get() = %+v, %v, want the draft of the stub receipt at %s", draft, err, key)
+	}
+}
+
+func TestProcessReceiptSignsButtons(t *testing.T) {
+	server, services, _, _ := newReceiptTest(t)
+	ctx := context.Background()
+	update := injectPhoto(server, "ticket.jpg")
+
+	response, err := services.ProcessReceipt(ctx, server.Client(), &update)
+	if err != nil {
+		t.Fatalf("ProcessReceipt() error = %v", err)
+	}
+	sent, ok := server.Message(update.Message.Chat.ID, response.MessageID)
+	if !ok || sent.ReplyMarkup == nil || len(sent.ReplyMarkup.InlineKeyboard) == 0 {
+		t.Fatalf("reply = %+v, want the draft buttons", sent)
+	}
+	data := sent.ReplyMarkup.InlineKeyboard[0][0].CallbackData
+
+	decoded, err := NewCallbackCodec(telegramtest.Token).Decode(telegramtest.User.ID, data)
+	if err != nil || decoded.Action != expenses.ActionConfirmDraft || decoded.Args[0] != response.ExpenseID {
+		t.Errorf("Decode(%q) = %+v, %v, want the confirmation of %s", data, decoded, err, response.ExpenseID)
+	}
+	if _, err := NewCallbackCodec("another-token").Decode(telegramtest.User.ID, data); err == nil {
+		t.Errorf("Decode(%q) with another key error = nil, want the signature rejected", data)
+	}
+	if _, err := services.Callbacks.Decode(telegramtest.User.ID+1, data); err == nil {
+		t.Errorf("Decode(%q) for another user error = nil, want the signature rejected", data)
+	}
+	forged, _ := (&keyboard.CallbackCodec{}).Encode(telegramtest.User.ID, keyboard.NewCallbackData(expenses.ActionDiscardDraft, response.ExpenseID))
+	if _, err := services.Callbacks.Decode(telegramtest.User.ID, forged); err == nil {
+		t.Errorf("Decode(%q) of unsigned data error = nil, want it rejected", forged)
+	}
+}
+
+func TestDraftButtonsOfAnotherUser(t *testing.T) {
+	server, services, _, _ := newReceiptTest(t)
+	ctx := context.Background()
+	update := injectPhoto(server, "ticket.jpg")
+	response, err := services.ProcessReceipt(ctx, server.Client(), &update)
+	if err != nil {
+		t.Fatalf("ProcessReceipt() error = %v", err)
+	}
+	sent, _ := server.Message(update.Message.Chat.ID, response.MessageID)
+	data := sent.ReplyMarkup.InlineKeyboard[1][0].CallbackData
+
+	// Otro usuario presiona el botón, por ejemplo si el mensaje se reenvió con sus botones
+	other := telegram.User{ID: telegramtest.User.ID + 1, FirstName: "Otro"}
+	pressed := server.InjectUpdate(telegram.Update{CallbackQuery: &telegram.CallbackQuery{
+		ID: "other", From: &other, Message: &sent, ChatInstance: "other", Data: data,
+	}})
+	result, err := services.HandleUpdate(ctx, server.Client(), &pressed)
+	if err != nil || result.Callback != "" {
+		t.Errorf("HandleUpdate() by another user = %+v, %v, want the button ignored", result, err)
+	}
+
+	pressed = server.InjectCallback(update.Message.Chat.ID, response.MessageID, data)
+	result, err = services.HandleUpdate(ctx, server.Client(), &pressed)
+	if err != nil || result.Callback != expenses.ActionDiscardDraft {
+		t.Errorf("HandleUpdate() by the owner = %+v, %v, want the draft discarded", result, err)
+	}
+	if _, err := services.Expenses.Get(ctx, telegramtest.User.ID, response.ExpenseID); err == nil {
+		t.Errorf("Get() error = nil, want the draft discarded")
+	}
+}
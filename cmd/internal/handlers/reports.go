@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/bot"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/reports"
+)
+
+// reportsUsage explica los subcomandos de /reports
+const reportsUsage = "Usa /reports diario|semanal|mensual si|no para suscribirte o cancelar, /reports hora <0-23> para elegir la hora o /reports show."
+
+// subscriptionWords son las respuestas para activar o desactivar un reporte
+var subscriptionWords = map[string]bool{
+	"on": true, "si": true, "sí": true, "activar": true,
+	"off": false, "no": false, "desactivar": false,
+}
+
+// handleReports atiende /reports show|hora|<frecuencia> si|no
+func (s *Services) handleReports(ctx context.Context, req *bot.Request) error {
+	switch strings.ToLower(req.Arg(0)) {
+	case "show", "ver", "":
+		return s.showReports(ctx, req)
+	case "hour", "hora":
+		return s.setReportHour(ctx, req)
+	}
+	frequency, ok := reports.ParseFrequency(req.Arg(0))
+	if !ok || len(req.Args) != 2 {
+		return req.Reply(ctx, reportsUsage)
+	}
+	subscribed, ok := subscriptionWords[strings.ToLower(req.Arg(1))]
+	if !ok {
+		return req.Reply(ctx, reportsUsage)
+	}
+
+	preferences, err := reports.Update(ctx, s.Reports, req.UserID(), func(preferences *reports.Preferences) error {
+		// Los reportes se envían al último chat desde el que se configuraron
+		preferences.ChatID = req.ChatID()
+		preferences.Set(frequency, subscribed)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !subscribed {
+		return req.Reply(ctx, fmt.Sprintf("Ya no recibirás el reporte %s.", frequency.Name()))
+	}
+	return req.Reply(ctx, fmt.Sprintf("Recibirás el reporte %s %s.", frequency.Name(), reportTime(frequency, preferences.Hour)))
+}
+
+// setReportHour cambia la hora local a la que se envían los reportes
+func (s *Services) setReportHour(ctx context.Context, req *bot.Request) error {
+	hour, err := strconv.Atoi(req.Arg(1))
+	if err != nil || hour < 0 || hour > 23 || len(req.Args) != 2 {
+		return req.Reply(ctx, "Escribe la hora como un número entre 0 y 23, por ejemplo /reports hora 8.")
+	}
+	_, err = reports.Update(ctx, s.Reports, req.UserID(), func(preferences *reports.Preferences) error {
+		preferences.Hour = hour
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return req.Reply(ctx, fmt.Sprintf("Tus reportes se enviarán a las %d:00.", hour))
+}
+
+// showReports muestra los reportes a los que está suscrito el usuario
+func (s *Services) showReports(ctx context.Context, req *bot.Request) error {
+	preferences, err := s.Reports.Load(ctx, req.UserID())
+	if err != nil {
+		return err
+	}
+	if len(preferences.Frequencies) == 0 {
+		return req.Reply(ctx, "No recibes reportes. "+reportsUsage)
+	}
+	lines := []string{"Tus reportes:"}
+	for _, frequency := range preferences.Frequencies {
+		lines = append(lines, fmt.Sprintf("• %s: %s", frequency.Name(), reportTime(frequency, preferences.Hour)))
+	}
+	return req.Reply(ctx, strings.Join(lines, "\n"))
+}
+
+// reportTime describe cuándo se envía un reporte, por ejemplo "cada lunes a las 8:00"
+func reportTime(frequency reports.Frequency, hour int) string {
+	switch frequency {
+	case reports.Daily:
+		return fmt.Sprintf("cada día a las %d:00", hour)
+	case reports.Weekly:
+		return fmt.Sprintf("cada lunes a las %d:00", hour)
+	}
+	return fmt.Sprintf("el día 1 de cada mes a las %d:00", hour)
+}
+
+// handleTimeZone muestra o cambia la zona horaria del usuario, por ejemplo "/timezone America/Bogota".
+// Los periodos de los presupuestos empiezan a la medianoche en ella
+func (s *Services) handleTimeZone(ctx context.Context, req *bot.Request) error {
+	if len(req.Args) == 0 {
+		location := s.userLocation(ctx, req.UserID())
+		return req.Reply(ctx, fmt.Sprintf("Tu zona horaria es %s, ahí son las %s. Cámbiala con /timezone <zona>, por ejemplo /timezone America/Bogota.",
+			location, time.Now().In(location).Format("15:04")))
+	}
+	// Solo se aceptan los nombres de la base de datos de zonas horarias, "Local" depende del servidor
+	location, err := time.LoadLocation(req.Arg(0))
+	if err != nil || req.Arg(0) == "" || req.Arg(0) == "Local" {
+		return req.Reply(ctx, "No conozco esa zona horaria, escríbela como en America/Mexico_City o Europe/Madrid.")
+	}
+	_, err = reports.Update(ctx, s.Reports, req.UserID(), func(preferences *reports.Preferences) error {
+		preferences.TimeZone = location.String()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return req.Reply(ctx, fmt.Sprintf("Tu zona horaria ahora es %s, ahí son las %s.", location, time.Now().In(location).Format("15:04")))
+}
+
+// userLocation es la zona horaria elegida por el usuario, o la del bot si no eligió una.
+// Un error al leerla no impide responder, se usa la del bot
+func (s *Services) userLocation(ctx context.Context, userID int64) *time.Location {
+	preferences, err := s.Reports.Load(ctx, userID)
+	if err != nil {
+		log.Printf("Error loading the time zone of %d: %v", userID, err)
+		return s.Location
+	}
+	return preferences.Location(s.Location)
+}
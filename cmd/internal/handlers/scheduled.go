@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/reports"
+)
+
+// ReportRequest es la entrada de report-builder, Time es la hora programada de la ejecución (la actual si viene vacía).
+// Sin UserID regresa los usuarios con reportes a esa hora; con UserID construye los reportes de ese usuario,
+// la máquina de estados llama una vez por usuario para que un error no detenga los reportes de los demás
+type ReportRequest struct {
+	Time   time.Time `json:"Time"`
+	UserID int64     `json:"UserID,omitempty"`
+}
+
+// Report es un resumen para enviar a ChatID con telegram-send-message
+type Report struct {
+	ChatID int64  `json:"ChatID"`
+	Text   string `json:"Text"`
+}
+
+// ReportResponse es la respuesta de report-builder. Time es la hora usada, para construir los reportes
+// de cada uno de los UserIDs con la misma; la máquina de estados envía cada uno de los Reports
+type ReportResponse struct {
+	Ok      bool      `json:"Ok"`
+	Time    time.Time `json:"Time"`
+	UserIDs []int64   `json:"UserIDs"`
+	Reports []Report  `json:"Reports"`
+}
+
+// BuildReports atiende una ejecución de report-builder, ver ReportRequest
+func (s *Services) BuildReports(ctx context.Context, request ReportRequest) (ReportResponse, error) {
+	now := request.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	response := ReportResponse{Ok: true, Time: now, UserIDs: []int64{}, Reports: []Report{}}
+	if request.UserID == 0 {
+		// Solo se consultan los usuarios programados cerca de esta hora, no toda la tabla
+		scheduled, err := s.Reports.Scheduled(ctx, now)
+		if err != nil {
+			return ReportResponse{Ok: false}, err
+		}
+		for _, preferences := range scheduled {
+			if len(preferences.DueAt(now, s.Location)) > 0 {
+				response.UserIDs = append(response.UserIDs, preferences.UserID)
+			}
+		}
+		return response, nil
+	}
+
+	preferences, err := s.Reports.Load(ctx, request.UserID)
+	if err != nil {
+		return ReportResponse{Ok: false}, err
+	}
+	// Los periodos de los reportes están en la zona horaria del usuario
+	local := now.In(preferences.Location(s.Location))
+	for _, frequency := range preferences.Due(local) {
+		summary, err := reports.Build(ctx, s.Expenses, request.UserID, frequency, local)
+		if err != nil {
+			return ReportResponse{Ok: false}, err
+		}
+		response.Reports = append(response.Reports, Report{ChatID: preferences.ChatID, Text: summary.Text()})
+	}
+	return response, nil
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses/reports"
+)
+
+func TestBuildReportsInUserTimeZone(t *testing.T) {
+	ctx := context.Background()
+	services := NewMemoryServices(time.UTC)
+	for _, preferences := range []*reports.Preferences{
+		{UserID: 42, ChatID: 420, Frequencies: []reports.Frequency{reports.Daily}, Hour: 8, TimeZone: "Asia/Tokyo"},
+		{UserID: 43, ChatID: 430, Frequencies: []reports.Frequency{reports.Daily}, Hour: 8},
+	} {
+		if err := services.Reports.Save(ctx, preferences); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	// Mediodía del 20 de marzo en Tokio, las 3 de la mañana en UTC
+	expense := &expenses.Expense{UserID: 42, ChatID: 420, Amount: 15000, Currency: expenses.DefaultCurrency, Category: "Comida", Date: time.Date(2024, time.March, 20, 3, 0, 0, 0, time.UTC)}
+	if err := services.Expenses.Create(ctx, expense); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Las 8 del 21 de marzo en Tokio
+	now := time.Date(2024, time.March, 20, 23, 0, 0, 0, time.UTC)
+	listed, err := services.BuildReports(ctx, ReportRequest{Time: now})
+	if err != nil {
+		t.Fatalf("BuildReports() error = %v", err)
+	}
+	if !listed.Ok || !slices.Equal(listed.UserIDs, []int64{42}) || len(listed.Reports) != 0 || !listed.Time.Equal(now) {
+		t.Fatalf("BuildReports() = %+v, want only the user of Tokyo", listed)
+	}
+
+	built, err := services.BuildReports(ctx, ReportRequest{Time: listed.Time, UserID: 42})
+	if err != nil {
+		t.Fatalf("BuildReports(42) error = %v", err)
+	}
+	if len(built.Reports) != 1 || built.Reports[0].ChatID != 420 || !strings.Contains(built.Reports[0].Text, "20/03/2024") || !strings.Contains(built.Reports[0].Text, "150.00") {
+		t.Errorf("BuildReports(42) = %+v, want the report of the 20th in Tokyo", built)
+	}
+	if built, _ := services.BuildReports(ctx, ReportRequest{Time: now, UserID: 43}); len(built.Reports) != 0 {
+		t.Errorf("BuildReports(43) = %+v, want none at 23:00 UTC", built)
+	}
+}
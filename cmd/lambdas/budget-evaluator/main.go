@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	// Incluye las zonas horarias, el runtime de Lambda no las trae
+	_ "time/tzdata"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/betofloresbaca/expenses-manager/cmd/internal/handlers"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+)
+
+// location es la zona horaria TIME_ZONE de los usuarios que no eligieron una
+var location = expenses.LoadLocation()
+
+// services lee los gastos, los presupuestos y la zona horaria de los usuarios de las tablas del entorno.
+// Los periodos de los presupuestos empiezan a la medianoche en la zona horaria del usuario, o en
+// TIME_ZONE (America/Mexico_City por defecto) si no eligió una
+var services = &handlers.Services{
+	Expenses: handlers.NewExpenseRepository(),
+	Budgets:  handlers.NewBudgetStore(),
+	Reports:  handlers.NewReportStore(location),
+	Location: location,
+}
+
+// handleRequest evalúa los presupuestos de la categoría del gasto registrado
+func handleRequest(ctx context.Context, request handlers.BudgetRequest) (handlers.BudgetResponse, error) {
+	return services.EvaluateBudgets(ctx, request)
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	// Incluye las zonas horarias, el runtime de Lambda no las trae
+	_ "time/tzdata"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/betofloresbaca/expenses-manager/cmd/internal/handlers"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+)
+
+// location es la zona horaria TIME_ZONE de los usuarios que no eligieron una (America/Mexico_City por defecto),
+// la hora de los reportes y sus periodos están en la zona horaria de cada usuario
+var location = expenses.LoadLocation()
+
+// services lee los gastos de la tabla EXPENSES_TABLE y las preferencias de los reportes de SETTINGS_TABLE
+var services = &handlers.Services{
+	Expenses: handlers.NewExpenseRepository(),
+	Reports:  handlers.NewReportStore(location),
+	Location: location,
+}
+
+// handleRequest lista los usuarios con reportes a esta hora, o construye los reportes de uno de ellos
+func handleRequest(ctx context.Context, request handlers.ReportRequest) (handlers.ReportResponse, error) {
+	return services.BuildReports(ctx, request)
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
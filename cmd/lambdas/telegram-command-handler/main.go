@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	// Incluye las zonas horarias, el runtime de Lambda no las trae
+	_ "time/tzdata"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/betofloresbaca/expenses-manager/cmd/internal/handlers"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/quick"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// rateLimiter se conserva entre invocaciones del mismo contenedor
+var rateLimiter = handlers.NewRateLimiter()
+
+// location es la zona horaria TIME_ZONE de los usuarios que no eligieron una
+var location = expenses.LoadLocation()
+
+// services guarda los gastos, las categorías, los presupuestos, los reportes y las conversaciones en sus tablas
+var services = &handlers.Services{
+	Expenses:       handlers.NewExpenseRepository(),
+	Categories:     handlers.NewCategoryStore(),
+	Budgets:        handlers.NewBudgetStore(),
+	Reports:        handlers.NewReportStore(location),
+	Conversations:  handlers.NewConversationStore(),
+	Receipts:       handlers.S3ObjectStore{},
+	ReceiptsBucket: handlers.MustGetenv("RECEIPTS_BUCKET"),
+	Location:       location,
+}
+
+// handleRequest recibe el update de Telegram desde la máquina de estados
+func handleRequest(ctx context.Context, update telegram.Update) (handlers.CommandResponse, error) {
+
+	// Obtener el nombre del parámetro desde la variable de entorno
+	telegramToken, err := quick.GetParameter(ctx, os.Getenv("TELEGRAM_TOKEN_PARAM"), true)
+	if err != nil {
+		return handlers.CommandResponse{Ok: false}, err
+	}
+	options := []telegram.ClientOption{telegram.WithRateLimiter(rateLimiter)}
+	// TELEGRAM_API_URL permite apuntar a un servidor local o a telegramtest.Server en pruebas
+	if apiURL := os.Getenv("TELEGRAM_API_URL"); apiURL != "" {
+		options = append(options, telegram.WithBaseURL(apiURL))
+	}
+	client := telegram.NewClient(telegramToken, options...)
+	// Los botones de los borradores se firman con el token, los dos Lambdas usan la misma clave
+	services.Callbacks = handlers.NewCallbackCodec(telegramToken)
+
+	response, err := services.HandleUpdate(ctx, client, &update)
+	if err != nil {
+		// Se regresa sin envolver para que Step Functions reciba "RetryAfterError" como nombre del error
+		var retryErr *telegram.RetryAfterError
+		if errors.As(err, &retryErr) {
+			return handlers.CommandResponse{Ok: false}, retryErr
+		}
+		return handlers.CommandResponse{Ok: false}, err
+	}
+	return response, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	// Incluye las zonas horarias, el runtime de Lambda no las trae
+	_ "time/tzdata"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/betofloresbaca/expenses-manager/cmd/internal/handlers"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/quick"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// rateLimiter se conserva entre invocaciones del mismo contenedor
+var rateLimiter = handlers.NewRateLimiter()
+
+// services guarda los borradores y las fotos de los tickets, y los lee con Textract
+// (o con datos fijos si RECEIPT_EXTRACTOR es "stub")
+var services = newServices()
+
+func newServices() *handlers.Services {
+	location := expenses.LoadLocation()
+	return &handlers.Services{
+		Expenses:       handlers.NewExpenseRepository(),
+		Categories:     handlers.NewCategoryStore(),
+		Receipts:       handlers.S3ObjectStore{},
+		ReceiptsBucket: handlers.MustGetenv("RECEIPTS_BUCKET"),
+		Extractor:      handlers.NewReceiptExtractor(location),
+		Location:       location,
+	}
+}
+
+func handleRequest(ctx context.Context, update telegram.Update) (handlers.ReceiptResponse, error) {
+	// Obtener el nombre del parámetro desde la variable de entorno
+	telegramToken, err := quick.GetParameter(ctx, os.Getenv("TELEGRAM_TOKEN_PARAM"), true)
+	if err != nil {
+		return handlers.ReceiptResponse{Ok: false}, err
+	}
+	options := []telegram.ClientOption{telegram.WithRateLimiter(rateLimiter)}
+	// TELEGRAM_API_URL permite apuntar a un servidor local o a telegramtest.Server en pruebas
+	if apiURL := os.Getenv("TELEGRAM_API_URL"); apiURL != "" {
+		options = append(options, telegram.WithBaseURL(apiURL))
+	}
+	client := telegram.NewClient(telegramToken, options...)
+	// Los botones de los borradores se firman con el token, los dos Lambdas usan la misma clave
+	services.Callbacks = handlers.NewCallbackCodec(telegramToken)
+
+	response, err := services.ProcessReceipt(ctx, client, &update)
+	if err != nil {
+		// Se regresa sin envolver para que Step Functions reciba "RetryAfterError" como nombre del error
+		var retryErr *telegram.RetryAfterError
+		if errors.As(err, &retryErr) {
+			return handlers.ReceiptResponse{Ok: false}, retryErr
+		}
+		return handlers.ReceiptResponse{Ok: false}, err
+	}
+	return response, nil
+}
+
+func main() {
+	lambda.Start(handleRequest)
+}
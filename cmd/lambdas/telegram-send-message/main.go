@@ -1,30 +1,39 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"net/http"
+	"errors"
+	"log"
 	"os"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/betofloresbaca/expenses-manager/cmd/internal/handlers"
 	"github.com/betofloresbaca/expenses-manager/pkg/quick"
 	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
 )
 
+// Request es el mensaje a enviar.
+// ChatType es el tipo del chat si se conoce (private, group...), el limitador lo deduce de ChatID si no.
 type Request struct {
 	ChatID    int64  `json:"ChatID"`
+	ChatType  string `json:"ChatType,omitempty"`
 	Text      string `json:"Text"`
 	ParseMode string `json:"ParseMode,omitempty"`
 }
 
 // Response representa la respuesta del Lambda.
+// Los textos largos se envían en varios mensajes, MessageID es el primero y MessageIDs los incluye todos.
+// Partial indica que el envío se cortó después del primer mensaje y MessageIDs solo tiene los enviados.
 type Response struct {
-	Ok        bool `json:"Ok"`
-	MessageID int  `json:"MessageID,omitempty"`
+	Ok         bool  `json:"Ok"`
+	MessageID  int   `json:"MessageID,omitempty"`
+	MessageIDs []int `json:"MessageIDs,omitempty"`
+	Partial    bool  `json:"Partial,omitempty"`
 }
 
+// rateLimiter se conserva entre invocaciones del mismo contenedor
+var rateLimiter = handlers.NewRateLimiter()
+
 func handleRequest(ctx context.Context, request Request) (Response, error) {
 
 	// Obtener el nombre del parámetro desde la variable de entorno
@@ -32,36 +41,42 @@ func handleRequest(ctx context.Context, request Request) (Response, error) {
 	if err != nil {
 		return Response{Ok: false}, err
 	}
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", telegramToken)
+	options := []telegram.ClientOption{telegram.WithRateLimiter(rateLimiter)}
+	// TELEGRAM_API_URL permite apuntar a un servidor local o a telegramtest.Server en pruebas
+	if apiURL := os.Getenv("TELEGRAM_API_URL"); apiURL != "" {
+		options = append(options, telegram.WithBaseURL(apiURL))
+	}
+	client := telegram.NewClient(telegramToken, options...)
+	if request.ChatType != "" {
+		ctx = telegram.WithChat(ctx, &telegram.Chat{ID: request.ChatID, Type: request.ChatType})
+	}
 
 	// Crear el request usando el modelo de telegram
-	sendRequest := telegram.SendMessageRequest{
+	messages, err := client.SendLongMessage(ctx, &telegram.SendMessageRequest{
 		ChatID:    request.ChatID,
 		Text:      request.Text,
 		ParseMode: request.ParseMode,
+	})
+	// Si ya se enviaron algunos mensajes no se regresa el error: el reintento de la máquina de estados los repetiría
+	var partialErr *telegram.PartialSendError
+	if errors.As(err, &partialErr) {
+		log.Printf("Message to chat %d was cut after %d messages: %v", request.ChatID, partialErr.Sent, partialErr.Err)
+		err = nil
 	}
-	jsonBody, _ := json.Marshal(sendRequest)
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return Response{Ok: false}, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return Response{Ok: false}, fmt.Errorf("error telegram: %d %s", resp.StatusCode, resp.Status)
-	}
-
-	var telegramResp telegram.APIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&telegramResp); err != nil {
+		// Se regresa sin envolver para que Step Functions reciba "RetryAfterError" como nombre del error
+		var retryErr *telegram.RetryAfterError
+		if errors.As(err, &retryErr) {
+			return Response{Ok: false}, retryErr
+		}
 		return Response{Ok: false}, err
 	}
 
-	if !telegramResp.OK {
-		return Response{Ok: false}, fmt.Errorf("telegram api error: %s", telegramResp.Description)
+	messageIDs := make([]int, 0, len(messages))
+	for _, message := range messages {
+		messageIDs = append(messageIDs, int(message.MessageID))
 	}
-
-	return Response{Ok: true, MessageID: int(telegramResp.Result.MessageID)}, nil
+	return Response{Ok: true, MessageID: messageIDs[0], MessageIDs: messageIDs, Partial: partialErr != nil}, nil
 }
 
 func main() {
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/cmd/internal/handlers"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/quick"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// Ejecuta el bot desde una laptop: recibe los updates con long polling y los entrega
+// al mismo código de los Lambdas, con los gastos, categorías, presupuestos y conversaciones
+// en memoria. Los tickets se leen con datos fijos, sin S3 ni Textract.
+func main() {
+	tokenParam := flag.String("token-param", "/em/TelegramToken", "SSM parameter with the bot token (ignored if TELEGRAM_TOKEN is set)")
+	timeZone := flag.String("time-zone", expenses.DefaultTimeZone, "time zone of the users")
+	allowedUpdates := flag.String("allowed-updates", "message,callback_query", "comma separated update kinds to receive")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// El token puede venir del entorno para no depender de SSM
+	token := os.Getenv("TELEGRAM_TOKEN")
+	if token == "" {
+		var err error
+		if token, err = quick.GetParameter(ctx, *tokenParam, true); err != nil {
+			log.Fatalln("Error getting Telegram token:", err)
+		}
+	}
+	location, err := time.LoadLocation(*timeZone)
+	if err != nil {
+		log.Fatalln("Error loading time zone:", err)
+	}
+
+	client := telegram.NewClient(token, telegram.WithRateLimiter(telegram.NewRateLimiter(telegram.NewMemoryBucketStore())))
+	services := handlers.NewMemoryServices(location)
+	services.Callbacks = handlers.NewCallbackCodec(token)
+	poller := telegram.NewPoller(client, telegram.UpdateHandlerFunc(
+		func(ctx context.Context, update *telegram.Update) error {
+			return dispatch(ctx, client, services, update)
+		},
+	))
+	for _, kind := range strings.Split(*allowedUpdates, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			poller.AllowedUpdates = append(poller.AllowedUpdates, telegram.UpdateKind(kind))
+		}
+	}
+
+	log.Println("Polling updates (Ctrl+C to stop), the expenses are lost when the bot stops")
+	if err := poller.Run(ctx); err != nil {
+		log.Fatalln("Error polling updates:", err)
+	}
+	log.Println("Stopped")
+}
+
+// dispatch sigue los pasos de la máquina de estados: los textos y los botones van al
+// handler de comandos, las fotos al de tickets, y los gastos registrados se evalúan
+// contra los presupuestos para enviar la alerta
+func dispatch(ctx context.Context, client *telegram.Client, services *handlers.Services, update *telegram.Update) error {
+	switch {
+	case update.Message != nil && update.Message.Text != "", update.CallbackQuery != nil:
+		response, err := services.HandleUpdate(ctx, client, update)
+		if err != nil {
+			return err
+		}
+		log.Printf("Update %d (%s): command=%q callback=%q handled=%t", update.UpdateID, update.Kind(), response.Command, response.Callback, response.Handled)
+		if response.ExpenseID == "" {
+			return nil
+		}
+		return sendBudgetAlert(ctx, client, services, handlers.BudgetRequest{
+			ExpenseID: response.ExpenseID,
+			UserID:    response.UserID,
+			ChatID:    response.ChatID,
+			ChatType:  response.ChatType,
+		})
+	case update.Message != nil && len(update.Message.Photo) > 0:
+		response, err := services.ProcessReceipt(ctx, client, update)
+		if err != nil {
+			return err
+		}
+		log.Printf("Update %d (%s): draft=%s", update.UpdateID, update.Kind(), response.ExpenseID)
+		return nil
+	}
+	log.Printf("Update %d (%s): not supported", update.UpdateID, update.Kind())
+	return nil
+}
+
+// sendBudgetAlert envía la alerta de los presupuestos que cruzó el gasto, si hay.
+// El gasto ya está registrado, un error al evaluar solo se anota
+func sendBudgetAlert(ctx context.Context, client *telegram.Client, services *handlers.Services, request handlers.BudgetRequest) error {
+	alert, err := services.EvaluateBudgets(ctx, request)
+	if err != nil {
+		log.Println("Error evaluating budgets:", err)
+		return nil
+	}
+	if alert.Text == "" {
+		return nil
+	}
+	if alert.ChatType != "" {
+		ctx = telegram.WithChat(ctx, &telegram.Chat{ID: alert.ChatID, Type: alert.ChatType})
+	}
+	_, err = client.SendLongMessage(ctx, &telegram.SendMessageRequest{ChatID: alert.ChatID, Text: alert.Text})
+	return err
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/cmd/internal/handlers"
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/telegramtest"
+)
+
+// sentTexts regresa los textos enviados con sendMessage
+func sentTexts(t *testing.T, server *telegramtest.Server) []string {
+	t.Helper()
+	var texts []string
+	for _, call := range server.CallsTo("sendMessage") {
+		var req struct {
+			Text string `json:"text"`
+		}
+		if err := call.Decode(&req); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		texts = append(texts, req.Text)
+	}
+	return texts
+}
+
+func TestDispatchRecordsExpenseAndSendsBudgetAlert(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	services := handlers.NewMemoryServices(time.UTC)
+	ctx := context.Background()
+	chatID := telegramtest.User.ID
+
+	for _, text := range []string{"/budget set Comida 100", "/gasto 150 tacos #comida", "Sí"} {
+		update := server.InjectMessage(chatID, text)
+		if err := dispatch(ctx, client, services, &update); err != nil {
+			t.Fatalf("dispatch(%q) error = %v", text, err)
+		}
+	}
+
+	list, err := services.Expenses.List(ctx, telegramtest.User.ID, expenses.Query{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 1 || list[0].Amount != 15000 || list[0].Category != "Comida" {
+		t.Fatalf("expenses = %+v, want the 150.00 Comida expense", list)
+	}
+	texts := sentTexts(t, server)
+	if len(texts) == 0 || !strings.Contains(texts[len(texts)-1], "Superaste el 100%") {
+		t.Errorf("sent texts = %q, want a budget alert last", texts)
+	}
+}
+
+func TestDispatchProcessesReceiptPhotos(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	services := handlers.NewMemoryServices(time.UTC)
+	ctx := context.Background()
+
+	fileID := server.AddFile("photos/ticket.jpg", []byte("jpeg"))
+	update := server.InjectUpdate(telegram.Update{Message: &telegram.Message{
+		From:  &telegramtest.User,
+		Chat:  telegram.ChatFromID(telegramtest.User.ID),
+		Date:  time.Now().Unix(),
+		Photo: []telegram.PhotoSize{{FileID: fileID, Width: 800, Height: 600, FileSize: 4}},
+	}})
+	if err := dispatch(ctx, client, services, &update); err != nil {
+		t.Fatalf("dispatch() error = %v", err)
+	}
+
+	list, err := services.Expenses.List(ctx, telegramtest.User.ID, expenses.Query{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 1 || !list[0].IsDraft() || list[0].Merchant != "Tienda de prueba" {
+		t.Fatalf("expenses = %+v, want the draft of the stub receipt", list)
+	}
+	texts := sentTexts(t, server)
+	if len(texts) != 1 || !strings.Contains(texts[0], "¿Lo registro?") {
+		t.Errorf("sent texts = %q, want the draft", texts)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/betofloresbaca/expenses-manager/pkg/clients"
+	"github.com/betofloresbaca/expenses-manager/pkg/quick"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// Administra el webhook de Telegram: lo registra con la URL del ApiStack (set),
+// muestra su estado (info) o lo elimina para usar long polling (delete).
+func main() {
+	tokenParam := flag.String("token-param", "/em/TelegramToken", "SSM parameter with the bot token (ignored if TELEGRAM_TOKEN is set)")
+	secretParam := flag.String("secret-param", "/em/TelegramSecret", "SSM parameter with the webhook secret token")
+	exportName := flag.String("export", "telegram-bot-apiUrl", "CloudFormation export with the API Gateway URL")
+	route := flag.String("route", "/webhook", "webhook route of the API")
+	allowedUpdates := flag.String("allowed-updates", "message,callback_query", "comma separated update kinds to receive")
+	dropPending := flag.Bool("drop-pending", false, "drop the pending updates")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: webhook [flags] set|info|delete")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	// El token puede venir del entorno para no depender de SSM
+	token := os.Getenv("TELEGRAM_TOKEN")
+	if token == "" {
+		var err error
+		if token, err = quick.GetParameter(ctx, *tokenParam, true); err != nil {
+			log.Fatalln("Error getting Telegram token:", err)
+		}
+	}
+	client := telegram.NewClient(token)
+
+	var expected *telegram.SetWebhookRequest
+	switch flag.Arg(0) {
+	case "set":
+		apiURL, err := getExport(ctx, *exportName)
+		if err != nil {
+			log.Fatalln("Error getting API URL:", err)
+		}
+		secret, err := quick.GetParameter(ctx, *secretParam, true)
+		if err != nil {
+			log.Fatalln("Error getting Telegram secret:", err)
+		}
+		expected = &telegram.SetWebhookRequest{
+			URL:                strings.TrimRight(apiURL, "/") + *route,
+			AllowedUpdates:     parseUpdateKinds(*allowedUpdates),
+			DropPendingUpdates: *dropPending,
+			SecretToken:        secret,
+		}
+		if err := client.SetWebhook(ctx, expected); err != nil {
+			log.Fatalln("Error setting webhook:", err)
+		}
+		fmt.Println("Webhook set to", expected.URL)
+	case "delete":
+		if err := client.DeleteWebhook(ctx, &telegram.DeleteWebhookRequest{DropPendingUpdates: *dropPending}); err != nil {
+			log.Fatalln("Error deleting webhook:", err)
+		}
+		fmt.Println("Webhook deleted")
+	case "info":
+		apiURL, err := getExport(ctx, *exportName)
+		if err != nil {
+			log.Println("Warning: cannot check the URL drift:", err)
+		} else {
+			expected = &telegram.SetWebhookRequest{
+				URL:            strings.TrimRight(apiURL, "/") + *route,
+				AllowedUpdates: parseUpdateKinds(*allowedUpdates),
+			}
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	info, err := client.GetWebhookInfo(ctx)
+	if err != nil {
+		log.Fatalln("Error getting webhook info:", err)
+	}
+	report(info, expected)
+}
+
+// report prints the webhook status and its drift from the expected configuration.
+func report(info *telegram.WebhookInfo, expected *telegram.SetWebhookRequest) {
+	fmt.Println("URL:                 ", valueOr(info.URL, "(none, using getUpdates)"))
+	fmt.Println("Allowed updates:     ", info.AllowedUpdates)
+	fmt.Println("Pending update count:", info.PendingUpdateCount)
+	if info.LastErrorDate != 0 {
+		fmt.Printf("Last error:           %s (%s)\n", info.LastErrorMessage, time.Unix(info.LastErrorDate, 0).Format(time.RFC3339))
+	}
+
+	if expected == nil {
+		return
+	}
+	if info.URL != expected.URL {
+		fmt.Printf("DRIFT: URL is %q, expected %q\n", info.URL, expected.URL)
+	}
+	if len(expected.AllowedUpdates) > 0 && !slices.Equal(info.AllowedUpdates, expected.AllowedUpdates) {
+		fmt.Printf("DRIFT: allowed updates are %v, expected %v\n", info.AllowedUpdates, expected.AllowedUpdates)
+	}
+	if info.LastErrorMessage != "" {
+		fmt.Println("DRIFT: Telegram could not deliver updates:", info.LastErrorMessage)
+	}
+}
+
+// getExport returns the value of a CloudFormation export.
+func getExport(ctx context.Context, name string) (string, error) {
+	cfnClient := clients.GetClient(func(cfg aws.Config) *cloudformation.Client {
+		return cloudformation.NewFromConfig(cfg)
+	})
+	paginator := cloudformation.NewListExportsPaginator(cfnClient, &cloudformation.ListExportsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, export := range page.Exports {
+			if aws.ToString(export.Name) == name {
+				return aws.ToString(export.Value), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("export %s not found, run mage deploy first", name)
+}
+
+// parseUpdateKinds splits a comma separated list of update kinds.
+func parseUpdateKinds(list string) []telegram.UpdateKind {
+	var kinds []telegram.UpdateKind
+	for _, kind := range strings.Split(list, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			kinds = append(kinds, telegram.UpdateKind(kind))
+		}
+	}
+	return kinds
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
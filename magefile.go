@@ -67,6 +67,42 @@ func Destroy() error {
 	return cdkDestroyCmd.Run()
 }
 
+// Run the bot locally with long polling, handling the updates in process with in-memory storage
+// The webhook must be deleted first (mage webhook:delete), Telegram does not allow both at the same time
+func Local() error {
+	fmt.Println("Running bot locally...")
+	localBotCmd := exec.Command("go", "run", "./cmd/local-bot")
+	localBotCmd.Stdin = os.Stdin
+	localBotCmd.Stdout = os.Stdout
+	localBotCmd.Stderr = os.Stderr
+	return localBotCmd.Run()
+}
+
+type Webhook mg.Namespace
+
+// Register the API Gateway URL as the Telegram webhook, with the secret token from SSM
+func (Webhook) Set() error {
+	return runWebhook("set")
+}
+
+// Show the webhook status and its drift from the deployed API
+func (Webhook) Info() error {
+	return runWebhook("info")
+}
+
+// Delete the webhook, needed before running the bot locally
+func (Webhook) Delete() error {
+	return runWebhook("delete")
+}
+
+func runWebhook(action string) error {
+	fmt.Println("Webhook " + action + "...")
+	webhookCmd := exec.Command("go", "run", "./cmd/webhook", action)
+	webhookCmd.Stdout = os.Stdout
+	webhookCmd.Stderr = os.Stderr
+	return webhookCmd.Run()
+}
+
 func buildLambdas() error {
 	lambdas := getLambdaNames()
 	for _, name := range lambdas {
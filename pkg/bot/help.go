@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// Help returns a handler that replies with the commands available in the chat,
+// register it e.g. as Command{Name: "help", Handler: router.Help()}.
+func (r *Router) Help() HandlerFunc {
+	return func(ctx context.Context, req *Request) error {
+		var help strings.Builder
+		help.WriteString(req.T(TextHelpHeader))
+		for _, command := range r.visibleCommands(req.Message.Chat.Type) {
+			fmt.Fprintf(&help, "\n%s - %s", usage(command), command.Description)
+		}
+		return req.Reply(ctx, help.String())
+	}
+}
+
+// commandScopes maps the scopes of the Telegram command menu to the chat types they cover.
+var commandScopes = []struct {
+	scope     string
+	chatTypes []string
+}{
+	{telegram.BotCommandScopeDefault, nil},
+	{telegram.BotCommandScopeAllPrivateChats, []string{telegram.ChatTypePrivate}},
+	{telegram.BotCommandScopeAllGroupChats, []string{telegram.ChatTypeGroup, telegram.ChatTypeSupergroup}},
+}
+
+// SyncCommands sets the Telegram command menu to the commands with a
+// description. Private and group chats get their own menu, with the commands
+// restricted to them; the default menu only has unrestricted commands.
+func (r *Router) SyncCommands(ctx context.Context) error {
+	for _, scope := range commandScopes {
+		var commands []telegram.BotCommand
+		for _, command := range r.scopeCommands(scope.chatTypes) {
+			commands = append(commands, telegram.BotCommand{Command: command.Name, Description: command.Description})
+		}
+
+		botScope := &telegram.BotCommandScope{Type: scope.scope}
+		var err error
+		if len(commands) == 0 {
+			err = r.client.DeleteMyCommands(ctx, &telegram.DeleteMyCommandsRequest{Scope: botScope})
+		} else {
+			err = r.client.SetMyCommands(ctx, &telegram.SetMyCommandsRequest{Commands: commands, Scope: botScope})
+		}
+		if err != nil {
+			return fmt.Errorf("syncing %s commands: %w", scope.scope, err)
+		}
+	}
+	return nil
+}
+
+// visibleCommands returns the commands with a description available in a chat type.
+func (r *Router) visibleCommands(chatType string) []*Command {
+	var commands []*Command
+	for _, command := range r.commands {
+		if command.Description != "" && command.availableIn(chatType) {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// scopeCommands returns the commands with a description available in every
+// chat type of a scope, or only the unrestricted ones for the default scope.
+func (r *Router) scopeCommands(chatTypes []string) []*Command {
+	var commands []*Command
+	for _, command := range r.commands {
+		if command.Description == "" {
+			continue
+		}
+		available := len(command.ChatTypes) == 0
+		if len(chatTypes) > 0 {
+			available = true
+			for _, chatType := range chatTypes {
+				available = available && command.availableIn(chatType)
+			}
+		}
+		if available {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
@@ -0,0 +1,95 @@
+package bot_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/bot"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// commandNames returns the names of a command menu.
+func commandNames(commands []telegram.BotCommand) []string {
+	var names []string
+	for _, command := range commands {
+		names = append(names, command.Command)
+	}
+	return names
+}
+
+func TestSyncCommands(t *testing.T) {
+	var handled []string
+	server, router := newTestRouter(t, &handled)
+	router.Handle(bot.Command{Name: "help", Description: "Muestra la ayuda", Handler: router.Help()})
+	// Without a description the command is hidden from every menu
+	router.Handle(bot.Command{Name: "debug", Handler: router.Help()})
+
+	if err := router.SyncCommands(context.Background()); err != nil {
+		t.Fatalf("SyncCommands() error = %v", err)
+	}
+	tests := []struct {
+		scope string
+		want  []string
+	}{
+		{telegram.BotCommandScopeDefault, []string{"help"}},
+		{telegram.BotCommandScopeAllPrivateChats, []string{"gasto", "help"}},
+		{telegram.BotCommandScopeAllGroupChats, []string{"split", "help"}},
+	}
+	for _, tt := range tests {
+		if got := commandNames(server.Commands(tt.scope, "")); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Commands(%s) = %q, want %q", tt.scope, got, tt.want)
+		}
+	}
+	if calls := len(server.CallsTo("setMyCommands")); calls != 3 {
+		t.Errorf("setMyCommands calls = %d, want one per scope", calls)
+	}
+}
+
+func TestSyncCommandsDeletesEmptyScopes(t *testing.T) {
+	var handled []string
+	server, router := newTestRouter(t, &handled)
+	ctx := context.Background()
+	// A menu left by a previous version of the bot
+	if err := server.Client().SetMyCommands(ctx, &telegram.SetMyCommandsRequest{
+		Commands: []telegram.BotCommand{{Command: "viejo", Description: "Ya no existe"}},
+		Scope:    &telegram.BotCommandScope{Type: telegram.BotCommandScopeDefault},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := router.SyncCommands(ctx); err != nil {
+		t.Fatalf("SyncCommands() error = %v", err)
+	}
+	if got := server.Commands(telegram.BotCommandScopeDefault, ""); len(got) != 0 {
+		t.Errorf("default commands = %q, want them deleted", commandNames(got))
+	}
+	var deleted telegram.DeleteMyCommandsRequest
+	if calls := server.CallsTo("deleteMyCommands"); len(calls) != 1 || calls[0].Decode(&deleted) != nil || deleted.Scope.Type != telegram.BotCommandScopeDefault {
+		t.Errorf("deleteMyCommands calls = %+v, want the default scope", calls)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	tests := []struct {
+		chatID int64
+		want   string
+	}{
+		{privateChat, "Comandos disponibles:\n/gasto <monto> [descripción] - Registra un gasto\n/help - Muestra la ayuda"},
+		{groupChat, "Comandos disponibles:\n/split - Divide un gasto\n/help - Muestra la ayuda"},
+	}
+	for _, tt := range tests {
+		var handled []string
+		server, router := newTestRouter(t, &handled)
+		router.Handle(bot.Command{Name: "help", Description: "Muestra la ayuda", Handler: router.Help()})
+		router.Handle(bot.Command{Name: "debug", Handler: router.Help()})
+
+		update := server.InjectMessage(tt.chatID, "/help")
+		if err := router.HandleUpdate(context.Background(), &update); err != nil {
+			t.Fatal(err)
+		}
+		if got := replies(t, server); len(got) != 1 || got[0] != tt.want {
+			t.Errorf("help in chat %d = %q, want %q", tt.chatID, got, tt.want)
+		}
+	}
+}
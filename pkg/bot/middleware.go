@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Logging logs every command with its user, chat, duration and error.
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, req *Request) error {
+			start := time.Now()
+			err := next(ctx, req)
+			name := req.Invocation.Name
+			if req.Command == nil {
+				name += " (unknown)"
+			}
+			if err != nil {
+				log.Printf("Command /%s from user %d in chat %d failed after %s: %v", name, req.UserID(), req.ChatID(), time.Since(start), err)
+			} else {
+				log.Printf("Command /%s from user %d in chat %d handled in %s", name, req.UserID(), req.ChatID(), time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// AllowUsers only lets the given users run commands, others get TextForbidden.
+func AllowUsers(userIDs ...int64) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, req *Request) error {
+			if !slices.Contains(userIDs, req.UserID()) {
+				log.Printf("User %d is not allowed to run /%s", req.UserID(), req.Invocation.Name)
+				return req.Reply(ctx, req.T(TextForbidden))
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// Catalog holds the translated texts by language code and key, e.g. catalog["en"][TextUsage].
+type Catalog map[string]map[string]string
+
+// I18n sets Request.Lang from the language of the user ("es-MX" uses "es")
+// and makes Request.T use its translations. Languages missing in catalog
+// use defaultLang.
+func I18n(catalog Catalog, defaultLang string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, req *Request) error {
+			req.Lang = defaultLang
+			if from := req.Message.From; from != nil && from.LanguageCode != "" {
+				lang, _, _ := strings.Cut(strings.ToLower(from.LanguageCode), "-")
+				if _, ok := catalog[lang]; ok {
+					req.Lang = lang
+				}
+			}
+			req.texts = catalog[req.Lang]
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package bot_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/bot"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	var handled, calls []string
+	server, router := newTestRouter(t, &handled)
+	trace := func(name string) bot.Middleware {
+		return func(next bot.HandlerFunc) bot.HandlerFunc {
+			return func(ctx context.Context, req *bot.Request) error {
+				command := "unknown"
+				if req.Command != nil {
+					command = req.Command.Name
+				}
+				calls = append(calls, name+" "+command)
+				err := next(ctx, req)
+				calls = append(calls, name+" done")
+				return err
+			}
+		}
+	}
+	router.Use(trace("outer"))
+	router.Use(trace("inner"))
+
+	for _, text := range []string{"/gasto 1", "/borrar"} {
+		update := server.InjectMessage(privateChat, text)
+		if err := router.HandleUpdate(context.Background(), &update); err != nil {
+			t.Fatalf("HandleUpdate(%q) error = %v", text, err)
+		}
+	}
+	want := []string{
+		"outer gasto", "inner gasto", "inner done", "outer done",
+		"outer unknown", "inner unknown", "inner done", "outer done",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+	if len(handled) != 1 {
+		t.Errorf("handled = %q, want only gasto", handled)
+	}
+}
+
+func TestAllowUsers(t *testing.T) {
+	var handled []string
+	server, router := newTestRouter(t, &handled)
+	router.Use(bot.AllowUsers(privateChat))
+
+	for _, chatID := range []int64{privateChat, privateChat + 1} {
+		update := server.InjectMessage(chatID, "/gasto 1")
+		if err := router.HandleUpdate(context.Background(), &update); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(handled) != 1 {
+		t.Errorf("handled = %q, want the allowed user only", handled)
+	}
+	if got := replies(t, server); len(got) != 1 || got[0] != bot.DefaultTexts[bot.TextForbidden] {
+		t.Errorf("replies = %q, want the forbidden text", got)
+	}
+}
+
+func TestI18n(t *testing.T) {
+	catalog := bot.Catalog{
+		"en": {bot.TextUsage: "Usage: %s", bot.TextUnknownCommand: "Unknown command"},
+		"es": {},
+	}
+	tests := []struct {
+		languageCode string
+		text         string
+		lang         string
+		reply        string
+	}{
+		{"en-US", "/gasto", "en", "Usage: /gasto <monto> [descripción]"},
+		{"EN", "/borrar", "en", "Unknown command"},
+		// Texts missing in the catalog use the defaults
+		{"en", "/split", "en", bot.DefaultTexts[bot.TextWrongChatType]},
+		{"es-MX", "/gasto", "es", "Uso: /gasto <monto> [descripción]"},
+		{"fr", "/borrar", "es", bot.DefaultTexts[bot.TextUnknownCommand]},
+		{"", "/borrar", "es", bot.DefaultTexts[bot.TextUnknownCommand]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.languageCode+tt.text, func(t *testing.T) {
+			var handled []string
+			server, router := newTestRouter(t, &handled)
+			var lang string
+			router.Use(bot.I18n(catalog, "es"), func(next bot.HandlerFunc) bot.HandlerFunc {
+				return func(ctx context.Context, req *bot.Request) error {
+					lang = req.Lang
+					return next(ctx, req)
+				}
+			})
+
+			update := server.InjectMessage(privateChat, tt.text)
+			update.Message.From.LanguageCode = tt.languageCode
+			if err := router.HandleUpdate(context.Background(), &update); err != nil {
+				t.Fatal(err)
+			}
+			if lang != tt.lang {
+				t.Errorf("Lang = %q, want %q", lang, tt.lang)
+			}
+			if got := strings.Join(replies(t, server), ";"); got != tt.reply {
+				t.Errorf("reply = %q, want %q", got, tt.reply)
+			}
+		})
+	}
+}
+
+func TestRequestT(t *testing.T) {
+	req := &bot.Request{}
+	if got := req.T(bot.TextUsage, "/gasto"); got != "Uso: /gasto" {
+		t.Errorf("T(TextUsage) = %q", got)
+	}
+	if got := req.T("missing.key"); got != "missing.key" {
+		t.Errorf("T(missing) = %q, want the key", got)
+	}
+}
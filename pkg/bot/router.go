@@ -0,0 +1,290 @@
+// Package bot routes the bot commands received in Telegram updates to Go
+// handlers.
+//
+//	router := bot.NewRouter(client, "MyBot")
+//	router.Use(bot.Logging())
+//	router.Handle(bot.Command{
+//		Name:        "gasto",
+//		Aliases:     []string{"g"},
+//		Description: "Registra un gasto",
+//		Usage:       "<monto> [descripción]",
+//		MinArgs:     1,
+//		ChatTypes:   []string{telegram.ChatTypePrivate},
+//		Handler: func(ctx context.Context, req *bot.Request) error {
+//			return req.Reply(ctx, "Gasto de "+req.Arg(0)+" registrado")
+//		},
+//	})
+//	err := router.HandleUpdate(ctx, update)
+//
+// Commands are matched by name regardless of the "@botname" suffix, the
+// arguments or the case, e.g. "/Gasto@MyBot 150 tacos" runs "gasto".
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// HandlerFunc handles a command.
+type HandlerFunc func(ctx context.Context, req *Request) error
+
+// Middleware wraps the handling of every command, e.g. to log, authorize or localize requests.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Command describes a bot command and its handler.
+type Command struct {
+	// Name is the command without the leading slash: 1-32 lowercase letters, digits and underscores.
+	Name string
+	// Aliases are alternative names, they are not shown in the help nor in the command menu.
+	Aliases []string
+	// Description is shown in the help and in the Telegram command menu.
+	// Commands without a description are hidden.
+	Description string
+	// Usage describes the arguments, e.g. "<monto> [descripción]".
+	Usage string
+	// MinArgs is the minimum number of arguments, the usage is sent back when there are less.
+	MinArgs int
+	// MaxArgs is the maximum number of arguments, zero means no limit.
+	MaxArgs int
+	// ChatTypes restricts the chats where the command is available (e.g.
+	// telegram.ChatTypePrivate), empty allows every chat.
+	ChatTypes []string
+	Handler   HandlerFunc
+}
+
+// availableIn reports if the command can be used in a chat of the given type.
+func (c *Command) availableIn(chatType string) bool {
+	return len(c.ChatTypes) == 0 || slices.Contains(c.ChatTypes, chatType)
+}
+
+// Request is a command received by the router.
+type Request struct {
+	Client  *telegram.Client
+	Update  *telegram.Update
+	Message *telegram.Message
+	// Command is the registered command, nil for unknown commands.
+	Command *Command
+	// Invocation is the command as typed by the user.
+	Invocation telegram.Command
+	// Args are the arguments split on white space.
+	Args []string
+	// Lang is the language of the user, set by the I18n middleware.
+	Lang string
+
+	texts map[string]string
+}
+
+// ChatID returns the chat where the command was sent.
+func (r *Request) ChatID() int64 {
+	return r.Message.Chat.ID
+}
+
+// UserID returns the user who sent the command, zero for anonymous group admins and channels.
+func (r *Request) UserID() int64 {
+	if r.Message.From == nil {
+		return 0
+	}
+	return r.Message.From.ID
+}
+
+// Arg returns the i-th argument, or an empty string if there are less arguments.
+func (r *Request) Arg(i int) string {
+	if i < 0 || i >= len(r.Args) {
+		return ""
+	}
+	return r.Args[i]
+}
+
+// Rest returns the arguments from the i-th on, joined by spaces.
+func (r *Request) Rest(i int) string {
+	if i < 0 || i >= len(r.Args) {
+		return ""
+	}
+	return strings.Join(r.Args[i:], " ")
+}
+
+// Reply sends text to the chat of the command. Long texts are split in several messages.
+func (r *Request) Reply(ctx context.Context, text string) error {
+	return r.ReplyWith(ctx, &telegram.SendMessageRequest{Text: text})
+}
+
+// ReplyWith sends req to the chat of the command, ChatID is set if empty.
+// A long reply cut after its first message is only logged: failing would make
+// the update be retried and the sent messages repeated.
+func (r *Request) ReplyWith(ctx context.Context, req *telegram.SendMessageRequest) error {
+	if req.ChatID == nil {
+		req.ChatID = r.ChatID()
+	}
+	_, err := r.Client.SendLongMessage(ctx, req)
+	var partialErr *telegram.PartialSendError
+	if errors.As(err, &partialErr) {
+		log.Printf("Reply to chat %d was cut after %d messages: %v", r.ChatID(), partialErr.Sent, partialErr.Err)
+		return nil
+	}
+	return err
+}
+
+// Texts used by the router, they can be translated with the I18n middleware.
+const (
+	TextUnknownCommand = "bot.unknown_command"
+	TextUsage          = "bot.usage"
+	TextWrongChatType  = "bot.wrong_chat_type"
+	TextForbidden      = "bot.forbidden"
+	TextHelpHeader     = "bot.help_header"
+)
+
+// DefaultTexts are the texts used when the language of the user has no translation.
+var DefaultTexts = map[string]string{
+	TextUnknownCommand: "Comando no soportado, usa /help para ver los comandos disponibles.",
+	TextUsage:          "Uso: %s",
+	TextWrongChatType:  "Este comando no está disponible en este chat.",
+	TextForbidden:      "No tienes permiso para usar este bot.",
+	TextHelpHeader:     "Comandos disponibles:",
+}
+
+// T returns the text for key in the language of the user, formatted with args.
+// It falls back to DefaultTexts and then to the key itself.
+func (r *Request) T(key string, args ...interface{}) string {
+	text, ok := r.texts[key]
+	if !ok {
+		if text, ok = DefaultTexts[key]; !ok {
+			text = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+var commandName = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// Router dispatches bot commands to their handlers. Register the commands and
+// middleware before handling updates, the Router is not safe for concurrent registration.
+type Router struct {
+	client      *telegram.Client
+	botUsername string
+	commands    []*Command
+	byName      map[string]*Command
+	middleware  []Middleware
+
+	// NotFound handles unknown commands (default: reply TextUnknownCommand).
+	NotFound HandlerFunc
+}
+
+// NewRouter creates a Router that replies with client. Commands addressed to
+// other bots ("/start@OtherBot") are ignored, unless botUsername is empty.
+func NewRouter(client *telegram.Client, botUsername string) *Router {
+	return &Router{
+		client:      client,
+		botUsername: strings.TrimPrefix(botUsername, "@"),
+		byName:      make(map[string]*Command),
+		NotFound: func(ctx context.Context, req *Request) error {
+			return req.Reply(ctx, req.T(TextUnknownCommand))
+		},
+	}
+}
+
+// Use appends middleware, the first one registered is the outermost.
+// Middleware also runs for unknown commands, with a nil Request.Command.
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Handle registers a command. It panics if a name is invalid or already registered.
+func (r *Router) Handle(command Command) {
+	if command.Handler == nil {
+		panic("bot: nil handler for command " + command.Name)
+	}
+	registered := &command
+	for _, name := range append([]string{command.Name}, command.Aliases...) {
+		if !commandName.MatchString(name) {
+			panic("bot: invalid command name " + name)
+		}
+		if _, ok := r.byName[name]; ok {
+			panic("bot: command " + name + " already registered")
+		}
+		r.byName[name] = registered
+	}
+	r.commands = append(r.commands, registered)
+}
+
+// Commands returns the registered commands in registration order.
+func (r *Router) Commands() []Command {
+	commands := make([]Command, len(r.commands))
+	for i, command := range r.commands {
+		commands[i] = *command
+	}
+	return commands
+}
+
+// Match returns the registered command of update, or nil for unknown commands.
+// The second result is false when the update is not a command for this bot.
+func (r *Router) Match(update *telegram.Update) (*Command, bool) {
+	msg := update.Message
+	if msg == nil {
+		return nil, false
+	}
+	invocation, ok := msg.Command()
+	if !ok {
+		return nil, false
+	}
+	if invocation.BotUsername != "" && r.botUsername != "" && !strings.EqualFold(invocation.BotUsername, r.botUsername) {
+		return nil, false
+	}
+	return r.byName[invocation.Name], true
+}
+
+// HandleUpdate runs the handler of the command in update. Updates that are not
+// commands for this bot are ignored. It implements telegram.UpdateHandler.
+func (r *Router) HandleUpdate(ctx context.Context, update *telegram.Update) error {
+	command, ok := r.Match(update)
+	if !ok {
+		return nil
+	}
+	invocation, _ := update.Message.Command()
+	req := &Request{
+		Client:     r.client,
+		Update:     update,
+		Message:    update.Message,
+		Command:    command,
+		Invocation: invocation,
+		Args:       invocation.Fields(),
+	}
+
+	handler := r.dispatch
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+	return handler(ctx, req)
+}
+
+// dispatch validates the request against its command and runs the handler.
+func (r *Router) dispatch(ctx context.Context, req *Request) error {
+	command := req.Command
+	if command == nil {
+		return r.NotFound(ctx, req)
+	}
+	if !command.availableIn(req.Message.Chat.Type) {
+		return req.Reply(ctx, req.T(TextWrongChatType))
+	}
+	if len(req.Args) < command.MinArgs || (command.MaxArgs > 0 && len(req.Args) > command.MaxArgs) {
+		return req.Reply(ctx, req.T(TextUsage, usage(command)))
+	}
+	return command.Handler(ctx, req)
+}
+
+// usage returns the command with its arguments, e.g. "/gasto <monto> [descripción]".
+func usage(command *Command) string {
+	if command.Usage == "" {
+		return "/" + command.Name
+	}
+	return "/" + command.Name + " " + command.Usage
+}
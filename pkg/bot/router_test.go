@@ -0,0 +1,179 @@
+package bot_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/bot"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/telegramtest"
+)
+
+// Chats of the tests, ChatFromID gives them their type.
+const (
+	privateChat    = int64(42)
+	groupChat      = int64(-4012345678)
+	supergroupChat = int64(-1001234567890)
+)
+
+// newTestRouter creates a router for telegramtest.Bot with the command "gasto",
+// that takes one or two arguments in private chats, and "split" for groups.
+// The handled commands are appended to handled.
+func newTestRouter(t *testing.T, handled *[]string) (*telegramtest.Server, *bot.Router) {
+	t.Helper()
+	server := telegramtest.NewServer()
+	t.Cleanup(server.Close)
+	router := bot.NewRouter(server.Client(), "@"+telegramtest.Bot.Username)
+	record := func(ctx context.Context, req *bot.Request) error {
+		*handled = append(*handled, req.Command.Name+" "+strings.Join(req.Args, ","))
+		return nil
+	}
+	router.Handle(bot.Command{
+		Name:        "gasto",
+		Aliases:     []string{"g"},
+		Description: "Registra un gasto",
+		Usage:       "<monto> [descripción]",
+		MinArgs:     1,
+		MaxArgs:     2,
+		ChatTypes:   []string{telegram.ChatTypePrivate},
+		Handler:     record,
+	})
+	router.Handle(bot.Command{
+		Name:        "split",
+		Description: "Divide un gasto",
+		ChatTypes:   []string{telegram.ChatTypeGroup, telegram.ChatTypeSupergroup},
+		Handler:     record,
+	})
+	return server, router
+}
+
+// replies returns the texts sent by the bot.
+func replies(t *testing.T, server *telegramtest.Server) []string {
+	t.Helper()
+	var texts []string
+	for _, call := range server.CallsTo("sendMessage") {
+		var req telegram.SendMessageRequest
+		if err := call.Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		texts = append(texts, req.Text)
+	}
+	return texts
+}
+
+func TestRouterDispatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		chatID  int64
+		text    string
+		handled string
+		reply   string
+	}{
+		{"command", privateChat, "/gasto 150", "gasto 150", ""},
+		{"this bot", privateChat, "/gasto@" + telegramtest.Bot.Username + " 150 tacos", "gasto 150,tacos", ""},
+		{"case insensitive", privateChat, "/GASTO@TEST_BOT 150", "gasto 150", ""},
+		{"alias", privateChat, "/g 150", "gasto 150", ""},
+		{"another bot", privateChat, "/gasto@other_bot 150", "", ""},
+		{"not a command", privateChat, "gasto 150", "", ""},
+		{"unknown command", privateChat, "/borrar 1", "", bot.DefaultTexts[bot.TextUnknownCommand]},
+		{"unknown command of another bot", groupChat, "/borrar@other_bot", "", ""},
+		{"missing arguments", privateChat, "/gasto", "", "Uso: /gasto <monto> [descripción]"},
+		{"too many arguments", privateChat, "/gasto 150 tacos al pastor", "", "Uso: /gasto <monto> [descripción]"},
+		{"private command in a group", groupChat, "/gasto 150", "", bot.DefaultTexts[bot.TextWrongChatType]},
+		{"group command in a group", groupChat, "/split", "split ", ""},
+		{"group command in a supergroup", supergroupChat, "/split@test_bot", "split ", ""},
+		{"group command in a private chat", privateChat, "/split", "", bot.DefaultTexts[bot.TextWrongChatType]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled []string
+			server, router := newTestRouter(t, &handled)
+			update := server.InjectMessage(tt.chatID, tt.text)
+			if err := router.HandleUpdate(context.Background(), &update); err != nil {
+				t.Fatalf("HandleUpdate() error = %v", err)
+			}
+
+			if got := strings.Join(handled, ";"); got != tt.handled {
+				t.Errorf("handled = %q, want %q", got, tt.handled)
+			}
+			if got := strings.Join(replies(t, server), ";"); got != tt.reply {
+				t.Errorf("replies = %q, want %q", got, tt.reply)
+			}
+		})
+	}
+}
+
+func TestRouterMatch(t *testing.T) {
+	var handled []string
+	server, router := newTestRouter(t, &handled)
+	tests := []struct {
+		text    string
+		command string
+		ok      bool
+	}{
+		{"/gasto@test_bot 1", "gasto", true},
+		{"/g", "gasto", true},
+		{"/borrar", "", true},
+		{"/gasto@other_bot", "", false},
+		{"hola", "", false},
+	}
+	for _, tt := range tests {
+		update := server.InjectMessage(privateChat, tt.text)
+		command, ok := router.Match(&update)
+		name := ""
+		if command != nil {
+			name = command.Name
+		}
+		if name != tt.command || ok != tt.ok {
+			t.Errorf("Match(%q) = %q, %t, want %q, %t", tt.text, name, ok, tt.command, tt.ok)
+		}
+	}
+
+	// Without a username every "@botname" is accepted
+	anyBot := bot.NewRouter(server.Client(), "")
+	anyBot.Handle(bot.Command{Name: "gasto", Handler: func(ctx context.Context, req *bot.Request) error { return nil }})
+	update := server.InjectMessage(privateChat, "/gasto@other_bot")
+	if command, ok := anyBot.Match(&update); command == nil || !ok {
+		t.Errorf("Match() without a bot username = %v, %t, want gasto", command, ok)
+	}
+}
+
+func TestRouterHandlePanics(t *testing.T) {
+	handler := func(ctx context.Context, req *bot.Request) error { return nil }
+	tests := []struct {
+		name    string
+		command bot.Command
+	}{
+		{"nil handler", bot.Command{Name: "nuevo"}},
+		{"uppercase", bot.Command{Name: "Nuevo", Handler: handler}},
+		{"too long", bot.Command{Name: strings.Repeat("a", 33), Handler: handler}},
+		{"registered name", bot.Command{Name: "gasto", Handler: handler}},
+		{"registered alias", bot.Command{Name: "nuevo", Aliases: []string{"g"}, Handler: handler}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled []string
+			_, router := newTestRouter(t, &handled)
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Handle(%+v) did not panic", tt.command)
+				}
+			}()
+			router.Handle(tt.command)
+		})
+	}
+}
+
+func TestRequestArgs(t *testing.T) {
+	req := &bot.Request{Args: []string{"150", "tacos", "al", "pastor"}}
+	if req.Arg(0) != "150" || req.Arg(4) != "" || req.Arg(-1) != "" {
+		t.Errorf("Arg() = %q, %q, %q", req.Arg(0), req.Arg(4), req.Arg(-1))
+	}
+	if got := req.Rest(1); got != "tacos al pastor" {
+		t.Errorf("Rest(1) = %q, want %q", got, "tacos al pastor")
+	}
+	if got := req.Rest(4); got != "" {
+		t.Errorf("Rest(4) = %q, want empty", got)
+	}
+}
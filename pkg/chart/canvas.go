@@ -0,0 +1,167 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// palette are the colors of the slices and series, in order.
+var palette = []color.RGBA{
+	{0x4e, 0x79, 0xa7, 0xff},
+	{0xf2, 0x8e, 0x2b, 0xff},
+	{0xe1, 0x57, 0x59, 0xff},
+	{0x76, 0xb7, 0xb2, 0xff},
+	{0x59, 0xa1, 0x4f, 0xff},
+	{0xed, 0xc9, 0x48, 0xff},
+	{0xb0, 0x7a, 0xa1, 0xff},
+	{0x9c, 0x75, 0x5f, 0xff},
+}
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	foreground = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	muted      = color.RGBA{0x77, 0x77, 0x77, 0xff}
+	grid       = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+)
+
+// fonts are the Go fonts, they cover the Latin alphabet with accents.
+var fonts = sync.OnceValue(func() [2]*opentype.Font {
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		panic("chart: parsing Go Regular: " + err.Error())
+	}
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		panic("chart: parsing Go Bold: " + err.Error())
+	}
+	return [2]*opentype.Font{regular, bold}
+})
+
+// newFace creates a face of f, faces are not safe for concurrent use so each canvas has its own.
+func newFace(f *opentype.Font, size float64) font.Face {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		panic("chart: creating font face: " + err.Error())
+	}
+	return face
+}
+
+// canvas is an image with the faces to write on it.
+type canvas struct {
+	img                         *image.RGBA
+	titleFace, labelFace, small font.Face
+}
+
+func newCanvas(width, height int) *canvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	f := fonts()
+	return &canvas{
+		img:       img,
+		titleFace: newFace(f[1], 26),
+		labelFace: newFace(f[0], 17),
+		small:     newFace(f[0], 14),
+	}
+}
+
+// title writes the title centered at the top.
+func (c *canvas) title(text string) {
+	c.text(c.fit(text, c.titleFace, c.img.Bounds().Dx()-40), c.img.Bounds().Dx()/2, 45, c.titleFace, foreground, alignCenter)
+}
+
+// alignment of a text to its x.
+type alignment int
+
+const (
+	alignLeft alignment = iota
+	alignCenter
+	alignRight
+)
+
+// text writes s with its baseline at y.
+func (c *canvas) text(s string, x, y int, face font.Face, col color.RGBA, align alignment) {
+	drawer := font.Drawer{Dst: c.img, Src: image.NewUniform(col), Face: face}
+	switch align {
+	case alignCenter:
+		x -= drawer.MeasureString(s).Round() / 2
+	case alignRight:
+		x -= drawer.MeasureString(s).Round()
+	}
+	drawer.Dot = fixed.P(x, y)
+	drawer.DrawString(s)
+}
+
+// width returns the width of s in pixels.
+func (c *canvas) width(s string, face font.Face) int {
+	return font.MeasureString(face, s).Round()
+}
+
+// fit shortens s with an ellipsis until it is at most max pixels wide.
+func (c *canvas) fit(s string, face font.Face, max int) string {
+	if c.width(s, face) <= max {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && c.width(string(runes)+"…", face) > max {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// rect fills r with col.
+func (c *canvas) rect(r image.Rectangle, col color.RGBA) {
+	draw.Draw(c.img, r, image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// blend paints the pixel (x, y) with col, coverage is the part of the pixel covered, 0-1.
+func (c *canvas) blend(x, y int, col color.RGBA, coverage float64) {
+	if coverage <= 0 || !(image.Point{x, y}).In(c.img.Bounds()) {
+		return
+	}
+	coverage = math.Min(coverage, 1)
+	dst := c.img.RGBAAt(x, y)
+	mix := func(src, dst uint8) uint8 {
+		return uint8(float64(src)*coverage + float64(dst)*(1-coverage) + 0.5)
+	}
+	c.img.SetRGBA(x, y, color.RGBA{mix(col.R, dst.R), mix(col.G, dst.G), mix(col.B, dst.B), 0xff})
+}
+
+// line draws an antialiased segment of the given width.
+func (c *canvas) line(x0, y0, x1, y1, width float64, col color.RGBA) {
+	half := width / 2
+	minX, maxX := int(math.Floor(math.Min(x0, x1)-half-1)), int(math.Ceil(math.Max(x0, x1)+half+1))
+	minY, maxY := int(math.Floor(math.Min(y0, y1)-half-1)), int(math.Ceil(math.Max(y0, y1)+half+1))
+	dx, dy := x1-x0, y1-y0
+	length := dx*dx + dy*dy
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			// Distance from the center of the pixel to the nearest point of the segment
+			px, py := float64(x)+0.5, float64(y)+0.5
+			t := 0.0
+			if length > 0 {
+				t = math.Max(0, math.Min(1, ((px-x0)*dx+(py-y0)*dy)/length))
+			}
+			distance := math.Hypot(px-(x0+t*dx), py-(y0+t*dy))
+			c.blend(x, y, col, half+0.5-distance)
+		}
+	}
+}
+
+// disc draws an antialiased filled circle.
+func (c *canvas) disc(cx, cy, radius float64, col color.RGBA) {
+	for y := int(cy - radius - 1); y <= int(cy+radius+1); y++ {
+		for x := int(cx - radius - 1); x <= int(cx+radius+1); x++ {
+			distance := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+			c.blend(x, y, col, radius+0.5-distance)
+		}
+	}
+}
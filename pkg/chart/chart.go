@@ -0,0 +1,132 @@
+// Package chart renders pie, bar and line charts as PNG images in pure Go,
+// small enough to send them with telegram.Client.SendPhoto.
+//
+//	c := &chart.Chart{Type: chart.Pie, Title: "Octubre", Items: items, Format: format}
+//	var buf bytes.Buffer
+//	if err := c.WritePNG(&buf); err != nil {
+//		...
+//	}
+//	client.SendPhoto(ctx, &telegram.SendPhotoRequest{ChatID: chatID, Photo: telegram.FileFromReader("chart.png", &buf)})
+package chart
+
+import (
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// Type is the kind of a chart.
+type Type int
+
+// Types of the charts.
+const (
+	// Pie shows each item as a share of the total, e.g. the expenses per category.
+	Pie Type = iota
+	// Bar shows an item per bar, e.g. the expenses per month.
+	Bar
+	// Line joins the items in order, e.g. the expenses of a category over time.
+	Line
+)
+
+// Size of the images, Telegram shows them whole on a phone.
+const (
+	Width  = 800
+	Height = 600
+)
+
+// MaxSlices is how many slices a pie shows, the smallest items are added up in the last one.
+const MaxSlices = 8
+
+// ErrNoData is returned when there is nothing to draw: a chart without items,
+// or a pie whose values add up to zero.
+var ErrNoData = errors.New("chart: no data")
+
+// Item is a value of a chart, a slice of a pie or a point of a bar or line chart.
+type Item struct {
+	Label string
+	// Value must not be negative.
+	Value float64
+}
+
+// Chart is a chart to render.
+type Chart struct {
+	Type  Type
+	Title string
+	// Items are drawn in order, the slices of a pie clockwise from the top.
+	Items []Item
+	// Format formats the values in the legend and the axis (default: the plain number).
+	Format func(value float64) string
+	// Others labels the slice that adds up the smallest items of a pie (default: "Others").
+	Others string
+}
+
+// Render draws the chart.
+func (c *Chart) Render() (*image.RGBA, error) {
+	if len(c.Items) == 0 {
+		return nil, ErrNoData
+	}
+	canvas := newCanvas(Width, Height)
+	canvas.title(c.Title)
+	switch c.Type {
+	case Pie:
+		items := c.slices()
+		if len(items) == 0 {
+			return nil, ErrNoData
+		}
+		canvas.pie(items, c.format)
+	default:
+		canvas.plot(c.Type, c.Items, c.format)
+	}
+	return canvas.img, nil
+}
+
+// WritePNG draws the chart and encodes it to w as a PNG image.
+func (c *Chart) WritePNG(w io.Writer) error {
+	img, err := c.Render()
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// format formats value with Format, or as a plain number.
+func (c *Chart) format(value float64) string {
+	if c.Format != nil {
+		return c.Format(value)
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// slices returns the positive items from the greatest, adding up the ones
+// after MaxSlices-1 in a single item labeled Others.
+func (c *Chart) slices() []Item {
+	var items []Item
+	for _, item := range c.Items {
+		if item.Value > 0 {
+			items = append(items, item)
+		}
+	}
+	slices.SortStableFunc(items, func(a, b Item) int {
+		switch {
+		case a.Value > b.Value:
+			return -1
+		case a.Value < b.Value:
+			return 1
+		}
+		return 0
+	})
+	if len(items) <= MaxSlices {
+		return items
+	}
+	others := Item{Label: c.Others}
+	if others.Label == "" {
+		others.Label = "Others"
+	}
+	for _, item := range items[MaxSlices-1:] {
+		others.Value += item.Value
+	}
+	return append(items[:MaxSlices-1], others)
+}
@@ -0,0 +1,131 @@
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// update rewrites the golden images in testdata: go test ./pkg/chart -update
+var update = flag.Bool("update", false, "update the golden images in testdata")
+
+// tolerance is the difference allowed per channel, for the rounding of the
+// floating point math across platforms.
+const tolerance = 2
+
+func months(values ...float64) []Item {
+	labels := []string{"Ene", "Feb", "Mar", "Abr", "May", "Jun", "Jul", "Ago", "Sep", "Oct", "Nov", "Dic"}
+	items := make([]Item, len(values))
+	for i, value := range values {
+		items[i] = Item{Label: labels[i%len(labels)], Value: value}
+	}
+	return items
+}
+
+func many(n int) []Item {
+	items := make([]Item, n)
+	for i := range items {
+		items[i] = Item{Label: "Categoría " + strconv.Itoa(i+1), Value: float64((i%7 + 1) * 350)}
+	}
+	return items
+}
+
+func money(value float64) string {
+	return "$" + strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		chart Chart
+	}{
+		{"pie", Chart{Type: Pie, Title: "Gastos de marzo 2024", Format: money, Items: []Item{
+			{"Comida", 4250.5}, {"Transporte", 1200}, {"Hogar", 8900}, {"Salud", 0}, {"Entretenimiento", 640},
+		}}},
+		{"pie_many", Chart{Type: Pie, Title: "Muchas categorías", Format: money, Others: "Otras", Items: many(15)}},
+		{"pie_single", Chart{Type: Pie, Title: "Una categoría", Items: []Item{{"Comida", 100}}}},
+		{"bar", Chart{Type: Bar, Title: "Gastos de 2024", Format: money, Items: months(12000, 9800.5, 15320, 0, 7000)}},
+		{"bar_zero", Chart{Type: Bar, Title: "Sin gastos", Items: months(0, 0, 0)}},
+		{"bar_many", Chart{Type: Bar, Title: "Muchos días", Items: many(31)}},
+		{"line", Chart{Type: Line, Title: "Comida en los últimos 12 meses", Format: money, Items: months(3200, 4100, 0, 2800, 3900, 4500, 5100, 4700, 3000, 3600, 4200, 6100)}},
+		{"line_zero", Chart{Type: Line, Title: "Sin gastos", Items: months(0, 0, 0, 0)}},
+		{"line_single", Chart{Type: Line, Title: "Un mes", Items: months(1500)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.chart.WritePNG(&buf); err != nil {
+				t.Fatalf("WritePNG() error = %v", err)
+			}
+			golden := filepath.Join("testdata", tt.name+".png")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			got, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := readPNG(golden)
+			if err != nil {
+				t.Fatalf("reading %s, run the test with -update to create it: %v", golden, err)
+			}
+			if diff := compare(got, want); diff != 0 {
+				t.Errorf("%d pixels differ from %s, check the image and run the test with -update if the change is right", diff, golden)
+			}
+		})
+	}
+}
+
+func TestNoData(t *testing.T) {
+	for name, chart := range map[string]Chart{
+		"pie without items":  {Type: Pie},
+		"bar without items":  {Type: Bar},
+		"line without items": {Type: Line},
+		"pie of zeros":       {Type: Pie, Items: months(0, 0)},
+	} {
+		if err := chart.WritePNG(&bytes.Buffer{}); !errors.Is(err, ErrNoData) {
+			t.Errorf("WritePNG() of a %s error = %v, want ErrNoData", name, err)
+		}
+	}
+}
+
+func readPNG(name string) (image.Image, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// compare returns how many pixels of got differ from want by more than
+// tolerance in a channel, every pixel if the sizes differ.
+func compare(got, want image.Image) int {
+	bounds := want.Bounds()
+	if got.Bounds() != bounds {
+		return bounds.Dx() * bounds.Dy()
+	}
+	diff := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r1, g1, b1, a1 := got.At(x, y).RGBA()
+			r2, g2, b2, a2 := want.At(x, y).RGBA()
+			for _, d := range []int{int(r1>>8) - int(r2>>8), int(g1>>8) - int(g2>>8), int(b1>>8) - int(b2>>8), int(a1>>8) - int(a2>>8)} {
+				if d > tolerance || d < -tolerance {
+					diff++
+					break
+				}
+			}
+		}
+	}
+	return diff
+}
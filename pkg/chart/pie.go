@@ -0,0 +1,58 @@
+package chart
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// pie draws the items as slices clockwise from the top, with a legend on the right.
+func (c *canvas) pie(items []Item, format func(float64) string) {
+	var total float64
+	for _, item := range items {
+		total += item.Value
+	}
+	// ends are the angles where each slice ends, clockwise from the top
+	ends := make([]float64, len(items))
+	var sum float64
+	for i, item := range items {
+		sum += item.Value
+		ends[i] = sum / total * 2 * math.Pi
+	}
+
+	const cx, cy, radius = 260.0, 330.0, 210.0
+	for y := int(cy - radius - 1); y <= int(cy+radius+1); y++ {
+		for x := int(cx - radius - 1); x <= int(cx+radius+1); x++ {
+			dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+			distance := math.Hypot(dx, dy)
+			if distance > radius+0.5 {
+				continue
+			}
+			angle := math.Atan2(dx, -dy)
+			if angle < 0 {
+				angle += 2 * math.Pi
+			}
+			slice := 0
+			for slice < len(ends)-1 && angle >= ends[slice] {
+				slice++
+			}
+			c.blend(x, y, palette[slice%len(palette)], radius+0.5-distance)
+		}
+	}
+	// White borders between the slices hide the steps of their straight edges
+	if len(items) > 1 {
+		for _, end := range ends {
+			c.line(cx, cy, cx+radius*math.Sin(end), cy-radius*math.Cos(end), 2, background)
+		}
+	}
+
+	const legendX, rowHeight = 500, 52
+	y := int(cy) - len(items)*rowHeight/2
+	for i, item := range items {
+		c.rect(image.Rect(legendX, y+4, legendX+18, y+22), palette[i%len(palette)])
+		c.text(c.fit(item.Label, c.labelFace, Width-legendX-50), legendX+28, y+20, c.labelFace, foreground, alignLeft)
+		detail := fmt.Sprintf("%d%% · %s", int(math.Round(item.Value/total*100)), format(item.Value))
+		c.text(c.fit(detail, c.small, Width-legendX-50), legendX+28, y+40, c.small, muted, alignLeft)
+		y += rowHeight
+	}
+}
@@ -0,0 +1,92 @@
+package chart
+
+import (
+	"image"
+	"math"
+)
+
+// Margins of the plot area of the bar and line charts, the left one grows with the labels of the axis.
+const (
+	plotTop    = 80
+	plotRight  = 30
+	plotBottom = 60
+	ticks      = 5
+)
+
+// plot draws the items as bars or as a line over a vertical axis starting at zero.
+func (c *canvas) plot(kind Type, items []Item, format func(float64) string) {
+	var max float64
+	for _, item := range items {
+		max = math.Max(max, item.Value)
+	}
+	step := niceStep(max / ticks)
+	top := step * ticks
+
+	labels := make([]string, 0, ticks+1)
+	labelWidth := 0
+	for i := 0; i <= ticks; i++ {
+		label := format(float64(i) * step)
+		labels = append(labels, label)
+		labelWidth = int(math.Max(float64(labelWidth), float64(c.width(label, c.small))))
+	}
+	area := image.Rect(labelWidth+20, plotTop, Width-plotRight, Height-plotBottom)
+	y := func(value float64) float64 {
+		return float64(area.Max.Y) - value/top*float64(area.Dy())
+	}
+
+	// Grid and labels of the axis
+	for i, label := range labels {
+		gridY := int(math.Round(y(float64(i) * step)))
+		c.rect(image.Rect(area.Min.X, gridY, area.Max.X, gridY+1), grid)
+		c.text(label, area.Min.X-10, gridY+5, c.small, muted, alignRight)
+	}
+
+	slot := float64(area.Dx()) / float64(len(items))
+	center := func(i int) float64 {
+		return float64(area.Min.X) + slot*(float64(i)+0.5)
+	}
+	// Skip labels of the items when they would overlap
+	widest := 0
+	for _, item := range items {
+		widest = int(math.Max(float64(widest), float64(c.width(item.Label, c.small))))
+	}
+	every := int(math.Ceil(float64(widest+8) / slot))
+	for i, item := range items {
+		if i%every == 0 {
+			c.text(item.Label, int(center(i)), area.Max.Y+22, c.small, foreground, alignCenter)
+		}
+	}
+
+	fill := palette[0]
+	switch kind {
+	case Bar:
+		half := slot * 0.3
+		for i, item := range items {
+			c.rect(image.Rect(int(math.Round(center(i)-half)), int(math.Round(y(item.Value))),
+				int(math.Round(center(i)+half)), area.Max.Y), fill)
+		}
+	case Line:
+		for i := 1; i < len(items); i++ {
+			c.line(center(i-1), y(items[i-1].Value), center(i), y(items[i].Value), 3, fill)
+		}
+		for i, item := range items {
+			c.disc(center(i), y(item.Value), 5, fill)
+		}
+	}
+	// Base of the axis over the bars
+	c.rect(image.Rect(area.Min.X, area.Max.Y, area.Max.X, area.Max.Y+1), muted)
+}
+
+// niceStep rounds step up to 1, 2, 2.5 or 5 times a power of ten, so the axis has round labels.
+func niceStep(step float64) float64 {
+	if step <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(step)))
+	for _, nice := range []float64{1, 2, 2.5, 5} {
+		if step <= nice*magnitude {
+			return nice * magnitude
+		}
+	}
+	return 10 * magnitude
+}
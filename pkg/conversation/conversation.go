@@ -0,0 +1,484 @@
+// Package conversation runs multi-step flows, like asking the amount, the
+// category and a confirmation of an expense, whose state is persisted
+// between Lambda invocations.
+//
+//	manager := conversation.NewManager(client, conversation.NewMemoryStore())
+//	manager.Register(conversation.Flow{
+//		Name: "expense",
+//		Steps: []conversation.Step{
+//			{Name: "amount", Prompt: "¿Cuánto gastaste?", Validate: parseAmount},
+//			{Name: "category", Prompt: "¿En qué categoría?", Options: []string{"Comida", "Transporte"}},
+//		},
+//		OnComplete: func(ctx context.Context, state *conversation.State) (string, error) {
+//			return "Gasto de " + state.Data["amount"] + " registrado", nil
+//		},
+//	})
+//	manager.Start(ctx, conversation.KeyOf(msg), "expense", nil)
+//	...
+//	handled, completed, err := manager.HandleMessage(ctx, nextMsg)
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/keyboard"
+)
+
+// DefaultTimeout is how long a conversation waits for an answer when the flow has no Timeout.
+const DefaultTimeout = 10 * time.Minute
+
+// maxConflictAttempts bounds how many times a message is applied to a freshly
+// loaded state when the previous state was changed concurrently (ErrConflict).
+const maxConflictAttempts = 3
+
+// ErrCancel can be returned by Step.Validate to end the conversation as cancelled,
+// e.g. when the user answers "No" to a confirmation.
+var ErrCancel = errors.New("conversation cancelled")
+
+// Texts sent by the Manager, they can be replaced, e.g. to translate them.
+var (
+	BackText      = "⬅️ Atrás"
+	CancelledText = "Operación cancelada."
+	ExpiredText   = "La operación anterior expiró por inactividad."
+	InvalidText   = "Respuesta no válida: %s"
+	ChooseText    = "elige una de las opciones del teclado"
+	TextOnlyText  = "envía la respuesta como texto"
+)
+
+// Commands that cancel the conversation or go back to the previous step, in any step.
+var (
+	CancelCommands = []string{"cancel", "cancelar"}
+	BackCommands   = []string{"back", "atras"}
+)
+
+// Key identifies a conversation: a user in a chat. They are the from.id and
+// chat.id of the message, the same values the state machine assigns to $User and $Chat.
+type Key struct {
+	ChatID int64 `json:"chat_id"`
+	UserID int64 `json:"user_id"`
+}
+
+// KeyOf returns the key of the conversation msg belongs to.
+func KeyOf(msg *telegram.Message) Key {
+	key := Key{ChatID: msg.Chat.ID}
+	if msg.From != nil {
+		key.UserID = msg.From.ID
+	}
+	return key
+}
+
+// String returns the key as stored, e.g. "chat:-100123:user:42".
+func (k Key) String() string {
+	return fmt.Sprintf("chat:%d:user:%d", k.ChatID, k.UserID)
+}
+
+// State is the persisted progress of a conversation.
+type State struct {
+	// Key is who the conversation is with, e.g. to store the result in OnComplete.
+	Key  Key    `json:"key"`
+	Flow string `json:"flow"`
+	Step string `json:"step"`
+	// Data holds the validated answers by step name, and the initial values given to Start.
+	Data map[string]string `json:"data"`
+	// History holds the previous steps, for the back commands.
+	History []string `json:"history,omitempty"`
+	// ReplyTo is the last message of the user, the questions reply to it so
+	// their keyboards are only shown to the user in groups.
+	ReplyTo int64 `json:"reply_to,omitempty"`
+	// LastMessageID is the last message of the user applied to the state. The
+	// same message received again, e.g. when an update is retried after a
+	// question failed to send, only asks the current step again.
+	LastMessageID int64     `json:"last_message_id,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	// Version is used by the stores for optimistic locking, do not change it.
+	Version int64 `json:"version"`
+}
+
+// Step is one question of a flow.
+type Step struct {
+	// Name identifies the step and is the key of its answer in State.Data.
+	Name string
+	// Prompt is the question. The user answers with a ForceReply, or with a
+	// reply keyboard when there are Options.
+	Prompt string
+	// PromptFunc builds the question from the answers so far, e.g. a summary
+	// to confirm. It overrides Prompt.
+	PromptFunc func(state *State) string
+	// Placeholder is shown in the input field while answering.
+	Placeholder string
+	// Options restricts the answers to the buttons of a reply keyboard.
+	Options []string
+	// OptionsFunc builds the options from the answers so far, e.g. choices
+	// that depend on the user. It overrides Options.
+	OptionsFunc func(state *State) []string
+	// Validate checks and normalizes the answer, the returned value is stored.
+	// The error message is sent to the user and the question is asked again.
+	Validate func(answer string, state *State) (string, error)
+	// Next returns the name of the next step, "" ends the flow. By default the
+	// flow continues with the following step in Flow.Steps.
+	Next func(state *State) string
+}
+
+// options returns the answers allowed in the step, none means any text.
+func (s *Step) options(state *State) []string {
+	if s.OptionsFunc != nil {
+		return s.OptionsFunc(state)
+	}
+	return s.Options
+}
+
+// Flow is a sequence of steps.
+type Flow struct {
+	Name  string
+	Steps []Step
+	// Timeout is how long to wait for each answer (default: DefaultTimeout).
+	Timeout time.Duration
+	// OnComplete is called when the last step is answered. The returned text,
+	// if any, is sent to the user.
+	OnComplete func(ctx context.Context, state *State) (string, error)
+}
+
+// step returns the step with the given name.
+func (f *Flow) step(name string) (*Step, int) {
+	for i := range f.Steps {
+		if f.Steps[i].Name == name {
+			return &f.Steps[i], i
+		}
+	}
+	return nil, -1
+}
+
+// Manager starts conversations and feeds them the answers of the users.
+type Manager struct {
+	client *telegram.Client
+	store  Store
+	flows  map[string]*Flow
+	now    func() time.Time
+}
+
+// NewManager creates a Manager that asks with client and persists the state in store.
+func NewManager(client *telegram.Client, store Store) *Manager {
+	return &Manager{
+		client: client,
+		store:  store,
+		flows:  make(map[string]*Flow),
+		now:    time.Now,
+	}
+}
+
+// Register adds a flow. It panics if the flow has no steps or its name is already registered.
+func (m *Manager) Register(flow Flow) {
+	if len(flow.Steps) == 0 {
+		panic("conversation: flow " + flow.Name + " has no steps")
+	}
+	if _, ok := m.flows[flow.Name]; ok {
+		panic("conversation: flow " + flow.Name + " already registered")
+	}
+	if flow.Timeout <= 0 {
+		flow.Timeout = DefaultTimeout
+	}
+	m.flows[flow.Name] = &flow
+}
+
+// Start begins the flow for key, replacing any conversation in progress, and
+// asks the first step. The steps already answered in data are skipped, but
+// they can still be changed with the back commands.
+func (m *Manager) Start(ctx context.Context, key Key, flowName string, data map[string]string) error {
+	return m.StartReply(ctx, key, 0, flowName, data)
+}
+
+// StartReply is Start for a flow started by the message replyTo of the user,
+// e.g. a command. The first question replies to it, see State.ReplyTo.
+func (m *Manager) StartReply(ctx context.Context, key Key, replyTo int64, flowName string, data map[string]string) error {
+	_, err := retryOnConflict(func() (bool, error) {
+		return false, m.start(ctx, key, replyTo, flowName, data)
+	})
+	return err
+}
+
+func (m *Manager) start(ctx context.Context, key Key, replyTo int64, flowName string, data map[string]string) error {
+	flow, ok := m.flows[flowName]
+	if !ok {
+		return fmt.Errorf("conversation: unknown flow %s", flowName)
+	}
+	current, err := m.store.Load(ctx, key)
+	if err != nil {
+		return err
+	}
+	state := &State{Key: key, Flow: flow.Name, Step: flow.Steps[0].Name, Data: make(map[string]string), ReplyTo: replyTo}
+	if current != nil {
+		state.Version = current.Version
+	}
+	for name, value := range data {
+		state.Data[name] = value
+	}
+	index := 0
+	for index < len(flow.Steps)-1 {
+		if _, ok := data[flow.Steps[index].Name]; !ok {
+			break
+		}
+		state.History = append(state.History, flow.Steps[index].Name)
+		index++
+	}
+	state.Step = flow.Steps[index].Name
+	return m.ask(ctx, key, flow, state, index)
+}
+
+// Active returns the conversation in progress of key, or nil.
+func (m *Manager) Active(ctx context.Context, key Key) (*State, error) {
+	state, err := m.store.Load(ctx, key)
+	if err != nil || state == nil || m.now().After(state.ExpiresAt) {
+		return nil, err
+	}
+	return state, nil
+}
+
+// HandleMessage feeds msg to the conversation in progress of its sender.
+// It returns false when there is no conversation in progress, or when msg is
+// a command other than the cancel and back commands, so it can be routed
+// elsewhere. If another message changed the conversation meanwhile, msg is
+// applied again to the new state. A message already applied is not applied
+// again, the question of the current step is sent instead.
+//
+// When msg answers the last step, completed is the final state of the
+// conversation, with any data OnComplete added, e.g. the ID of what it stored.
+func (m *Manager) HandleMessage(ctx context.Context, msg *telegram.Message) (handled bool, completed *State, err error) {
+	handled, err = retryOnConflict(func() (bool, error) {
+		var err error
+		handled, completed, err = m.handleMessage(ctx, msg)
+		return handled, err
+	})
+	return handled, completed, err
+}
+
+// retryOnConflict repeats fn while the state it saves changed concurrently,
+// at most maxConflictAttempts times.
+func retryOnConflict(fn func() (bool, error)) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		handled, err := fn()
+		if !errors.Is(err, ErrConflict) || attempt >= maxConflictAttempts {
+			return handled, err
+		}
+	}
+}
+
+func (m *Manager) handleMessage(ctx context.Context, msg *telegram.Message) (bool, *State, error) {
+	key := KeyOf(msg)
+	state, err := m.store.Load(ctx, key)
+	if err != nil || state == nil {
+		return false, nil, err
+	}
+	flow, ok := m.flows[state.Flow]
+	if !ok {
+		log.Printf("Dropping conversation %s of unknown flow %s", key, state.Flow)
+		return false, nil, m.store.Delete(ctx, key)
+	}
+
+	command, isCommand := msg.Command()
+	switch {
+	case m.now().After(state.ExpiresAt):
+		// The TTL of the store deletes the state eventually, not at the deadline
+		if err := m.store.Delete(ctx, key); err != nil {
+			return false, nil, err
+		}
+		if !isCommand {
+			return true, nil, m.send(ctx, msg, ExpiredText, keyboard.Remove())
+		}
+		return false, nil, nil
+	case msg.MessageID != 0 && msg.MessageID == state.LastMessageID:
+		return true, nil, m.repeatPrompt(ctx, key, flow, state)
+	case isCommand && slices.Contains(CancelCommands, command.Name), msg.Text == keyboard.CancelText:
+		return true, nil, m.cancel(ctx, key, msg)
+	case isCommand && slices.Contains(BackCommands, command.Name), msg.Text == BackText:
+		state.ReplyTo, state.LastMessageID = msg.MessageID, msg.MessageID
+		return true, nil, m.back(ctx, key, flow, state)
+	case isCommand:
+		return false, nil, nil
+	}
+
+	step, index := flow.step(state.Step)
+	if step == nil {
+		log.Printf("Dropping conversation %s at unknown step %s", key, state.Step)
+		return false, nil, m.store.Delete(ctx, key)
+	}
+	state.ReplyTo, state.LastMessageID = msg.MessageID, msg.MessageID
+	answer := strings.TrimSpace(msg.Text)
+	value, err := m.validate(step, answer, state)
+	if errors.Is(err, ErrCancel) {
+		return true, nil, m.cancel(ctx, key, msg)
+	}
+	if err != nil {
+		// The state is saved before sending anything, a conflict must not repeat the messages
+		if saveErr := m.save(ctx, key, flow, state); saveErr != nil {
+			return true, nil, saveErr
+		}
+		if sendErr := m.send(ctx, msg, fmt.Sprintf(InvalidText, err.Error()), nil); sendErr != nil {
+			return true, nil, sendErr
+		}
+		return true, nil, m.prompt(ctx, key, flow, state, index)
+	}
+
+	state.Data[step.Name] = value
+	next := nextStep(flow, step, index, state)
+	if next == "" {
+		if err := m.complete(ctx, key, flow, state, msg); err != nil {
+			return true, nil, err
+		}
+		return true, state, nil
+	}
+	nextStep, nextIndex := flow.step(next)
+	if nextStep == nil {
+		return true, nil, fmt.Errorf("conversation: flow %s has no step %s", flow.Name, next)
+	}
+	state.History = append(state.History, step.Name)
+	state.Step = nextStep.Name
+	return true, nil, m.ask(ctx, key, flow, state, nextIndex)
+}
+
+// validate checks an answer against the options and the validator of step.
+func (m *Manager) validate(step *Step, answer string, state *State) (string, error) {
+	if answer == "" {
+		return "", errors.New(TextOnlyText)
+	}
+	if options := step.options(state); len(options) > 0 && !slices.Contains(options, answer) {
+		return "", errors.New(ChooseText)
+	}
+	if step.Validate == nil {
+		return answer, nil
+	}
+	return step.Validate(answer, state)
+}
+
+// nextStep returns the name of the step after step, "" when the flow ends.
+func nextStep(flow *Flow, step *Step, index int, state *State) string {
+	if step.Next != nil {
+		return step.Next(state)
+	}
+	if index+1 < len(flow.Steps) {
+		return flow.Steps[index+1].Name
+	}
+	return ""
+}
+
+// ask saves the state with a new deadline and sends the question of the step at index.
+func (m *Manager) ask(ctx context.Context, key Key, flow *Flow, state *State, index int) error {
+	if err := m.save(ctx, key, flow, state); err != nil {
+		return err
+	}
+	return m.prompt(ctx, key, flow, state, index)
+}
+
+// save stores state with a new deadline.
+func (m *Manager) save(ctx context.Context, key Key, flow *Flow, state *State) error {
+	state.ExpiresAt = m.now().Add(flow.Timeout)
+	return m.store.Save(ctx, key, state)
+}
+
+// prompt sends the question of the step at index. In groups the keyboard or
+// the forced reply is Selective: only the user it replies to gets it.
+func (m *Manager) prompt(ctx context.Context, key Key, flow *Flow, state *State, index int) error {
+	step := &flow.Steps[index]
+	prompt := step.Prompt
+	if step.PromptFunc != nil {
+		prompt = step.PromptFunc(state)
+	}
+	// In private chats the chat and the user have the same ID
+	selective := key.ChatID != key.UserID && state.ReplyTo != 0
+	var markup telegram.ReplyMarkup
+	if options := step.options(state); len(options) > 0 {
+		builder := keyboard.NewReply().OneTime().Placeholder(step.Placeholder)
+		if selective {
+			builder.Selective()
+		}
+		for _, option := range options {
+			builder.Row(option)
+		}
+		if len(state.History) > 0 {
+			builder.Row(BackText, keyboard.CancelText)
+		} else {
+			builder.Row(keyboard.CancelText)
+		}
+		markup = builder.Build()
+	} else {
+		forceReply := keyboard.ForceReply(step.Placeholder)
+		forceReply.Selective = selective
+		markup = forceReply
+	}
+	req := &telegram.SendMessageRequest{
+		ChatID:      key.ChatID,
+		Text:        prompt,
+		ReplyMarkup: markup,
+	}
+	if state.ReplyTo != 0 {
+		req.ReplyParameters = &telegram.ReplyParameters{MessageID: int(state.ReplyTo), AllowSendingWithoutReply: true}
+	}
+	_, err := m.client.SendMessage(ctx, req)
+	return err
+}
+
+// repeatPrompt sends the question of the current step again.
+func (m *Manager) repeatPrompt(ctx context.Context, key Key, flow *Flow, state *State) error {
+	_, index := flow.step(state.Step)
+	if index < 0 {
+		return nil
+	}
+	return m.prompt(ctx, key, flow, state, index)
+}
+
+// back returns to the previous step, or asks the first step again.
+func (m *Manager) back(ctx context.Context, key Key, flow *Flow, state *State) error {
+	if len(state.History) > 0 {
+		state.Step = state.History[len(state.History)-1]
+		state.History = state.History[:len(state.History)-1]
+	}
+	_, index := flow.step(state.Step)
+	if index < 0 {
+		index = 0
+		state.Step = flow.Steps[0].Name
+	}
+	return m.ask(ctx, key, flow, state, index)
+}
+
+func (m *Manager) cancel(ctx context.Context, key Key, msg *telegram.Message) error {
+	if err := m.store.Delete(ctx, key); err != nil {
+		return err
+	}
+	return m.send(ctx, msg, CancelledText, keyboard.Remove())
+}
+
+// complete ends the flow and sends the result of OnComplete. The state is
+// deleted once OnComplete succeeds: if it fails, the conversation stays at the
+// last step, so the answer can be applied again when the update is retried.
+func (m *Manager) complete(ctx context.Context, key Key, flow *Flow, state *State, msg *telegram.Message) error {
+	text := ""
+	if flow.OnComplete != nil {
+		var err error
+		if text, err = flow.OnComplete(ctx, state); err != nil {
+			return err
+		}
+	}
+	if err := m.store.Delete(ctx, key); err != nil {
+		return err
+	}
+	if text == "" {
+		return nil
+	}
+	return m.send(ctx, msg, text, keyboard.Remove())
+}
+
+// send sends text to the chat of msg.
+func (m *Manager) send(ctx context.Context, msg *telegram.Message, text string, markup telegram.ReplyMarkup) error {
+	req := &telegram.SendMessageRequest{ChatID: msg.Chat.ID, Text: text}
+	if markup != nil {
+		req.ReplyMarkup = markup
+	}
+	_, err := m.client.SendMessage(ctx, req)
+	return err
+}
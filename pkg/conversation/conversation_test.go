@@ -0,0 +1,248 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/telegramtest"
+)
+
+// conflictingStore fails the first conflicts saves with ErrConflict, as if
+// another message had changed the conversation meanwhile.
+type conflictingStore struct {
+	*MemoryStore
+	conflicts int
+	saves     int
+}
+
+func (s *conflictingStore) Save(ctx context.Context, key Key, state *State) error {
+	s.saves++
+	if s.conflicts > 0 {
+		s.conflicts--
+		return ErrConflict
+	}
+	return s.MemoryStore.Save(ctx, key, state)
+}
+
+var testFlow = Flow{
+	Name: "expense",
+	Steps: []Step{
+		{Name: "amount", Prompt: "¿Cuánto gastaste?"},
+		{Name: "category", Prompt: "¿En qué categoría?", Options: []string{"Comida", "Transporte"}},
+	},
+}
+
+func TestHandleMessageRetriesConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		conflicts int
+		saves     int
+		wantErr   error
+	}{
+		{name: "retried", conflicts: 2, saves: 3},
+		{name: "gives up", conflicts: maxConflictAttempts, saves: maxConflictAttempts, wantErr: ErrConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := telegramtest.NewServer()
+			defer server.Close()
+			store := &conflictingStore{MemoryStore: NewMemoryStore()}
+			manager := NewManager(server.Client(), store)
+			manager.Register(testFlow)
+
+			ctx := context.Background()
+			update := server.InjectMessage(42, "/gasto")
+			if err := manager.StartReply(ctx, KeyOf(update.Message), update.Message.MessageID, "expense", nil); err != nil {
+				t.Fatalf("StartReply() error = %v", err)
+			}
+
+			store.conflicts, store.saves = tt.conflicts, 0
+			answer := server.InjectMessage(42, "150")
+			handled, _, err := manager.HandleMessage(ctx, answer.Message)
+			if !handled || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("HandleMessage() = %t, %v, want true, %v", handled, err, tt.wantErr)
+			}
+			if store.saves != tt.saves {
+				t.Errorf("saves = %d, want %d", store.saves, tt.saves)
+			}
+			// Only the first question and, once saved, the next one are sent
+			wantPrompts := 2
+			if tt.wantErr != nil {
+				wantPrompts = 1
+			}
+			if calls := server.CallsTo("sendMessage"); len(calls) != wantPrompts {
+				t.Errorf("sendMessage calls = %d, want %d", len(calls), wantPrompts)
+			}
+		})
+	}
+}
+
+func TestPromptIsSelectiveInGroups(t *testing.T) {
+	tests := []struct {
+		name      string
+		chatID    int64
+		selective bool
+	}{
+		{name: "private", chatID: telegramtest.User.ID, selective: false},
+		{name: "group", chatID: -4012345678, selective: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := telegramtest.NewServer()
+			defer server.Close()
+			manager := NewManager(server.Client(), NewMemoryStore())
+			manager.Register(testFlow)
+
+			ctx := context.Background()
+			update := server.InjectMessage(tt.chatID, "/gasto")
+			if err := manager.StartReply(ctx, KeyOf(update.Message), update.Message.MessageID, "expense", nil); err != nil {
+				t.Fatal(err)
+			}
+			answer := server.InjectMessage(tt.chatID, "150")
+			if _, _, err := manager.HandleMessage(ctx, answer.Message); err != nil {
+				t.Fatal(err)
+			}
+
+			calls := server.CallsTo("sendMessage")
+			if len(calls) != 2 {
+				t.Fatalf("sendMessage calls = %d, want 2", len(calls))
+			}
+			var forceReply, options struct {
+				ReplyParameters *telegram.ReplyParameters `json:"reply_parameters"`
+				ReplyMarkup     struct {
+					Selective bool `json:"selective"`
+				} `json:"reply_markup"`
+			}
+			if err := calls[0].Decode(&forceReply); err != nil {
+				t.Fatal(err)
+			}
+			if err := calls[1].Decode(&options); err != nil {
+				t.Fatal(err)
+			}
+			if forceReply.ReplyMarkup.Selective != tt.selective || options.ReplyMarkup.Selective != tt.selective {
+				t.Errorf("selective = %t and %t, want %t", forceReply.ReplyMarkup.Selective, options.ReplyMarkup.Selective, tt.selective)
+			}
+			if options.ReplyParameters == nil || int64(options.ReplyParameters.MessageID) != answer.Message.MessageID {
+				t.Errorf("reply_parameters = %+v, want a reply to the answer %d", options.ReplyParameters, answer.Message.MessageID)
+			}
+		})
+	}
+}
+
+func TestHandleMessageReturnsCompletedState(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	manager := NewManager(server.Client(), NewMemoryStore())
+	flow := testFlow
+	flow.OnComplete = func(ctx context.Context, state *State) (string, error) {
+		state.Data["expense_id"] = "20240320-1"
+		return "Gasto registrado", nil
+	}
+	manager.Register(flow)
+
+	ctx := context.Background()
+	update := server.InjectMessage(42, "/gasto")
+	if err := manager.Start(ctx, KeyOf(update.Message), "expense", nil); err != nil {
+		t.Fatal(err)
+	}
+	handled, completed, err := manager.HandleMessage(ctx, server.InjectMessage(42, "150").Message)
+	if !handled || completed != nil || err != nil {
+		t.Fatalf("HandleMessage() of the amount = %t, %+v, %v, want handled without completing", handled, completed, err)
+	}
+	handled, completed, err = manager.HandleMessage(ctx, server.InjectMessage(42, "Comida").Message)
+	if !handled || err != nil {
+		t.Fatalf("HandleMessage() of the category = %t, %v, want handled", handled, err)
+	}
+	if completed == nil || completed.Data["amount"] != "150" || completed.Data["category"] != "Comida" || completed.Data["expense_id"] != "20240320-1" {
+		t.Errorf("completed = %+v, want the answers and the data of OnComplete", completed)
+	}
+	if active, _ := manager.Active(ctx, KeyOf(update.Message)); active != nil {
+		t.Errorf("Active() after completing = %+v, want none", active)
+	}
+}
+
+func TestHandleMessageIgnoresReplays(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	manager := NewManager(server.Client(), NewMemoryStore())
+	manager.Register(Flow{
+		Name: "note",
+		Steps: []Step{
+			{Name: "amount", Prompt: "¿Cuánto gastaste?"},
+			{Name: "description", Prompt: "¿En qué?"},
+		},
+	})
+
+	ctx := context.Background()
+	key := Key{ChatID: 42, UserID: 42}
+	if err := manager.Start(ctx, key, "note", nil); err != nil {
+		t.Fatal(err)
+	}
+	// The answer is applied, but the next question fails and the update is retried
+	answer := server.InjectMessage(42, "150")
+	server.FailNext("sendMessage", telegramtest.BlockedByUser())
+	if handled, _, err := manager.HandleMessage(ctx, answer.Message); !handled || err == nil {
+		t.Fatalf("HandleMessage() = %t, %v, want the failed question", handled, err)
+	}
+	handled, completed, err := manager.HandleMessage(ctx, answer.Message)
+	if !handled || completed != nil || err != nil {
+		t.Fatalf("HandleMessage() of the retry = %t, %+v, %v, want it handled", handled, completed, err)
+	}
+
+	state, err := manager.Active(ctx, key)
+	if err != nil || state == nil || state.Step != "description" || state.Data["description"] != "" {
+		t.Fatalf("Active() = %+v, %v, want the description still unanswered", state, err)
+	}
+	calls := server.CallsTo("sendMessage")
+	var prompt struct {
+		Text string `json:"text"`
+	}
+	if err := calls[len(calls)-1].Decode(&prompt); err != nil || prompt.Text != "¿En qué?" {
+		t.Errorf("last message = %q, %v, want the question asked again", prompt.Text, err)
+	}
+
+	// A new message answers the step
+	if _, completed, err := manager.HandleMessage(ctx, server.InjectMessage(42, "tacos").Message); err != nil || completed == nil || completed.Data["description"] != "tacos" {
+		t.Errorf("HandleMessage() = %+v, %v, want the flow completed with the description", completed, err)
+	}
+}
+
+func TestCompleteKeepsStateWhenOnCompleteFails(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	manager := NewManager(server.Client(), NewMemoryStore())
+	failures := 1
+	flow := testFlow
+	flow.OnComplete = func(ctx context.Context, state *State) (string, error) {
+		if failures > 0 {
+			failures--
+			return "", errors.New("table unavailable")
+		}
+		return "Gasto registrado", nil
+	}
+	manager.Register(flow)
+
+	ctx := context.Background()
+	key := Key{ChatID: 42, UserID: 42}
+	if err := manager.Start(ctx, key, "expense", map[string]string{"amount": "150"}); err != nil {
+		t.Fatal(err)
+	}
+	answer := server.InjectMessage(42, "Comida")
+	if _, completed, err := manager.HandleMessage(ctx, answer.Message); err == nil || completed != nil {
+		t.Fatalf("HandleMessage() = %+v, %v, want the OnComplete error", completed, err)
+	}
+	if state, err := manager.Active(ctx, key); err != nil || state == nil || state.Step != "category" {
+		t.Fatalf("Active() after the failure = %+v, %v, want the conversation at the last step", state, err)
+	}
+
+	// The retried update completes the flow
+	_, completed, err := manager.HandleMessage(ctx, answer.Message)
+	if err != nil || completed == nil || completed.Data["category"] != "Comida" {
+		t.Fatalf("HandleMessage() of the retry = %+v, %v, want the flow completed", completed, err)
+	}
+	if state, _ := manager.Active(ctx, key); state != nil {
+		t.Errorf("Active() after completing = %+v, want none", state)
+	}
+}
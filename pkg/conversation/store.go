@@ -0,0 +1,74 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrConflict is returned by Store.Save when the state was changed since it
+// was loaded, e.g. by two messages handled at the same time.
+var ErrConflict = errors.New("conversation: state changed concurrently")
+
+// Store persists the state of the conversations.
+type Store interface {
+	// Load returns the state of key, or nil if there is none.
+	Load(ctx context.Context, key Key) (*State, error)
+	// Save stores state if its Version matches the stored one, and increments it.
+	Save(ctx context.Context, key Key, state *State) error
+	// Delete removes the state of key, if any.
+	Delete(ctx context.Context, key Key) error
+}
+
+// MemoryStore is a Store for tests and local runs, the state is lost on restart.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[Key]State
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[Key]State)}
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(ctx context.Context, key Key) (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[key]
+	if !ok {
+		return nil, nil
+	}
+	return copyState(state), nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(ctx context.Context, key Key, state *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.states[key].Version != state.Version {
+		return ErrConflict
+	}
+	state.Version++
+	s.states[key] = *copyState(*state)
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(ctx context.Context, key Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
+
+// copyState copies state without sharing its map and slice.
+func copyState(state State) *State {
+	data := make(map[string]string, len(state.Data))
+	for name, value := range state.Data {
+		data[name] = value
+	}
+	state.Data = data
+	state.History = append([]string(nil), state.History...)
+	return &state
+}
@@ -0,0 +1,126 @@
+package conversation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by DynamoDBStore.
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+// DynamoDBStore is a Store over a DynamoDB table with a string partition key
+// "pk" and TTL on "expires_at", so abandoned conversations are removed.
+//
+// Each conversation is one item with the State as JSON in "state", saved with
+// optimistic locking on "version".
+type DynamoDBStore struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+// NewDynamoDBStore creates a DynamoDBStore over tableName.
+func NewDynamoDBStore(client DynamoDBAPI, tableName string) *DynamoDBStore {
+	return &DynamoDBStore{client: client, tableName: tableName}
+}
+
+// Load implements Store.
+func (s *DynamoDBStore) Load(ctx context.Context, key Key) (*State, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            itemKey(key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading conversation %s: %w", key, err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	encoded, ok := out.Item["state"].(*types.AttributeValueMemberS)
+	if !ok {
+		return nil, fmt.Errorf("loading conversation %s: state attribute is missing", key)
+	}
+	var state State
+	if err := json.Unmarshal([]byte(encoded.Value), &state); err != nil {
+		return nil, fmt.Errorf("loading conversation %s: %w", key, err)
+	}
+	version, ok := out.Item["version"].(*types.AttributeValueMemberN)
+	if !ok {
+		return nil, fmt.Errorf("loading conversation %s: version attribute is missing", key)
+	}
+	if state.Version, err = strconv.ParseInt(version.Value, 10, 64); err != nil {
+		return nil, fmt.Errorf("loading conversation %s: %w", key, err)
+	}
+	return &state, nil
+}
+
+// Save implements Store.
+func (s *DynamoDBStore) Save(ctx context.Context, key Key, state *State) error {
+	version := state.Version
+	state.Version = version + 1
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		state.Version = version
+		return fmt.Errorf("saving conversation %s: %w", key, err)
+	}
+
+	condition := "attribute_not_exists(pk)"
+	var values map[string]types.AttributeValue
+	if version > 0 {
+		condition = "version = :version"
+		values = map[string]types.AttributeValue{":version": numberValue(version)}
+	}
+	item := itemKey(key)
+	item["state"] = &types.AttributeValueMemberS{Value: string(encoded)}
+	item["version"] = numberValue(version + 1)
+	// Keep the item a while after the deadline to tell the user it expired
+	item["expires_at"] = numberValue(state.ExpiresAt.Add(time.Hour).Unix())
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(s.tableName),
+		Item:                      item,
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		state.Version = version
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return ErrConflict
+		}
+		return fmt.Errorf("saving conversation %s: %w", key, err)
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (s *DynamoDBStore) Delete(ctx context.Context, key Key) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.tableName),
+		Key:       itemKey(key),
+	})
+	if err != nil {
+		return fmt.Errorf("deleting conversation %s: %w", key, err)
+	}
+	return nil
+}
+
+func itemKey(key Key) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: key.String()}}
+}
+
+func numberValue(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
@@ -0,0 +1,76 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testStore runs the Store contract against store.
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	key := Key{ChatID: -100123, UserID: 42}
+
+	state, err := store.Load(ctx, key)
+	if err != nil || state != nil {
+		t.Fatalf("Load() of a new key = %+v, %v, want nil", state, err)
+	}
+
+	state = &State{
+		Key:       key,
+		Flow:      "expense",
+		Step:      "amount",
+		Data:      map[string]string{"payer": "Ana"},
+		ExpiresAt: time.Now().Add(time.Minute).Truncate(time.Second),
+	}
+	if err := store.Save(ctx, key, state); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if state.Version != 1 {
+		t.Errorf("Version after Save() = %d, want 1", state.Version)
+	}
+
+	// Changing the saved state does not change the stored one
+	state.Data["payer"] = "Luis"
+	loaded, err := store.Load(ctx, key)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Data["payer"] != "Ana" || loaded.Step != "amount" || loaded.Version != 1 || !loaded.ExpiresAt.Equal(state.ExpiresAt) {
+		t.Errorf("Load() = %+v, want the saved state", loaded)
+	}
+
+	// A state loaded before another save is stale
+	stale := *loaded
+	loaded.Step = "category"
+	if err := store.Save(ctx, key, loaded); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	stale.Step = "notes"
+	if err := store.Save(ctx, key, &stale); !errors.Is(err, ErrConflict) {
+		t.Errorf("Save() of a stale state error = %v, want ErrConflict", err)
+	}
+	if current, _ := store.Load(ctx, key); current.Step != "category" || current.Version != 2 {
+		t.Errorf("Load() after the conflict = %+v, want step category at version 2", current)
+	}
+
+	// Version 0 only saves a new conversation
+	if err := store.Save(ctx, key, &State{Key: key, Flow: "expense", Step: "amount"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Save() of a new state over an existing one error = %v, want ErrConflict", err)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if state, err := store.Load(ctx, key); err != nil || state != nil {
+		t.Errorf("Load() after Delete() = %+v, %v, want nil", state, err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of a missing key error = %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
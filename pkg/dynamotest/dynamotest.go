@@ -0,0 +1,107 @@
+// Package dynamotest runs tests against DynamoDB Local.
+//
+// The tests are skipped unless DYNAMODB_LOCAL_ENDPOINT points to a DynamoDB
+// Local instance, e.g.:
+//
+//	docker run -p 8000:8000 amazon/dynamodb-local
+//	DYNAMODB_LOCAL_ENDPOINT=http://localhost:8000 go test ./...
+//
+// Each test gets its own table, deleted when the test ends.
+//
+//	client := dynamotest.Client(t)
+//	table := dynamotest.NewTable(t, client, &dynamodb.CreateTableInput{...})
+package dynamotest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// EndpointEnv is the environment variable with the DynamoDB Local endpoint.
+const EndpointEnv = "DYNAMODB_LOCAL_ENDPOINT"
+
+// tables numbers the tables created by the test process.
+var tables atomic.Int64
+
+// Client returns a DynamoDB client for DynamoDB Local, or skips the test if
+// EndpointEnv is not set.
+func Client(t testing.TB) *dynamodb.Client {
+	t.Helper()
+	endpoint := os.Getenv(EndpointEnv)
+	if endpoint == "" {
+		t.Skipf("%s is not set, skipping the DynamoDB Local test", EndpointEnv)
+	}
+	cfg := aws.Config{
+		Region: "us-east-1",
+		// DynamoDB Local accepts any credentials
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "local", SecretAccessKey: "local"}, nil
+		}),
+	}
+	return dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		o.BaseEndpoint = aws.String(endpoint)
+	})
+}
+
+// NewTable creates the table described by input with a unique name, waits
+// until it is active and deletes it when the test ends. It returns the name.
+// BillingMode defaults to PAY_PER_REQUEST.
+func NewTable(t testing.TB, client *dynamodb.Client, input *dynamodb.CreateTableInput) string {
+	t.Helper()
+	ctx := context.Background()
+	name := fmt.Sprintf("%s-%d-%d", tableName(t.Name()), time.Now().UnixNano(), tables.Add(1))
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+
+	table := *input
+	table.TableName = aws.String(name)
+	if table.BillingMode == "" {
+		table.BillingMode = types.BillingModePayPerRequest
+	}
+	if _, err := client.CreateTable(ctx, &table); err != nil {
+		t.Fatalf("creating table %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		if _, err := client.DeleteTable(context.Background(), &dynamodb.DeleteTableInput{TableName: aws.String(name)}); err != nil {
+			t.Errorf("deleting table %s: %v", name, err)
+		}
+	})
+
+	waiter := dynamodb.NewTableExistsWaiter(client)
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(name)}, time.Minute); err != nil {
+		t.Fatalf("waiting for table %s: %v", name, err)
+	}
+	return name
+}
+
+// tableName replaces the characters of a test name not allowed in table names.
+func tableName(testName string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, testName)
+}
+
+// StringKeyTable describes a table with a string partition key named pk.
+func StringKeyTable(pk string) *dynamodb.CreateTableInput {
+	return &dynamodb.CreateTableInput{
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String(pk), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(pk), KeyType: types.KeyTypeHash},
+		},
+	}
+}
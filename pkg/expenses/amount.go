@@ -0,0 +1,132 @@
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidAmount is returned when a text is not a positive amount.
+var ErrInvalidAmount = errors.New("expenses: invalid amount")
+
+// currencyExponents lists the currencies whose minor unit is not 1/100.
+var currencyExponents = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"CLP": 0,
+	"BHD": 3,
+	"KWD": 3,
+}
+
+// currencySymbols maps the symbols accepted before an amount to their currency.
+// "$" is ambiguous and resolves to the default currency of the parser.
+var currencySymbols = map[string]string{
+	"€": "EUR",
+	"£": "GBP",
+	"¥": "JPY",
+}
+
+// Exponent returns the number of decimals of the minor unit of currency.
+func Exponent(currency string) int {
+	if exponent, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// ParseAmount parses a positive amount in minor units of currency. Both
+// decimal locales are accepted: "1,200.50" and "1.200,50" are 120050 MXN.
+//
+// When there is a single separator it is a thousands separator if exactly
+// three digits follow it ("1,200" and "1.200" are 1200), otherwise it is the
+// decimal separator ("23,5" and "23.50" are 23.50).
+func ParseAmount(text, currency string) (int64, error) {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "$")
+	for symbol := range currencySymbols {
+		text = strings.TrimPrefix(text, symbol)
+	}
+	if text == "" {
+		return 0, ErrInvalidAmount
+	}
+	for _, r := range text {
+		if !unicode.IsDigit(r) && r != ',' && r != '.' {
+			return 0, ErrInvalidAmount
+		}
+	}
+
+	integer, fraction := splitDecimal(text)
+	integer = strings.NewReplacer(",", "", ".", "").Replace(integer)
+	if integer == "" {
+		integer = "0"
+	}
+	exponent := Exponent(currency)
+	if len(fraction) > exponent {
+		return 0, fmt.Errorf("%w: %s has more than %d decimals", ErrInvalidAmount, text, exponent)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	amount, err := strconv.ParseInt(integer+fraction, 10, 64)
+	if err != nil || amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	return amount, nil
+}
+
+// splitDecimal splits an amount in its integer and fractional digits, guessing
+// which separator is the decimal one.
+func splitDecimal(text string) (string, string) {
+	lastComma, lastDot := strings.LastIndex(text, ","), strings.LastIndex(text, ".")
+	switch {
+	case lastComma >= 0 && lastDot >= 0:
+		// Both separators: the last one is the decimal separator
+		last := max(lastComma, lastDot)
+		return text[:last], text[last+1:]
+	case lastComma < 0 && lastDot < 0:
+		return text, ""
+	}
+	sep := ","
+	if lastDot >= 0 {
+		sep = "."
+	}
+	last := strings.LastIndex(text, sep)
+	if strings.Count(text, sep) > 1 || len(text)-last-1 == 3 {
+		return text, ""
+	}
+	return text[:last], text[last+1:]
+}
+
+// FormatAmount formats an amount in minor units of currency, e.g. "$1,200.50 MXN".
+func FormatAmount(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	exponent := Exponent(currency)
+	digits := strconv.FormatInt(amount, 10)
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	integer, fraction := digits[:len(digits)-exponent], digits[len(digits)-exponent:]
+
+	var grouped strings.Builder
+	for i, r := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(r)
+	}
+	if fraction != "" {
+		grouped.WriteString("." + fraction)
+	}
+
+	symbol := "$"
+	for s, c := range currencySymbols {
+		if c == currency {
+			symbol = s
+		}
+	}
+	return sign + symbol + grouped.String() + " " + currency
+}
@@ -0,0 +1,94 @@
+package expenses
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		text     string
+		currency string
+		want     int64
+	}{
+		{"23.50", "MXN", 2350},
+		{"23,50", "MXN", 2350},
+		{"23,5", "MXN", 2350},
+		{"23.5", "MXN", 2350},
+		{"100", "MXN", 10000},
+		{"1,200", "MXN", 120000},
+		{"1.200", "MXN", 120000},
+		{"1,200.50", "MXN", 120050},
+		{"1.200,50", "MXN", 120050},
+		{"1.234.567", "MXN", 123456700},
+		{"1,234,567.8", "MXN", 123456780},
+		{"$1,200", "MXN", 120000},
+		{" 0,99 ", "MXN", 99},
+		{".5", "USD", 50},
+		{"€12,5", "EUR", 1250},
+		{"1500", "JPY", 1500},
+		{"1,500", "jpy", 1500},
+		{"1,5", "KWD", 1500},
+		{"2.125", "KWD", 2125000},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.text, tt.currency)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAmount(%q, %s) = %d, %v, want %d", tt.text, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseAmountInvalid(t *testing.T) {
+	tests := []struct {
+		text     string
+		currency string
+	}{
+		{"", "MXN"},
+		{"$", "MXN"},
+		{"0", "MXN"},
+		{"0,00", "MXN"},
+		{"-5", "MXN"},
+		{"12a", "MXN"},
+		{"diez", "MXN"},
+		{"1.5", "JPY"},
+		{"99999999999999999999", "MXN"},
+	}
+	for _, tt := range tests {
+		if got, err := ParseAmount(tt.text, tt.currency); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("ParseAmount(%q, %s) = %d, %v, want ErrInvalidAmount", tt.text, tt.currency, got, err)
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{120050, "MXN", "$1,200.50 MXN"},
+		{2350, "USD", "$23.50 USD"},
+		{5, "MXN", "$0.05 MXN"},
+		{0, "MXN", "$0.00 MXN"},
+		{-2350, "MXN", "-$23.50 MXN"},
+		{123456700, "MXN", "$1,234,567.00 MXN"},
+		{1250, "EUR", "€12.50 EUR"},
+		{1500, "JPY", "¥1,500 JPY"},
+		{2125000, "KWD", "$2,125.000 KWD"},
+	}
+	for _, tt := range tests {
+		got := FormatAmount(tt.amount, tt.currency)
+		if got != tt.want {
+			t.Errorf("FormatAmount(%d, %s) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+		// The formatted amount is parsed back to the same value
+		if tt.amount > 0 {
+			number, _, _ := strings.Cut(got, " ")
+			if parsed, err := ParseAmount(number, tt.currency); err != nil || parsed != tt.amount {
+				t.Errorf("ParseAmount(%q, %s) = %d, %v, want %d", number, tt.currency, parsed, err, tt.amount)
+			}
+		}
+	}
+}
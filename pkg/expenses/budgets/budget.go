@@ -0,0 +1,232 @@
+// Package budgets keeps monthly and weekly spending limits per category and
+// tells when the expenses of a period cross a threshold of the limit, once
+// per threshold and period.
+//
+//	plan, err := store.Load(ctx, userID)
+//	for _, budget := range plan.Of(expense.Category) {
+//		spent, err := budgets.Spent(ctx, repository, userID, *budget, expense.Date)
+//		if threshold, ok := budget.Cross(spent, expense.Date); ok {
+//			// Warn the user, then save the plan to remember the alert
+//		}
+//	}
+package budgets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+)
+
+// Period is how often a budget starts over.
+type Period string
+
+// Periods of the budgets. The weeks start on Monday, and both start at
+// midnight in the location of the dates given to them.
+const (
+	Monthly Period = "monthly"
+	Weekly  Period = "weekly"
+)
+
+// periodWords are the names of the periods the users can write.
+var periodWords = map[string]Period{
+	"monthly": Monthly, "month": Monthly, "mensual": Monthly, "mes": Monthly,
+	"weekly": Weekly, "week": Weekly, "semanal": Weekly, "semana": Weekly,
+}
+
+// ParsePeriod parses the name of a period in Spanish or English, e.g. "mensual" or "weekly".
+func ParsePeriod(text string) (Period, bool) {
+	period, ok := periodWords[strings.ToLower(strings.TrimSpace(text))]
+	return period, ok
+}
+
+// Name returns the period in Spanish, e.g. "mensual".
+func (p Period) Name() string {
+	if p == Weekly {
+		return "semanal"
+	}
+	return "mensual"
+}
+
+// Bounds returns the first instant of the period containing t and the first
+// instant of the next one.
+func (p Period) Bounds(t time.Time) (from, to time.Time) {
+	year, month, day := t.Date()
+	if p == Weekly {
+		// time.Weekday starts on Sunday
+		offset := (int(t.Weekday()) + 6) % 7
+		from = time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+		return from, time.Date(year, month, day-offset+7, 0, 0, 0, 0, t.Location())
+	}
+	from = time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	return from, from.AddDate(0, 1, 0)
+}
+
+// Key identifies the period containing t, e.g. "2026-10" or "2026-W42".
+func (p Period) Key(t time.Time) string {
+	if p == Weekly {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return t.Format("2006-01")
+}
+
+// MaxBudgets is the maximum number of budgets of a plan, to keep it in a single DynamoDB item.
+const MaxBudgets = 50
+
+// DefaultThresholds are the percentages of the limit that alert by default.
+var DefaultThresholds = []int{50, 80, 100}
+
+var (
+	// ErrInvalidBudget is returned by Plan.Set when the budget has no
+	// category, an unknown period, a limit that is not positive or
+	// thresholds out of 1-1000.
+	ErrInvalidBudget = errors.New("budgets: invalid budget")
+	// ErrTooMany is returned when a plan would exceed MaxBudgets.
+	ErrTooMany = errors.New("budgets: too many budgets")
+)
+
+// Budget is the limit of the expenses of a category in a period.
+type Budget struct {
+	Category string `json:"category"`
+	Period   Period `json:"period"`
+	// Limit is in minor units of Currency, only the expenses in Currency count.
+	Limit    int64  `json:"limit"`
+	Currency string `json:"currency"`
+	// Thresholds are the percentages of Limit that alert, in ascending order.
+	Thresholds []int `json:"thresholds"`
+	// AlertedPeriod is the Period.Key of the last alert and Alerted its threshold.
+	AlertedPeriod string `json:"alerted_period,omitempty"`
+	Alerted       int    `json:"alerted,omitempty"`
+}
+
+// Percent returns spent as a percentage of the limit, rounded down.
+func (b *Budget) Percent(spent int64) int {
+	return int(spent * 100 / b.Limit)
+}
+
+// Cross returns the highest threshold crossed by spent in the period
+// containing date that was not alerted yet, and records it as alerted. The
+// alerts start over in each period.
+func (b *Budget) Cross(spent int64, date time.Time) (int, bool) {
+	key := b.Period.Key(date)
+	alerted := b.Alerted
+	if b.AlertedPeriod != key {
+		alerted = 0
+	}
+	crossed := 0
+	for _, threshold := range b.Thresholds {
+		if threshold > alerted && spent*100 >= b.Limit*int64(threshold) {
+			crossed = threshold
+		}
+	}
+	if crossed == 0 {
+		return 0, false
+	}
+	b.AlertedPeriod, b.Alerted = key, crossed
+	return crossed, true
+}
+
+// Spent returns the total of the confirmed expenses of the category of budget
+// in its currency, in the period containing date.
+//
+// The expenses of a category are listed from an index that is updated
+// eventually, recorded is the expense just stored, or nil. It counts with its
+// current amount even when the index does not have it or has an older version.
+func Spent(ctx context.Context, repository expenses.Repository, userID int64, budget Budget, date time.Time, recorded *expenses.Expense) (int64, error) {
+	from, to := budget.Period.Bounds(date)
+	query := expenses.Query{From: from, To: to, Category: budget.Category}
+	list, err := repository.List(ctx, userID, query)
+	if err != nil {
+		return 0, err
+	}
+	if recorded != nil {
+		list = slices.DeleteFunc(list, func(expense *expenses.Expense) bool { return expense.ID == recorded.ID })
+		if recorded.UserID == userID && query.Matches(recorded) {
+			list = append(list, recorded)
+		}
+	}
+	var spent int64
+	for _, expense := range list {
+		if !expense.IsDraft() && strings.EqualFold(expense.Currency, budget.Currency) {
+			spent += expense.Amount
+		}
+	}
+	return spent, nil
+}
+
+// Plan holds the budgets of a user.
+type Plan struct {
+	UserID  int64    `json:"user_id"`
+	Budgets []Budget `json:"budgets,omitempty"`
+	// Version is used by the stores for optimistic locking, do not change it.
+	Version int64 `json:"version"`
+}
+
+// Of returns the budgets of category, ignoring the case.
+func (p *Plan) Of(category string) []*Budget {
+	var selected []*Budget
+	for i := range p.Budgets {
+		if strings.EqualFold(p.Budgets[i].Category, category) {
+			selected = append(selected, &p.Budgets[i])
+		}
+	}
+	return selected
+}
+
+// Set adds budget, or replaces the one of its category and period. The
+// alerts start over, and the thresholds default to DefaultThresholds.
+func (p *Plan) Set(budget Budget) error {
+	if len(budget.Thresholds) == 0 {
+		budget.Thresholds = slices.Clone(DefaultThresholds)
+	}
+	slices.Sort(budget.Thresholds)
+	budget.Thresholds = slices.Compact(budget.Thresholds)
+	switch {
+	case budget.Category == "", budget.Period != Monthly && budget.Period != Weekly, budget.Limit <= 0:
+		return fmt.Errorf("%w: %+v", ErrInvalidBudget, budget)
+	case budget.Thresholds[0] < 1, budget.Thresholds[len(budget.Thresholds)-1] > 1000:
+		return fmt.Errorf("%w: thresholds %v", ErrInvalidBudget, budget.Thresholds)
+	}
+	budget.AlertedPeriod, budget.Alerted = "", 0
+
+	index := slices.IndexFunc(p.Budgets, func(b Budget) bool {
+		return strings.EqualFold(b.Category, budget.Category) && b.Period == budget.Period
+	})
+	if index >= 0 {
+		p.Budgets[index] = budget
+		return nil
+	}
+	if len(p.Budgets) >= MaxBudgets {
+		return ErrTooMany
+	}
+	p.Budgets = append(p.Budgets, budget)
+	return nil
+}
+
+// Clear removes the budgets of category in period, or in every period when
+// period is empty, and returns how many were removed.
+func (p *Plan) Clear(category string, period Period) int {
+	before := len(p.Budgets)
+	p.Budgets = slices.DeleteFunc(p.Budgets, func(b Budget) bool {
+		return strings.EqualFold(b.Category, category) && (period == "" || b.Period == period)
+	})
+	return before - len(p.Budgets)
+}
+
+// Rename moves the budgets of the category old to name, e.g. when the
+// category is renamed, and returns how many were moved.
+func (p *Plan) Rename(old, name string) int {
+	renamed := 0
+	for i := range p.Budgets {
+		if strings.EqualFold(p.Budgets[i].Category, old) {
+			p.Budgets[i].Category = name
+			renamed++
+		}
+	}
+	return renamed
+}
@@ -0,0 +1,213 @@
+package budgets
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+)
+
+func TestPeriodBounds(t *testing.T) {
+	mexico, err := time.LoadLocation("America/Mexico_City")
+	if err != nil {
+		t.Skip(err)
+	}
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		period   Period
+		t        time.Time
+		from, to time.Time
+		key      string
+	}{
+		{"month", Monthly, time.Date(2024, time.March, 20, 15, 0, 0, 0, time.UTC), day(2024, time.March, 1), day(2024, time.April, 1), "2024-03"},
+		{"last instant of the month", Monthly, time.Date(2024, time.February, 29, 23, 59, 59, 0, time.UTC), day(2024, time.February, 1), day(2024, time.March, 1), "2024-02"},
+		{"december", Monthly, day(2024, time.December, 31), day(2024, time.December, 1), day(2025, time.January, 1), "2024-12"},
+		{"week from wednesday", Weekly, day(2024, time.March, 20), day(2024, time.March, 18), day(2024, time.March, 25), "2024-W12"},
+		{"week from monday", Weekly, day(2024, time.March, 18), day(2024, time.March, 18), day(2024, time.March, 25), "2024-W12"},
+		{"week from sunday", Weekly, time.Date(2024, time.March, 24, 23, 0, 0, 0, time.UTC), day(2024, time.March, 18), day(2024, time.March, 25), "2024-W12"},
+		{"week across months", Weekly, day(2024, time.May, 1), day(2024, time.April, 29), day(2024, time.May, 6), "2024-W18"},
+		// The ISO year of the week is not the year of the date
+		{"week 1 in december", Weekly, day(2024, time.December, 31), day(2024, time.December, 30), day(2025, time.January, 6), "2025-W01"},
+		{"week 53 in january", Weekly, day(2021, time.January, 3), day(2020, time.December, 28), day(2021, time.January, 4), "2020-W53"},
+		{"week 52 in january", Weekly, day(2023, time.January, 1), day(2022, time.December, 26), day(2023, time.January, 2), "2022-W52"},
+		// In the location of the date, 01:00 UTC is still the last day of the month
+		{"month in mexico", Monthly, time.Date(2024, time.April, 1, 1, 0, 0, 0, time.UTC).In(mexico), time.Date(2024, time.March, 1, 0, 0, 0, 0, mexico), time.Date(2024, time.April, 1, 0, 0, 0, 0, mexico), "2024-03"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := tt.period.Bounds(tt.t)
+			if !from.Equal(tt.from) || !to.Equal(tt.to) {
+				t.Errorf("Bounds(%s) = %s, %s, want %s, %s", tt.t, from, to, tt.from, tt.to)
+			}
+			if got := tt.period.Key(tt.t); got != tt.key {
+				t.Errorf("Key(%s) = %q, want %q", tt.t, got, tt.key)
+			}
+			if got := tt.period.Key(from); got != tt.key {
+				t.Errorf("Key(%s) = %q, want %q", from, got, tt.key)
+			}
+		})
+	}
+}
+
+func TestBudgetCross(t *testing.T) {
+	budget := Budget{Category: "comida", Period: Monthly, Limit: 1000, Currency: "MXN", Thresholds: []int{50, 80, 100}}
+	march := time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2024, time.April, 2, 0, 0, 0, 0, time.UTC)
+	steps := []struct {
+		spent     int64
+		date      time.Time
+		threshold int
+		ok        bool
+	}{
+		{400, march, 0, false},
+		{500, march, 50, true},
+		// Each threshold alerts once per period
+		{600, march, 0, false},
+		// Crossing several thresholds at once alerts the highest one
+		{1200, march, 100, true},
+		{1500, march, 0, false},
+		// The alerts start over in the next period
+		{300, april, 0, false},
+		{850, april, 80, true},
+		{900, april, 0, false},
+	}
+	for i, step := range steps {
+		threshold, ok := budget.Cross(step.spent, step.date)
+		if threshold != step.threshold || ok != step.ok {
+			t.Errorf("step %d: Cross(%d, %s) = %d, %t, want %d, %t", i, step.spent, step.date.Format(time.DateOnly), threshold, ok, step.threshold, step.ok)
+		}
+	}
+	if budget.AlertedPeriod != "2024-04" || budget.Alerted != 80 {
+		t.Errorf("alerted = %q %d, want 2024-04 80", budget.AlertedPeriod, budget.Alerted)
+	}
+}
+
+func TestPlanSet(t *testing.T) {
+	valid := Budget{Category: "comida", Period: Monthly, Limit: 300000, Currency: "MXN"}
+	tests := []struct {
+		name   string
+		change func(b *Budget)
+		want   error
+	}{
+		{"valid", func(b *Budget) {}, nil},
+		{"no category", func(b *Budget) { b.Category = "" }, ErrInvalidBudget},
+		{"unknown period", func(b *Budget) { b.Period = "daily" }, ErrInvalidBudget},
+		{"zero limit", func(b *Budget) { b.Limit = 0 }, ErrInvalidBudget},
+		{"negative limit", func(b *Budget) { b.Limit = -5 }, ErrInvalidBudget},
+		{"zero threshold", func(b *Budget) { b.Thresholds = []int{0, 50} }, ErrInvalidBudget},
+		{"threshold over 1000", func(b *Budget) { b.Thresholds = []int{50, 1001} }, ErrInvalidBudget},
+		{"threshold of 1000", func(b *Budget) { b.Thresholds = []int{1000} }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := valid
+			tt.change(&budget)
+			var plan Plan
+			err := plan.Set(budget)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Set() error = %v, want %v", err, tt.want)
+			}
+			if err != nil && len(plan.Budgets) != 0 {
+				t.Errorf("budgets = %+v, want none", plan.Budgets)
+			}
+		})
+	}
+}
+
+func TestPlanSetReplaces(t *testing.T) {
+	var plan Plan
+	if err := plan.Set(Budget{Category: "Comida", Period: Monthly, Limit: 1000, Currency: "MXN", Thresholds: []int{100, 50, 100}}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got := plan.Budgets[0].Thresholds; len(got) != 2 || got[0] != 50 || got[1] != 100 {
+		t.Errorf("thresholds = %v, want [50 100]", got)
+	}
+	plan.Budgets[0].AlertedPeriod, plan.Budgets[0].Alerted = "2024-03", 50
+
+	// The same category and period replaces the budget and its alerts
+	if err := plan.Set(Budget{Category: "comida", Period: Monthly, Limit: 2000, Currency: "MXN"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := plan.Set(Budget{Category: "comida", Period: Weekly, Limit: 500, Currency: "MXN"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if len(plan.Budgets) != 2 {
+		t.Fatalf("budgets = %+v, want 2", plan.Budgets)
+	}
+	monthly := plan.Budgets[0]
+	if monthly.Limit != 2000 || monthly.AlertedPeriod != "" || monthly.Alerted != 0 || len(monthly.Thresholds) != len(DefaultThresholds) {
+		t.Errorf("replaced budget = %+v", monthly)
+	}
+	if got := plan.Of("COMIDA"); len(got) != 2 {
+		t.Errorf("Of(COMIDA) = %d budgets, want 2", len(got))
+	}
+
+	for i := len(plan.Budgets); i < MaxBudgets; i++ {
+		if err := plan.Set(Budget{Category: string(rune('a' + i)), Period: Monthly, Limit: 1, Currency: "MXN"}); err != nil {
+			t.Fatalf("Set(%d) error = %v", i, err)
+		}
+	}
+	if err := plan.Set(Budget{Category: "one more", Period: Monthly, Limit: 1, Currency: "MXN"}); !errors.Is(err, ErrTooMany) {
+		t.Errorf("Set() over MaxBudgets error = %v, want ErrTooMany", err)
+	}
+	// Replacing is allowed in a full plan
+	if err := plan.Set(Budget{Category: "comida", Period: Monthly, Limit: 3000, Currency: "MXN"}); err != nil {
+		t.Errorf("Set() replacing in a full plan error = %v", err)
+	}
+}
+
+// staleRepository lists the expenses of an index that has not seen the last update.
+type staleRepository struct {
+	expenses.Repository
+	list []*expenses.Expense
+}
+
+func (r *staleRepository) List(ctx context.Context, userID int64, query expenses.Query) ([]*expenses.Expense, error) {
+	return slices.Clone(r.list), nil
+}
+
+func TestSpent(t *testing.T) {
+	date := time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC)
+	expense := func(id string, amount int64, change func(e *expenses.Expense)) *expenses.Expense {
+		e := &expenses.Expense{ID: id, UserID: 1, Amount: amount, Currency: "MXN", Category: "comida", Date: date}
+		if change != nil {
+			change(e)
+		}
+		return e
+	}
+	budget := Budget{Category: "comida", Period: Monthly, Limit: 1000, Currency: "MXN"}
+	repository := &staleRepository{list: []*expenses.Expense{
+		expense("a", 100, nil),
+		// An older version of the recorded expense
+		expense("b", 200, nil),
+		expense("usd", 300, func(e *expenses.Expense) { e.Currency = "USD" }),
+		expense("draft", 400, func(e *expenses.Expense) { e.ExpiresAt = date.Add(time.Hour) }),
+	}}
+	tests := []struct {
+		name     string
+		recorded *expenses.Expense
+		want     int64
+	}{
+		{"without recorded", nil, 300},
+		{"updated amount", expense("b", 250, nil), 350},
+		{"missing from the index", expense("c", 50, nil), 350},
+		{"moved to another category", expense("b", 250, func(e *expenses.Expense) { e.Category = "casa" }), 100},
+		{"moved to another month", expense("b", 250, func(e *expenses.Expense) { e.Date = date.AddDate(0, 1, 0) }), 100},
+		{"of another user", expense("c", 50, func(e *expenses.Expense) { e.UserID = 2 }), 300},
+		{"in another currency", expense("c", 50, func(e *expenses.Expense) { e.Currency = "USD" }), 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Spent(context.Background(), repository, 1, budget, date, tt.recorded)
+			if err != nil || got != tt.want {
+				t.Errorf("Spent() = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package budgets
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrConflict is returned by Store.Save when the plan was changed since it was loaded.
+var ErrConflict = errors.New("budgets: plan changed concurrently")
+
+// Store persists the plans of the users.
+type Store interface {
+	// Load returns the plan of userID, or an empty one if it has none.
+	Load(ctx context.Context, userID int64) (*Plan, error)
+	// Save stores plan if its Version matches the stored one, and increments it.
+	Save(ctx context.Context, plan *Plan) error
+}
+
+// MemoryStore is a Store for tests and local runs, the plans are lost on restart.
+type MemoryStore struct {
+	mu    sync.Mutex
+	plans map[int64]Plan
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{plans: make(map[int64]Plan)}
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(ctx context.Context, userID int64) (*Plan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plan, ok := s.plans[userID]
+	if !ok {
+		return &Plan{UserID: userID}, nil
+	}
+	return copyPlan(plan), nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(ctx context.Context, plan *Plan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.plans[plan.UserID].Version != plan.Version {
+		return ErrConflict
+	}
+	plan.Version++
+	s.plans[plan.UserID] = *copyPlan(*plan)
+	return nil
+}
+
+// Update loads the plan of userID, changes it with update and saves it,
+// loading it again when it was changed concurrently.
+func Update(ctx context.Context, store Store, userID int64, update func(plan *Plan) error) (*Plan, error) {
+	for attempt := 0; ; attempt++ {
+		plan, err := store.Load(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := update(plan); err != nil {
+			return nil, err
+		}
+		err = store.Save(ctx, plan)
+		if !errors.Is(err, ErrConflict) || attempt == 2 {
+			return plan, err
+		}
+	}
+}
+
+// copyPlan copies plan without sharing its budgets.
+func copyPlan(plan Plan) *Plan {
+	plan.Budgets = slices.Clone(plan.Budgets)
+	for i := range plan.Budgets {
+		plan.Budgets[i].Thresholds = slices.Clone(plan.Budgets[i].Thresholds)
+	}
+	return &plan
+}
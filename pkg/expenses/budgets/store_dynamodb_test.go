@@ -0,0 +1,13 @@
+package budgets
+
+import (
+	"testing"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/dynamotest"
+)
+
+func TestDynamoDBStore(t *testing.T) {
+	client := dynamotest.Client(t)
+	table := dynamotest.NewTable(t, client, dynamotest.StringKeyTable("pk"))
+	testStore(t, NewDynamoDBStore(client, table))
+}
@@ -0,0 +1,82 @@
+package budgets
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// testStore runs the Store contract against store.
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	plan, err := store.Load(ctx, 42)
+	if err != nil || plan.UserID != 42 || len(plan.Budgets) != 0 || plan.Version != 0 {
+		t.Fatalf("Load() of a new user = %+v, %v, want an empty plan", plan, err)
+	}
+	if err := plan.Set(Budget{Category: "comida", Period: Monthly, Limit: 300000, Currency: "MXN"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Save(ctx, plan); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if plan.Version != 1 {
+		t.Errorf("Version after Save() = %d, want 1", plan.Version)
+	}
+
+	// Changing the saved plan does not change the stored one
+	plan.Budgets[0].Thresholds[0] = 10
+	loaded, err := store.Load(ctx, 42)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded.Budgets) != 1 || loaded.Budgets[0].Limit != 300000 || loaded.Budgets[0].Thresholds[0] != 50 || loaded.Version != 1 {
+		t.Errorf("Load() = %+v, want the saved plan", loaded)
+	}
+
+	// A plan loaded before another save is stale
+	stale := copyPlan(*loaded)
+	loaded.Budgets[0].AlertedPeriod, loaded.Budgets[0].Alerted = "2024-03", 50
+	if err := store.Save(ctx, loaded); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	stale.Clear("comida", "")
+	if err := store.Save(ctx, stale); !errors.Is(err, ErrConflict) {
+		t.Errorf("Save() of a stale plan error = %v, want ErrConflict", err)
+	}
+	if stale.Version != 1 {
+		t.Errorf("Version after a conflict = %d, want 1", stale.Version)
+	}
+	if current, _ := store.Load(ctx, 42); len(current.Budgets) != 1 || current.Budgets[0].Alerted != 50 || current.Version != 2 {
+		t.Errorf("Load() after the conflict = %+v, want the alert at version 2", current)
+	}
+
+	// Version 0 only saves a new plan
+	if err := store.Save(ctx, &Plan{UserID: 42}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Save() of a new plan over an existing one error = %v, want ErrConflict", err)
+	}
+
+	// Update retries the conflicts with the stored plan
+	conflicts := 0
+	updated, err := Update(ctx, store, 42, func(plan *Plan) error {
+		if conflicts == 0 {
+			conflicts++
+			concurrent, _ := store.Load(ctx, 42)
+			concurrent.Rename("comida", "súper")
+			if err := store.Save(ctx, concurrent); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+		}
+		return plan.Set(Budget{Category: "casa", Period: Weekly, Limit: 50000, Currency: "MXN"})
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if len(updated.Budgets) != 2 || updated.Budgets[0].Category != "súper" || updated.Version != 4 {
+		t.Errorf("Update() = %+v, want both changes at version 4", updated)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
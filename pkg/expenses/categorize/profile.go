@@ -0,0 +1,261 @@
+package categorize
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+)
+
+// Limits of a profile, to keep it in a single DynamoDB item and a keyboard.
+const (
+	MaxCategories = 30
+	MaxRules      = 300
+	// MaxLearnedRules is how many corrections are remembered, the oldest are forgotten first.
+	MaxLearnedRules = 200
+	// MaxNameLength is the maximum length of a category name, in characters.
+	MaxNameLength = 30
+)
+
+// DefaultCategories are the categories of a new profile.
+var DefaultCategories = []string{"Comida", "Transporte", "Hogar", "Salud", "Entretenimiento", "Otros"}
+
+var (
+	// ErrUnknownCategory is returned when a category is not in the profile.
+	ErrUnknownCategory = errors.New("categorize: unknown category")
+	// ErrDuplicateCategory is returned when a category is already in the profile.
+	ErrDuplicateCategory = errors.New("categorize: category already exists")
+	// ErrInvalidName is returned when a category name is empty, too long or
+	// has characters other than letters, digits, "-" and "_".
+	ErrInvalidName = errors.New("categorize: invalid category name")
+	// ErrTooMany is returned when a profile would exceed MaxCategories or MaxRules.
+	ErrTooMany = errors.New("categorize: too many categories or rules")
+)
+
+// Profile holds the categories and the rules of a user.
+type Profile struct {
+	UserID     int64    `json:"user_id"`
+	Categories []string `json:"categories"`
+	// Rules are in the order they were added.
+	Rules []Rule `json:"rules,omitempty"`
+	// Renames are the renamed categories whose expenses are still being
+	// moved, in the order they were renamed. See Rename.
+	Renames []Renamed `json:"renames,omitempty"`
+	// Version is used by the stores for optimistic locking, do not change it.
+	Version int64 `json:"version"`
+}
+
+// NewProfile returns the profile of a user without one: the default
+// categories with their keywords.
+func NewProfile(userID int64) *Profile {
+	profile := &Profile{UserID: userID, Categories: slices.Clone(DefaultCategories)}
+	for _, category := range DefaultCategories {
+		for _, keyword := range defaultRules[category] {
+			profile.Rules = append(profile.Rules, Rule{Kind: RuleKeyword, Category: category, Keyword: keyword})
+		}
+	}
+	return profile
+}
+
+// Find returns the name of the category as stored in the profile, ignoring
+// the case and the accents of name.
+func (p *Profile) Find(name string) (string, bool) {
+	for _, category := range p.Categories {
+		if expenses.Fold(category) == expenses.Fold(name) {
+			return category, true
+		}
+	}
+	return "", false
+}
+
+// Categorize returns the category of the best rule matching expense. A
+// hashtag with the name of a category counts as a tag rule.
+func (p *Profile) Categorize(expense *expenses.Expense) (string, bool) {
+	var best *Rule
+	consider := func(rule Rule) {
+		if best == nil || compareRanks(rule, *best) > 0 {
+			best = &rule
+		}
+	}
+	for _, rule := range p.Rules {
+		if rule.Matches(expense) {
+			consider(rule)
+		}
+	}
+	for _, tag := range expense.Tags {
+		if category, ok := p.Find(strings.TrimPrefix(tag, "#")); ok {
+			consider(Rule{Kind: RuleTag, Category: category})
+		}
+	}
+	if best == nil {
+		return "", false
+	}
+	return best.Category, true
+}
+
+// compareRanks compares the precedence of two rules.
+func compareRanks(a, b Rule) int {
+	aKind, aScore := a.rank()
+	bKind, bScore := b.rank()
+	if aKind != bKind {
+		return aKind - bKind
+	}
+	switch {
+	case aScore > bScore:
+		return 1
+	case aScore < bScore:
+		return -1
+	}
+	return 0
+}
+
+// Add adds the category name, or the rules to it when it exists, and returns
+// its name as stored. The rules already in the profile are moved to the category.
+func (p *Profile) Add(name string, rules ...Rule) (string, error) {
+	name = strings.TrimSpace(name)
+	if err := validName(name); err != nil {
+		return "", err
+	}
+	if existing, ok := p.Find(name); ok {
+		name = existing
+	} else {
+		if len(p.Categories) >= MaxCategories {
+			return "", ErrTooMany
+		}
+		p.Categories = append(p.Categories, name)
+	}
+	for _, rule := range rules {
+		rule.Category = name
+		p.Rules = slices.DeleteFunc(p.Rules, rule.same)
+		p.Rules = append(p.Rules, rule)
+	}
+	if len(p.Rules) > MaxRules {
+		return "", ErrTooMany
+	}
+	return name, nil
+}
+
+// Renamed is a category renamed from Old to New.
+type Renamed struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// Rename changes the name of the category old to name, in its rules too,
+// and returns the name old had in the profile.
+//
+// The rename is added to Renames, the caller moves the expenses of old to
+// name and then calls Done. Renaming again a category whose rename is
+// pending returns it, so an interrupted rename can be resumed.
+func (p *Profile) Rename(old, name string) (Renamed, error) {
+	name = strings.TrimSpace(name)
+	if err := validName(name); err != nil {
+		return Renamed{}, err
+	}
+	for _, renamed := range p.Renames {
+		if expenses.Fold(renamed.Old) == expenses.Fold(old) && renamed.New == name {
+			if current, ok := p.Find(old); !ok || current == name {
+				return renamed, nil
+			}
+		}
+	}
+	current, ok := p.Find(old)
+	if !ok {
+		return Renamed{}, fmt.Errorf("%w: %s", ErrUnknownCategory, old)
+	}
+	// Only the case or the accents can change without a new name
+	if existing, ok := p.Find(name); ok && existing != current {
+		return Renamed{}, fmt.Errorf("%w: %s", ErrDuplicateCategory, existing)
+	}
+	p.Categories[slices.Index(p.Categories, current)] = name
+	for i := range p.Rules {
+		if p.Rules[i].Category == current {
+			p.Rules[i].Category = name
+		}
+	}
+	renamed := Renamed{Old: current, New: name}
+	p.Renames = append(p.Renames, renamed)
+	return renamed, nil
+}
+
+// Done removes renamed from Renames once its expenses were moved. It reports
+// if the profile changed and must be saved.
+func (p *Profile) Done(renamed Renamed) bool {
+	index := slices.Index(p.Renames, renamed)
+	if index < 0 {
+		return false
+	}
+	p.Renames = slices.Delete(p.Renames, index, index+1)
+	return true
+}
+
+// Remove removes the category name and its rules, and returns the name it
+// had in the profile. The expenses keep the category.
+func (p *Profile) Remove(name string) (string, error) {
+	current, ok := p.Find(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCategory, name)
+	}
+	p.Categories = slices.DeleteFunc(p.Categories, func(category string) bool { return category == current })
+	p.Rules = slices.DeleteFunc(p.Rules, func(rule Rule) bool { return rule.Category == current })
+	return current, nil
+}
+
+// RulesOf returns the rules of category, in the order they were added.
+func (p *Profile) RulesOf(category string) []Rule {
+	var rules []Rule
+	for _, rule := range p.Rules {
+		if expenses.Fold(rule.Category) == expenses.Fold(category) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Learn remembers that the expenses of the merchant of expense belong to
+// category, e.g. when the user changes the suggested one. It reports if the
+// profile changed and must be saved.
+func (p *Profile) Learn(expense *expenses.Expense, category string) bool {
+	category, ok := p.Find(category)
+	keyword := strings.Join(words(expense.Merchant), " ")
+	if !ok || keyword == "" {
+		return false
+	}
+	rule := Rule{Kind: RuleKeyword, Category: category, Keyword: keyword, Learned: true}
+	index := slices.IndexFunc(p.Rules, func(r Rule) bool { return r.Learned && r.same(rule) })
+	if index >= 0 && p.Rules[index].Category == category {
+		return false
+	}
+	if index >= 0 {
+		p.Rules = slices.Delete(p.Rules, index, index+1)
+	}
+	p.Rules = append(p.Rules, rule)
+
+	learned := 0
+	for _, r := range p.Rules {
+		if r.Learned {
+			learned++
+		}
+	}
+	if learned > MaxLearnedRules || len(p.Rules) > MaxRules {
+		oldest := slices.IndexFunc(p.Rules, func(r Rule) bool { return r.Learned })
+		p.Rules = slices.Delete(p.Rules, oldest, oldest+1)
+	}
+	return true
+}
+
+// validName checks a category name.
+func validName(name string) error {
+	if name == "" || len([]rune(name)) > MaxNameLength {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return fmt.Errorf("%w: %q", ErrInvalidName, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package categorize
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+)
+
+func TestFind(t *testing.T) {
+	profile := NewProfile(42)
+	for _, name := range []string{"comida", "COMIDA", " Comída "} {
+		if got, ok := profile.Find(name); !ok || got != "Comida" {
+			t.Errorf("Find(%q) = %q, %t, want Comida", name, got, ok)
+		}
+	}
+	if got, ok := profile.Find("Viajes"); ok {
+		t.Errorf("Find(Viajes) = %q, want none", got)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	profile := NewProfile(42)
+	rule, _ := ParseRule("uber", "", "MXN")
+	name, err := profile.Add("Viajes", rule)
+	if err != nil || name != "Viajes" {
+		t.Fatalf("Add(Viajes) = %q, %v", name, err)
+	}
+	// The rule of Transporte moves to Viajes
+	if got, _ := profile.Categorize(&expenses.Expense{Merchant: "uber"}); got != "Viajes" {
+		t.Errorf("Categorize(uber) = %q, want Viajes", got)
+	}
+	if rules := profile.RulesOf("transporte"); slices.ContainsFunc(rules, rule.same) {
+		t.Errorf("RulesOf(transporte) = %+v, want without uber", rules)
+	}
+	if name, err := profile.Add("viajes"); err != nil || name != "Viajes" {
+		t.Errorf("Add(viajes) = %q, %v, want the existing Viajes", name, err)
+	}
+
+	for _, name := range []string{"", "Con espacio", "emoji🍔", strings.Repeat("a", MaxNameLength+1)} {
+		if _, err := profile.Add(name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Add(%q) error = %v, want ErrInvalidName", name, err)
+		}
+	}
+	for len(profile.Categories) < MaxCategories {
+		if _, err := profile.Add("Categoria" + strconv.Itoa(len(profile.Categories))); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if _, err := profile.Add("Otra"); !errors.Is(err, ErrTooMany) {
+		t.Errorf("Add() over the limit error = %v, want ErrTooMany", err)
+	}
+}
+
+func TestRename(t *testing.T) {
+	profile := NewProfile(42)
+	profile.Learn(&expenses.Expense{Merchant: "Oxxo"}, "Comida")
+
+	renamed, err := profile.Rename("comída", "Alimentos")
+	if err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if want := (Renamed{Old: "Comida", New: "Alimentos"}); renamed != want || !slices.Equal(profile.Renames, []Renamed{want}) {
+		t.Errorf("Rename() = %+v with renames %+v, want %+v pending", renamed, profile.Renames, want)
+	}
+	if _, ok := profile.Find("Comida"); ok {
+		t.Error("Find(Comida) after Rename() = found, want none")
+	}
+	if got, _ := profile.Categorize(&expenses.Expense{Merchant: "Tacos"}); got != "Alimentos" {
+		t.Errorf("Categorize() of a keyword = %q, want Alimentos", got)
+	}
+	if got, _ := profile.Categorize(&expenses.Expense{Merchant: "OXXO"}); got != "Alimentos" {
+		t.Errorf("Categorize() of a learned merchant = %q, want Alimentos", got)
+	}
+
+	// Renaming again resumes the pending rename
+	if again, err := profile.Rename("Comida", "Alimentos"); err != nil || again != renamed || len(profile.Renames) != 1 {
+		t.Errorf("Rename() again = %+v, %v with renames %+v, want the pending rename", again, err, profile.Renames)
+	}
+	if !profile.Done(renamed) || len(profile.Renames) != 0 {
+		t.Errorf("Done() renames = %+v, want none", profile.Renames)
+	}
+	if profile.Done(renamed) {
+		t.Error("Done() again = true, want unchanged")
+	}
+
+	// Only the case or the accents change
+	if renamed, err := profile.Rename("salud", "SALUD"); err != nil || renamed.New != "SALUD" {
+		t.Errorf("Rename(salud, SALUD) = %+v, %v", renamed, err)
+	}
+	if _, err := profile.Rename("Hogar", "transporte"); !errors.Is(err, ErrDuplicateCategory) {
+		t.Errorf("Rename() to an existing name error = %v, want ErrDuplicateCategory", err)
+	}
+	if _, err := profile.Rename("Viajes", "Vacaciones"); !errors.Is(err, ErrUnknownCategory) {
+		t.Errorf("Rename() of an unknown category error = %v, want ErrUnknownCategory", err)
+	}
+	if _, err := profile.Rename("Hogar", "Casa propia"); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("Rename() to an invalid name error = %v, want ErrInvalidName", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	profile := NewProfile(42)
+	removed, err := profile.Remove("transporte")
+	if err != nil || removed != "Transporte" {
+		t.Fatalf("Remove(transporte) = %q, %v", removed, err)
+	}
+	if _, ok := profile.Find("Transporte"); ok {
+		t.Error("Find(Transporte) after Remove() = found, want none")
+	}
+	if rules := profile.RulesOf("Transporte"); len(rules) != 0 {
+		t.Errorf("RulesOf(Transporte) = %+v, want none", rules)
+	}
+	if got, ok := profile.Categorize(&expenses.Expense{Merchant: "Uber"}); ok {
+		t.Errorf("Categorize(Uber) = %q, want none", got)
+	}
+	if _, err := profile.Remove("Transporte"); !errors.Is(err, ErrUnknownCategory) {
+		t.Errorf("Remove() again error = %v, want ErrUnknownCategory", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	profile, err := Update(ctx, store, 42, func(profile *Profile) error {
+		_, err := profile.Rename("Comida", "Alimentos")
+		return err
+	})
+	if err != nil || profile.Version != 1 {
+		t.Fatalf("Update() = %+v, %v, want version 1", profile, err)
+	}
+
+	stale, _ := store.Load(ctx, 42)
+	loaded, _ := store.Load(ctx, 42)
+	loaded.Done(loaded.Renames[0])
+	if err := store.Save(ctx, loaded); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.Save(ctx, stale); !errors.Is(err, ErrConflict) {
+		t.Errorf("Save() of a stale profile error = %v, want ErrConflict", err)
+	}
+	if got, _ := store.Load(ctx, 42); len(got.Renames) != 0 || got.Categories[0] != "Alimentos" {
+		t.Errorf("Load() = %+v, want Alimentos without pending renames", got)
+	}
+	if len(profile.Renames) != 1 {
+		t.Errorf("renames of the returned profile = %+v, want it unchanged by Done", profile.Renames)
+	}
+}
@@ -0,0 +1,185 @@
+// Package categorize assigns categories to expenses with per-user rules:
+// keywords of the merchant or the notes, hashtags and amount ranges. The
+// rules learned from the corrections of the user take precedence.
+//
+//	profile, err := store.Load(ctx, userID)
+//	if category, ok := profile.Categorize(expense); ok {
+//		expense.Category = category
+//	}
+//	...
+//	// The user chose another category for the merchant
+//	if profile.Learn(expense, chosen) {
+//		err = store.Save(ctx, profile)
+//	}
+package categorize
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+)
+
+// RuleKind is what a rule looks at.
+type RuleKind string
+
+// Kinds of rules.
+const (
+	// RuleKeyword matches a word, or words, of the merchant or the notes.
+	RuleKeyword RuleKind = "keyword"
+	// RuleTag matches a hashtag of the expense.
+	RuleTag RuleKind = "tag"
+	// RuleAmount matches the amounts in a range.
+	RuleAmount RuleKind = "amount"
+)
+
+// ErrInvalidRule is returned by ParseRule when the text is not a rule.
+var ErrInvalidRule = errors.New("categorize: invalid rule")
+
+// Rule assigns Category to the expenses it matches.
+type Rule struct {
+	Kind     RuleKind `json:"kind"`
+	Category string   `json:"category"`
+	// Keyword is the folded words of a keyword rule, or the tag without "#" of a tag rule.
+	Keyword string `json:"keyword,omitempty"`
+	// Min and Max are the range of an amount rule in minor units of
+	// Currency, Min is included and Max excluded. Max 0 means no upper bound.
+	Min      int64  `json:"min,omitempty"`
+	Max      int64  `json:"max,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	// Learned rules come from the corrections of the user.
+	Learned bool `json:"learned,omitempty"`
+}
+
+// ParseRule parses a rule written by the user: "#tag" for a tag, "<100",
+// ">1000" or "100-500" for an amount range in currency, and any other text
+// for a keyword.
+func ParseRule(text, category, currency string) (Rule, error) {
+	text = strings.TrimSpace(text)
+	rule := Rule{Category: category}
+	switch {
+	case strings.HasPrefix(text, "#"):
+		rule.Kind, rule.Keyword = RuleTag, expenses.Fold(strings.TrimPrefix(text, "#"))
+		if rule.Keyword == "" {
+			return Rule{}, fmt.Errorf("%w: empty tag", ErrInvalidRule)
+		}
+		return rule, nil
+	case strings.HasPrefix(text, "<"), strings.HasPrefix(text, ">"), strings.Contains(text, "-") && strings.IndexFunc(text, unicode.IsLetter) < 0:
+		return parseRange(text, rule, currency)
+	}
+	rule.Kind, rule.Keyword = RuleKeyword, strings.Join(words(text), " ")
+	if rule.Keyword == "" {
+		return Rule{}, fmt.Errorf("%w: empty keyword %q", ErrInvalidRule, text)
+	}
+	return rule, nil
+}
+
+// parseRange parses the amount range of rule.
+func parseRange(text string, rule Rule, currency string) (Rule, error) {
+	rule.Kind, rule.Currency = RuleAmount, currency
+	amount := func(text string) (int64, error) {
+		value, err := expenses.ParseAmount(strings.TrimSpace(text), currency)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrInvalidRule, err)
+		}
+		return value, nil
+	}
+	var err error
+	switch {
+	case strings.HasPrefix(text, "<"):
+		rule.Max, err = amount(text[1:])
+	case strings.HasPrefix(text, ">"):
+		rule.Min, err = amount(text[1:])
+	default:
+		low, high, _ := strings.Cut(text, "-")
+		if rule.Min, err = amount(low); err == nil {
+			rule.Max, err = amount(high)
+		}
+		if err == nil && rule.Max <= rule.Min {
+			err = fmt.Errorf("%w: empty range %q", ErrInvalidRule, text)
+		}
+	}
+	if err != nil {
+		return Rule{}, err
+	}
+	return rule, nil
+}
+
+// Matches reports if the rule applies to expense.
+func (r Rule) Matches(expense *expenses.Expense) bool {
+	switch r.Kind {
+	case RuleKeyword:
+		phrase := " " + r.Keyword + " "
+		return strings.Contains(" "+strings.Join(words(expense.Merchant), " ")+" ", phrase) ||
+			strings.Contains(" "+strings.Join(words(expense.Notes), " ")+" ", phrase)
+	case RuleTag:
+		for _, tag := range expense.Tags {
+			if expenses.Fold(strings.TrimPrefix(tag, "#")) == r.Keyword {
+				return true
+			}
+		}
+	case RuleAmount:
+		return expense.Amount > 0 && strings.EqualFold(expense.Currency, r.Currency) &&
+			expense.Amount >= r.Min && (r.Max == 0 || expense.Amount < r.Max)
+	}
+	return false
+}
+
+// String describes the rule to the user, e.g. "uber", "#cafe" or
+// "$100.00 MXN-$500.00 MXN".
+func (r Rule) String() string {
+	switch r.Kind {
+	case RuleTag:
+		return "#" + r.Keyword
+	case RuleAmount:
+		switch {
+		case r.Max == 0:
+			return ">" + expenses.FormatAmount(r.Min, r.Currency)
+		case r.Min == 0:
+			return "<" + expenses.FormatAmount(r.Max, r.Currency)
+		}
+		return expenses.FormatAmount(r.Min, r.Currency) + "-" + expenses.FormatAmount(r.Max, r.Currency)
+	}
+	return r.Keyword
+}
+
+// same reports if both rules match the same expenses, ignoring their category.
+func (r Rule) same(other Rule) bool {
+	return r.Kind == other.Kind && r.Keyword == other.Keyword && r.Min == other.Min && r.Max == other.Max &&
+		strings.EqualFold(r.Currency, other.Currency)
+}
+
+// rank orders the matching rules, the greatest wins: learned keywords, then
+// tags, keywords and amounts. Between rules of the same kind the longest
+// keyword or the narrowest range wins.
+func (r Rule) rank() (int, int64) {
+	switch {
+	case r.Kind == RuleKeyword && r.Learned:
+		return 4, int64(len(r.Keyword))
+	case r.Kind == RuleTag:
+		return 3, 0
+	case r.Kind == RuleKeyword:
+		return 2, int64(len(r.Keyword))
+	case r.Max == 0:
+		return 1, -1 << 62
+	}
+	return 1, r.Min - r.Max
+}
+
+// defaultRules are the keywords of the default categories.
+var defaultRules = map[string][]string{
+	"Comida":          {"restaurante", "tacos", "cafe", "starbucks", "super", "soriana", "chedraui", "la comer"},
+	"Transporte":      {"uber", "didi", "taxi", "metro", "gasolina", "pemex", "estacionamiento", "caseta"},
+	"Hogar":           {"renta", "luz", "cfe", "agua", "gas", "internet", "telmex", "totalplay", "home depot"},
+	"Salud":           {"farmacia", "doctor", "medico", "consulta", "hospital", "dentista"},
+	"Entretenimiento": {"netflix", "spotify", "cine", "cinepolis", "cinemex", "concierto"},
+}
+
+// words splits the folded text in words of letters and digits.
+func words(text string) []string {
+	return strings.FieldsFunc(expenses.Fold(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
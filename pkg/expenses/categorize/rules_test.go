@@ -0,0 +1,202 @@
+package categorize
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		text string
+		want Rule
+	}{
+		{"Starbucks", Rule{Kind: RuleKeyword, Category: "Café", Keyword: "starbucks"}},
+		{"  La Comer ", Rule{Kind: RuleKeyword, Category: "Café", Keyword: "la comer"}},
+		{"Café-Bar", Rule{Kind: RuleKeyword, Category: "Café", Keyword: "cafe bar"}},
+		{"#Cafés", Rule{Kind: RuleTag, Category: "Café", Keyword: "cafes"}},
+		{"<100", Rule{Kind: RuleAmount, Category: "Café", Max: 10000, Currency: "MXN"}},
+		{">1000", Rule{Kind: RuleAmount, Category: "Café", Min: 100000, Currency: "MXN"}},
+		{"100-500.50", Rule{Kind: RuleAmount, Category: "Café", Min: 10000, Max: 50050, Currency: "MXN"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			rule, err := ParseRule(tt.text, "Café", "MXN")
+			if err != nil {
+				t.Fatalf("ParseRule(%q) error = %v", tt.text, err)
+			}
+			if rule != tt.want {
+				t.Errorf("ParseRule(%q) = %+v, want %+v", tt.text, rule, tt.want)
+			}
+		})
+	}
+
+	for _, text := range []string{"", "#", "!!", "<abc", "500-100", "100-100"} {
+		if _, err := ParseRule(text, "Café", "MXN"); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("ParseRule(%q) error = %v, want ErrInvalidRule", text, err)
+		}
+	}
+}
+
+func TestRuleString(t *testing.T) {
+	tests := map[string]string{
+		"Uber Eats": "uber eats",
+		"#Café":     "#cafe",
+		"<100":      "<$100.00 MXN",
+		">1000":     ">$1,000.00 MXN",
+		"100-500":   "$100.00 MXN-$500.00 MXN",
+	}
+	for text, want := range tests {
+		rule, err := ParseRule(text, "Café", "MXN")
+		if err != nil {
+			t.Fatalf("ParseRule(%q) error = %v", text, err)
+		}
+		if got := rule.String(); got != want {
+			t.Errorf("String() of %q = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	keyword := Rule{Kind: RuleKeyword, Keyword: "la comer"}
+	tag := Rule{Kind: RuleTag, Keyword: "cafe"}
+	amount := Rule{Kind: RuleAmount, Min: 10000, Max: 50000, Currency: "MXN"}
+	tests := []struct {
+		name    string
+		rule    Rule
+		expense expenses.Expense
+		want    bool
+	}{
+		{"keyword in merchant", keyword, expenses.Expense{Merchant: "La Comer Coyoacán"}, true},
+		{"keyword in notes", keyword, expenses.Expense{Notes: "súper en la comer"}, true},
+		{"keyword ignoring case", keyword, expenses.Expense{Merchant: "LA COMER"}, true},
+		{"keyword ignoring punctuation", keyword, expenses.Expense{Merchant: "la-comer, s.a."}, true},
+		{"keyword inside a word", keyword, expenses.Expense{Merchant: "Villa Comercial"}, false},
+		{"keyword words apart", keyword, expenses.Expense{Notes: "la tienda comer"}, false},
+		{"accented keyword", Rule{Kind: RuleKeyword, Keyword: "medico"}, expenses.Expense{Notes: "consulta con el Médico"}, true},
+		{"tag", tag, expenses.Expense{Tags: []string{"trabajo", "cafe"}}, true},
+		{"tag with accents and case", tag, expenses.Expense{Tags: []string{"#Café"}}, true},
+		{"tag missing", tag, expenses.Expense{Tags: []string{"cafeteria"}, Merchant: "cafe"}, false},
+		{"amount at min", amount, expenses.Expense{Amount: 10000, Currency: "MXN"}, true},
+		{"amount at max", amount, expenses.Expense{Amount: 50000, Currency: "MXN"}, false},
+		{"amount of another currency", amount, expenses.Expense{Amount: 20000, Currency: "USD"}, false},
+		{"amount without upper bound", Rule{Kind: RuleAmount, Min: 100, Currency: "MXN"}, expenses.Expense{Amount: 1 << 40, Currency: "mxn"}, true},
+		{"no amount", Rule{Kind: RuleAmount, Max: 100, Currency: "MXN"}, expenses.Expense{Currency: "MXN"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches(&tt.expense); got != tt.want {
+				t.Errorf("%+v.Matches(%+v) = %t, want %t", tt.rule, tt.expense, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategorize(t *testing.T) {
+	profile := NewProfile(42)
+	for _, add := range []struct {
+		category string
+		rules    []string
+	}{
+		{"Café", []string{"starbucks", "#cafe", "<80"}},
+		{"Súper", []string{"walmart", "walmart express"}},
+		{"Grandes", []string{">5000", "1000-10000"}},
+	} {
+		var rules []Rule
+		for _, text := range add.rules {
+			rule, err := ParseRule(text, add.category, "MXN")
+			if err != nil {
+				t.Fatalf("ParseRule(%q) error = %v", text, err)
+			}
+			rules = append(rules, rule)
+		}
+		if _, err := profile.Add(add.category, rules...); err != nil {
+			t.Fatalf("Add(%s) error = %v", add.category, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		expense expenses.Expense
+		want    string
+	}{
+		{"default keyword", expenses.Expense{Merchant: "UBER TRIP"}, "Transporte"},
+		{"default keyword with accents", expenses.Expense{Notes: "Farmacia del Ahorro"}, "Salud"},
+		{"added keyword", expenses.Expense{Merchant: "Walmart Universidad"}, "Súper"},
+		{"longest keyword wins", expenses.Expense{Merchant: "Walmart Express Mixcoac", Notes: "tacos"}, "Súper"},
+		{"tag over keyword", expenses.Expense{Merchant: "Uber", Tags: []string{"cafe"}}, "Café"},
+		{"category as hashtag", expenses.Expense{Merchant: "Uber", Tags: []string{"#SUPER"}}, "Súper"},
+		{"keyword over amount", expenses.Expense{Merchant: "Starbucks", Amount: 600000, Currency: "MXN"}, "Café"},
+		{"amount", expenses.Expense{Amount: 5000, Currency: "MXN"}, "Café"},
+		{"narrowest range wins", expenses.Expense{Amount: 600000, Currency: "MXN"}, "Grandes"},
+		{"no rule", expenses.Expense{Merchant: "Papelería", Amount: 20000, Currency: "MXN"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := profile.Categorize(&tt.expense)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("Categorize(%+v) = %q, %t, want %q", tt.expense, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestLearn(t *testing.T) {
+	profile := NewProfile(42)
+	expense := &expenses.Expense{Merchant: "Uber Eats", Tags: []string{"comida"}}
+	if got, _ := profile.Categorize(expense); got != "Comida" {
+		t.Fatalf("Categorize() before learning = %q, want Comida", got)
+	}
+
+	// A learned rule takes precedence over the tags and the other keywords
+	if !profile.Learn(expense, "entretenimiento") {
+		t.Fatal("Learn() = false, want the profile changed")
+	}
+	if got, _ := profile.Categorize(expense); got != "Entretenimiento" {
+		t.Errorf("Categorize() after learning = %q, want Entretenimiento", got)
+	}
+	if got, _ := profile.Categorize(&expenses.Expense{Merchant: "uber"}); got != "Transporte" {
+		t.Errorf("Categorize() of a shorter merchant = %q, want Transporte", got)
+	}
+	if profile.Learn(expense, "Entretenimiento") {
+		t.Error("Learn() of the same category = true, want unchanged")
+	}
+
+	// Learning another category replaces the learned rule
+	if !profile.Learn(expense, "Hogar") {
+		t.Fatal("Learn() of another category = false, want the profile changed")
+	}
+	if got, _ := profile.Categorize(expense); got != "Hogar" {
+		t.Errorf("Categorize() after learning again = %q, want Hogar", got)
+	}
+	learned := 0
+	for _, rule := range profile.Rules {
+		if rule.Learned {
+			learned++
+		}
+	}
+	if learned != 1 {
+		t.Errorf("learned rules = %d, want 1", learned)
+	}
+
+	if profile.Learn(expense, "Desconocida") {
+		t.Error("Learn() of an unknown category = true, want unchanged")
+	}
+	if profile.Learn(&expenses.Expense{Notes: "sin comercio"}, "Hogar") {
+		t.Error("Learn() without merchant = true, want unchanged")
+	}
+}
+
+func TestLearnForgetsOldest(t *testing.T) {
+	profile := &Profile{UserID: 42, Categories: []string{"Otros"}}
+	for i := range MaxLearnedRules + 1 {
+		profile.Learn(&expenses.Expense{Merchant: "comercio " + string(rune('a'+i/26)) + string(rune('a'+i%26))}, "Otros")
+	}
+	if len(profile.Rules) != MaxLearnedRules {
+		t.Fatalf("rules = %d, want %d", len(profile.Rules), MaxLearnedRules)
+	}
+	if profile.Rules[0].Keyword != "comercio ab" {
+		t.Errorf("oldest rule = %q, want comercio ab", profile.Rules[0].Keyword)
+	}
+}
@@ -0,0 +1,79 @@
+package categorize
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrConflict is returned by Store.Save when the profile was changed since it was loaded.
+var ErrConflict = errors.New("categorize: profile changed concurrently")
+
+// Store persists the profiles of the users.
+type Store interface {
+	// Load returns the profile of userID, or NewProfile if it has none.
+	Load(ctx context.Context, userID int64) (*Profile, error)
+	// Save stores profile if its Version matches the stored one, and increments it.
+	Save(ctx context.Context, profile *Profile) error
+}
+
+// MemoryStore is a Store for tests and local runs, the profiles are lost on restart.
+type MemoryStore struct {
+	mu       sync.Mutex
+	profiles map[int64]Profile
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{profiles: make(map[int64]Profile)}
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(ctx context.Context, userID int64) (*Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	profile, ok := s.profiles[userID]
+	if !ok {
+		return NewProfile(userID), nil
+	}
+	return copyProfile(profile), nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(ctx context.Context, profile *Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.profiles[profile.UserID].Version != profile.Version {
+		return ErrConflict
+	}
+	profile.Version++
+	s.profiles[profile.UserID] = *copyProfile(*profile)
+	return nil
+}
+
+// Update loads the profile of userID, changes it with update and saves it,
+// loading it again when it was changed concurrently.
+func Update(ctx context.Context, store Store, userID int64, update func(profile *Profile) error) (*Profile, error) {
+	for attempt := 0; ; attempt++ {
+		profile, err := store.Load(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := update(profile); err != nil {
+			return nil, err
+		}
+		err = store.Save(ctx, profile)
+		if !errors.Is(err, ErrConflict) || attempt == 2 {
+			return profile, err
+		}
+	}
+}
+
+// copyProfile copies profile without sharing its slices.
+func copyProfile(profile Profile) *Profile {
+	profile.Categories = slices.Clone(profile.Categories)
+	profile.Rules = slices.Clone(profile.Rules)
+	profile.Renames = slices.Clone(profile.Renames)
+	return &profile
+}
@@ -0,0 +1,109 @@
+package categorize
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by DynamoDBStore.
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+// DynamoDBStore is a Store over a DynamoDB table with a string partition key "pk".
+//
+// Each profile is one item with the key "USER#<id>" and the Profile as JSON
+// in "profile", saved with optimistic locking on "version".
+type DynamoDBStore struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+// NewDynamoDBStore creates a DynamoDBStore over tableName.
+func NewDynamoDBStore(client DynamoDBAPI, tableName string) *DynamoDBStore {
+	return &DynamoDBStore{client: client, tableName: tableName}
+}
+
+// Load implements Store.
+func (s *DynamoDBStore) Load(ctx context.Context, userID int64) (*Profile, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            itemKey(userID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading categories of %d: %w", userID, err)
+	}
+	if out.Item == nil {
+		return NewProfile(userID), nil
+	}
+
+	encoded, ok := out.Item["profile"].(*types.AttributeValueMemberS)
+	if !ok {
+		return nil, fmt.Errorf("loading categories of %d: profile attribute is missing", userID)
+	}
+	var profile Profile
+	if err := json.Unmarshal([]byte(encoded.Value), &profile); err != nil {
+		return nil, fmt.Errorf("loading categories of %d: %w", userID, err)
+	}
+	version, ok := out.Item["version"].(*types.AttributeValueMemberN)
+	if !ok {
+		return nil, fmt.Errorf("loading categories of %d: version attribute is missing", userID)
+	}
+	if profile.Version, err = strconv.ParseInt(version.Value, 10, 64); err != nil {
+		return nil, fmt.Errorf("loading categories of %d: %w", userID, err)
+	}
+	return &profile, nil
+}
+
+// Save implements Store.
+func (s *DynamoDBStore) Save(ctx context.Context, profile *Profile) error {
+	version := profile.Version
+	profile.Version = version + 1
+	encoded, err := json.Marshal(profile)
+	if err != nil {
+		profile.Version = version
+		return fmt.Errorf("saving categories of %d: %w", profile.UserID, err)
+	}
+
+	condition := "attribute_not_exists(pk)"
+	var values map[string]types.AttributeValue
+	if version > 0 {
+		condition = "version = :version"
+		values = map[string]types.AttributeValue{":version": numberValue(version)}
+	}
+	item := itemKey(profile.UserID)
+	item["profile"] = &types.AttributeValueMemberS{Value: string(encoded)}
+	item["version"] = numberValue(version + 1)
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(s.tableName),
+		Item:                      item,
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		profile.Version = version
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return ErrConflict
+		}
+		return fmt.Errorf("saving categories of %d: %w", profile.UserID, err)
+	}
+	return nil
+}
+
+func itemKey(userID int64) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "USER#" + strconv.FormatInt(userID, 10)}}
+}
+
+func numberValue(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
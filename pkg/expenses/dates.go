@@ -0,0 +1,143 @@
+package expenses
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeZone is the time zone of the users when TIME_ZONE is not set.
+const DefaultTimeZone = "America/Mexico_City"
+
+// LoadLocation loads the time zone named by the TIME_ZONE environment variable,
+// or DefaultTimeZone if it is not set. It panics if the name is unknown, so a
+// misconfigured Lambda fails when it starts.
+func LoadLocation() *time.Location {
+	name := os.Getenv("TIME_ZONE")
+	if name == "" {
+		name = DefaultTimeZone
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("invalid TIME_ZONE %s: %v", name, err))
+	}
+	return location
+}
+
+// daysAgoWords are the words for days relative to today.
+var daysAgoWords = map[string]int{
+	"hoy":       0,
+	"today":     0,
+	"ayer":      1,
+	"yesterday": 1,
+	"antier":    2,
+	"anteayer":  2,
+}
+
+// weekdayWords are the weekday names in Spanish and English, without accents.
+var weekdayWords = map[string]time.Weekday{
+	"domingo":   time.Sunday,
+	"lunes":     time.Monday,
+	"martes":    time.Tuesday,
+	"miercoles": time.Wednesday,
+	"jueves":    time.Thursday,
+	"viernes":   time.Friday,
+	"sabado":    time.Saturday,
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// dateLayouts are the absolute dates accepted, day first as written in Mexico.
+var dateLayouts = []string{"2006-01-02", "2/1/2006", "2/1/06", "2-1-2006"}
+
+// dayWords are the words for "days" after a number, e.g. "hace 3 días" or "3 days ago".
+var dayWords = map[string]bool{"dia": true, "dias": true, "day": true, "days": true}
+
+// accentFolder removes the accents of the Spanish words.
+var accentFolder = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u")
+
+// Fold lower cases text and removes its spaces around and its accents, to
+// compare the words and names written by the users.
+func Fold(text string) string {
+	return accentFolder.Replace(strings.ToLower(strings.TrimSpace(text)))
+}
+
+// fold folds word and removes its trailing punctuation.
+func fold(word string) string {
+	return Fold(strings.TrimRight(word, ",.;:!?"))
+}
+
+// parseDate looks for a date at words[i] and returns the day it refers to and
+// how many words it takes, or 0 if there is none. today is at midnight.
+//
+// Weekdays refer to the last one before today, e.g. "lunes" said on a Monday
+// is the Monday of the week before.
+func parseDate(words []string, i int, today time.Time) (time.Time, int) {
+	word := fold(words[i])
+	next := func(n int) string {
+		if i+n < len(words) {
+			return fold(words[i+n])
+		}
+		return ""
+	}
+
+	if days, ok := daysAgoWords[word]; ok {
+		return today.AddDate(0, 0, -days), 1
+	}
+	// "day before yesterday"
+	if word == "day" && next(1) == "before" && next(2) == "yesterday" {
+		return today.AddDate(0, 0, -2), 3
+	}
+	// "hace 3 días"
+	if word == "hace" {
+		if days, err := strconv.Atoi(next(1)); err == nil && days >= 0 && dayWords[next(2)] {
+			return today.AddDate(0, 0, -days), 3
+		}
+	}
+	// "3 days ago"
+	if days, err := strconv.Atoi(word); err == nil && days >= 0 && dayWords[next(1)] && next(2) == "ago" {
+		return today.AddDate(0, 0, -days), 3
+	}
+	// "el lunes", "last monday", "lunes pasado"
+	length := 0
+	if word == "el" || word == "last" {
+		word, length = next(1), 1
+	}
+	if weekday, ok := weekdayWords[word]; ok {
+		length++
+		if next(length) == "pasado" {
+			length++
+		}
+		days := (int(today.Weekday())-int(weekday)+6)%7 + 1
+		return today.AddDate(0, 0, -days), length
+	}
+
+	// "2024-03-01", "el 15/03/2024", "last 2024-03-01"
+	for _, layout := range dateLayouts {
+		if date, err := time.ParseInLocation(layout, word, today.Location()); err == nil {
+			return date, length + 1
+		}
+	}
+	// "15/03" is the last 15 of March up to today
+	if date, err := time.ParseInLocation("2/1", word, today.Location()); err == nil {
+		date = date.AddDate(today.Year(), 0, 0)
+		if date.After(today) {
+			date = date.AddDate(-1, 0, 0)
+		}
+		return date, length + 1
+	}
+	return time.Time{}, 0
+}
+
+// startOfDay returns the midnight of the day of t in loc.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,64 @@
+package expenses
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	// Wednesday
+	today := time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		text   string
+		want   time.Time
+		length int
+	}{
+		{"ayer tacos", time.Date(2024, time.March, 19, 0, 0, 0, 0, time.UTC), 1},
+		{"day before yesterday", time.Date(2024, time.March, 18, 0, 0, 0, 0, time.UTC), 3},
+		{"hace 3 días", time.Date(2024, time.March, 17, 0, 0, 0, 0, time.UTC), 3},
+		{"3 days ago", time.Date(2024, time.March, 17, 0, 0, 0, 0, time.UTC), 3},
+		{"el lunes", time.Date(2024, time.March, 18, 0, 0, 0, 0, time.UTC), 2},
+		{"miércoles pasado", time.Date(2024, time.March, 13, 0, 0, 0, 0, time.UTC), 2},
+		{"2024-03-01", time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), 1},
+		{"last 2024-03-01", time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), 2},
+		{"el 1/3/2024", time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), 2},
+		{"15/03", time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), 1},
+		{"el 15/03", time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC), 2},
+		{"el 25/12", time.Date(2023, time.December, 25, 0, 0, 0, 0, time.UTC), 2},
+		{"el súper", time.Time{}, 0},
+		{"tacos", time.Time{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			date, length := parseDate(strings.Fields(tt.text), 0, today)
+			if !date.Equal(tt.want) || length != tt.length {
+				t.Errorf("parseDate(%q) = %s, %d, want %s, %d", tt.text, date, length, tt.want, tt.length)
+			}
+		})
+	}
+}
+
+func TestParseDateConsumesArticle(t *testing.T) {
+	parser := &Parser{Now: func() time.Time { return time.Date(2024, time.March, 20, 12, 0, 0, 0, time.UTC) }}
+	for _, text := range []string{"100 tacos el 15/03", "100 tacos last 2024-03-15"} {
+		expense, err := parser.Parse(text)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", text, err)
+		}
+		if want := time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC); !expense.Date.Equal(want) || expense.Notes != "tacos" {
+			t.Errorf("Parse(%q) = date %s, notes %q, want %s and \"tacos\"", text, expense.Date, expense.Notes, want)
+		}
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	t.Setenv("TIME_ZONE", "")
+	if location := LoadLocation(); location.String() != DefaultTimeZone {
+		t.Errorf("LoadLocation() = %s, want %s", location, DefaultTimeZone)
+	}
+	t.Setenv("TIME_ZONE", "Europe/Madrid")
+	if location := LoadLocation(); location.String() != "Europe/Madrid" {
+		t.Errorf("LoadLocation() = %s, want Europe/Madrid", location)
+	}
+}
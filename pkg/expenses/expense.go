@@ -0,0 +1,96 @@
+// Package expenses holds the expense domain model and turns chat messages
+// into expenses.
+package expenses
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultCurrency is the currency of the amounts that do not state one.
+const DefaultCurrency = "MXN"
+
+// Expense is a single expense recorded by a user.
+type Expense struct {
+	ID     string `json:"id"`
+	UserID int64  `json:"user_id"`
+	ChatID int64  `json:"chat_id"`
+	// Amount is in minor units of Currency, e.g. 2350 is 23.50 MXN. See ParseAmount.
+	Amount int64 `json:"amount"`
+	// Currency is an ISO 4217 code, e.g. "MXN".
+	Currency string   `json:"currency"`
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Merchant string   `json:"merchant,omitempty"`
+	// Date is the day of the expense, at midnight in the location of the user.
+	Date time.Time `json:"date"`
+	// Payer is who paid, by default the name of the user who recorded it.
+	Payer string `json:"payer,omitempty"`
+	Notes string `json:"notes,omitempty"`
+	// ReceiptKey is the S3 key of the picture of the receipt, if any.
+	ReceiptKey string `json:"receipt_key,omitempty"`
+
+	// ExpiresAt is set on drafts, e.g. not confirmed receipts, to remove them
+	// after it. It is zero for the confirmed expenses. See IsDraft.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Version is used by the repository for optimistic locking, do not change it.
+	Version int64 `json:"version"`
+}
+
+// IsDraft reports if the expense waits to be confirmed.
+func (e *Expense) IsDraft() bool {
+	return !e.ExpiresAt.IsZero()
+}
+
+// Validate checks that the expense can be stored. Drafts may have no amount yet.
+func (e *Expense) Validate() error {
+	switch {
+	case e.UserID == 0:
+		return errors.New("expenses: expense without user")
+	case e.Amount < 0 || e.Amount == 0 && !e.IsDraft():
+		return ErrInvalidAmount
+	case len(e.Currency) != 3:
+		return fmt.Errorf("expenses: invalid currency %q", e.Currency)
+	case e.Date.IsZero():
+		return errors.New("expenses: expense without date")
+	case len(e.Tags) > MaxTags:
+		return fmt.Errorf("expenses: more than %d tags", MaxTags)
+	}
+	return nil
+}
+
+// FormattedAmount returns the amount with its currency, e.g. "$1,200.00 MXN".
+func (e *Expense) FormattedAmount() string {
+	return FormatAmount(e.Amount, e.Currency)
+}
+
+// Summary describes the expense to the user, in Spanish like the rest of the
+// bot, e.g. "$23.50 MXN en Comida (Starbucks): café del 17/10/2026".
+func (e *Expense) Summary() string {
+	summary := e.FormattedAmount()
+	if e.Category != "" {
+		summary += " en " + e.Category
+	}
+	if e.Merchant != "" {
+		summary += " (" + e.Merchant + ")"
+	}
+	if e.Notes != "" {
+		summary += ": " + e.Notes
+	}
+	return summary + " del " + e.Date.Format("02/01/2006")
+}
+
+// HasTag reports if the expense has tag, ignoring the case.
+func (e *Expense) HasTag(tag string) bool {
+	tag = normalizeTag(tag)
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,242 @@
+package expenses
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// ErrNoAmount is returned by the Parser when the text has no amount.
+var ErrNoAmount = errors.New("expenses: no amount found")
+
+// currencyWords are the words accepted next to an amount to state its currency.
+var currencyWords = map[string]string{
+	"mxn":     "MXN",
+	"peso":    "MXN",
+	"pesos":   "MXN",
+	"usd":     "USD",
+	"dolar":   "USD",
+	"dolares": "USD",
+	"dollar":  "USD",
+	"dollars": "USD",
+	"eur":     "EUR",
+	"euro":    "EUR",
+	"euros":   "EUR",
+	"gbp":     "GBP",
+	"jpy":     "JPY",
+	"cad":     "CAD",
+	"cop":     "COP",
+	"ars":     "ARS",
+	"clp":     "CLP",
+}
+
+// merchantWords introduce the merchant at the end of the text, e.g. "café en Starbucks".
+var merchantWords = map[string]bool{"en": true, "at": true}
+
+// Parser turns chat text like "23.50 tacos #food ayer" or "$1,200 rent" into
+// an Expense. The zero value is ready to use.
+//
+// The first number is the amount, with an optional currency symbol or code
+// next to it. Hashtags are the tags, dates like "ayer", "yesterday",
+// "el lunes" or "15/03" are the date (today by default), the words after a
+// final "en" or "at" are the merchant and the remaining words are the notes.
+type Parser struct {
+	// Currency is used when the text does not state one, DefaultCurrency if empty.
+	Currency string
+	// Location is where the user lives, to know which day is today. UTC if nil.
+	Location *time.Location
+	// Now returns the current time, time.Now if nil.
+	Now func() time.Time
+}
+
+// Parse parses text into an Expense, taking the tags from the words that
+// start with "#".
+func (p *Parser) Parse(text string) (*Expense, error) {
+	return p.parse(text, nil, p.now())
+}
+
+// ParseMessage parses the text, or the caption, of msg into an Expense of
+// its sender. A leading bot command is ignored, the tags are the hashtag
+// entities and relative dates refer to the day msg was sent.
+func (p *Parser) ParseMessage(msg *telegram.Message) (*Expense, error) {
+	text := msg.Text
+	if text == "" {
+		text = msg.Caption
+	}
+	if command, ok := msg.Command(); ok {
+		text = command.Args
+	}
+	tags := msg.Hashtags()
+	if tags == nil {
+		tags = []string{}
+	}
+	now := p.now()
+	if msg.Date > 0 {
+		now = time.Unix(msg.Date, 0)
+	}
+
+	expense, err := p.parse(text, tags, now)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Chat != nil {
+		expense.ChatID = msg.Chat.ID
+	}
+	if msg.From != nil {
+		expense.UserID = msg.From.ID
+	}
+	expense.Payer = PayerOf(msg.From)
+	return expense, nil
+}
+
+// PayerOf returns the name of user as the payer of its expenses.
+func PayerOf(user *telegram.User) string {
+	if user == nil {
+		return ""
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
+
+// parse parses text, with the tags from the entities or nil to take them from the text.
+func (p *Parser) parse(text string, tags []string, now time.Time) (*Expense, error) {
+	location := p.Location
+	if location == nil {
+		location = time.UTC
+	}
+	expense := &Expense{
+		Currency: p.Currency,
+		Date:     startOfDay(now, location),
+	}
+	if expense.Currency == "" {
+		expense.Currency = DefaultCurrency
+	}
+	today := expense.Date
+
+	words := strings.Fields(text)
+	var rest []string
+	amountText, dateFound := "", false
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		switch {
+		case strings.HasPrefix(word, "#") || strings.HasPrefix(word, "$") && len(word) > 1 && isLetters(word[1:]):
+			// Hashtags and cashtags, e.g. "#comida" or "$USD"
+			if currency, ok := currencyWords[fold(word[1:])]; ok && word[0] == '$' {
+				expense.Currency = currency
+			} else if tags == nil && word[0] == '#' {
+				expense.Tags = append(expense.Tags, word[1:])
+			}
+			continue
+		case !dateFound:
+			if date, length := parseDate(words, i, today); length > 0 {
+				expense.Date, dateFound = date, true
+				i += length - 1
+				continue
+			}
+		}
+		if amountText == "" {
+			if number, currency, ok := splitCurrency(word); ok {
+				amountText = number
+				if currency != "" {
+					expense.Currency = currency
+				} else if i+1 < len(words) {
+					// "100 USD"
+					if currency, ok := currencyWords[fold(words[i+1])]; ok {
+						expense.Currency = currency
+						i++
+					}
+				}
+				if len(rest) > 0 {
+					// "USD 100"
+					if currency, ok := currencyWords[fold(rest[len(rest)-1])]; ok {
+						expense.Currency = currency
+						rest = rest[:len(rest)-1]
+					}
+				}
+				continue
+			}
+		}
+		rest = append(rest, word)
+	}
+	if amountText == "" {
+		return nil, ErrNoAmount
+	}
+	amount, err := ParseAmount(amountText, expense.Currency)
+	if err != nil {
+		return nil, err
+	}
+	expense.Amount = amount
+
+	if tags != nil {
+		expense.Tags = tags
+	}
+	expense.Tags = normalizeTags(expense.Tags)
+
+	for i := len(rest) - 2; i >= 0; i-- {
+		if merchantWords[fold(rest[i])] {
+			expense.Merchant = strings.Join(rest[i+1:], " ")
+			rest = rest[:i]
+			break
+		}
+	}
+	expense.Notes = strings.Join(rest, " ")
+	return expense, nil
+}
+
+func (p *Parser) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+// splitCurrency splits word in a number and the currency of its symbol, if
+// any, e.g. "€12" or "12€". ok is false if word is not a number.
+func splitCurrency(word string) (number, currency string, ok bool) {
+	number = strings.TrimRight(word, ",.;:!?")
+	for symbol, c := range currencySymbols {
+		if strings.HasPrefix(number, symbol) || strings.HasSuffix(number, symbol) {
+			number, currency = strings.TrimSuffix(strings.TrimPrefix(number, symbol), symbol), c
+		}
+	}
+	number = strings.TrimPrefix(number, "$")
+	if number == "" {
+		return "", "", false
+	}
+	for _, r := range number {
+		if (r < '0' || r > '9') && r != ',' && r != '.' {
+			return "", "", false
+		}
+	}
+	return number, currency, true
+}
+
+// isLetters reports if s is only ASCII letters.
+func isLetters(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeTag lower cases tag and removes its "#".
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// normalizeTags normalizes tags and removes the duplicates and empty ones.
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,156 @@
+package expenses
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+func TestParse(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, time.March, 20, 15, 30, 0, 0, time.UTC)
+	today := time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		text string
+		want Expense
+	}{
+		{
+			text: "23.50 tacos #food ayer",
+			want: Expense{Amount: 2350, Currency: "MXN", Tags: []string{"food"}, Notes: "tacos", Date: today.AddDate(0, 0, -1)},
+		},
+		{
+			text: "$1,200 rent",
+			want: Expense{Amount: 120000, Currency: "MXN", Notes: "rent", Date: today},
+		},
+		{
+			text: "23,50 café en Starbucks",
+			want: Expense{Amount: 2350, Currency: "MXN", Merchant: "Starbucks", Notes: "café", Date: today},
+		},
+		{
+			text: "100 en Oxxo",
+			want: Expense{Amount: 10000, Currency: "MXN", Merchant: "Oxxo", Date: today},
+		},
+		{
+			text: "gasolina 500 at Pemex Reforma",
+			want: Expense{Amount: 50000, Currency: "MXN", Merchant: "Pemex Reforma", Notes: "gasolina", Date: today},
+		},
+		{
+			text: "100 USD taxi",
+			want: Expense{Amount: 10000, Currency: "USD", Notes: "taxi", Date: today},
+		},
+		{
+			text: "taxi usd 100",
+			want: Expense{Amount: 10000, Currency: "USD", Notes: "taxi", Date: today},
+		},
+		{
+			text: "12 uber $USD",
+			want: Expense{Amount: 1200, Currency: "USD", Notes: "uber", Date: today},
+		},
+		{
+			text: "€12,5 museo",
+			want: Expense{Amount: 1250, Currency: "EUR", Notes: "museo", Date: today},
+		},
+		{
+			text: "1.200,50 super #Casa #casa el lunes",
+			want: Expense{Amount: 120050, Currency: "MXN", Tags: []string{"casa"}, Notes: "super", Date: today.AddDate(0, 0, -2)},
+		},
+		{
+			text: "pan 35 el 15/03",
+			want: Expense{Amount: 3500, Currency: "MXN", Notes: "pan", Date: time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	p := &Parser{Now: func() time.Time { return now }}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := p.Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.text, err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.text, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		want error
+	}{
+		{"", ErrNoAmount},
+		{"tacos #food ayer", ErrNoAmount},
+		{"0 nada", ErrInvalidAmount},
+		{"1.5 JPY ramen", ErrInvalidAmount},
+	}
+	p := &Parser{}
+	for _, tt := range tests {
+		if _, err := p.Parse(tt.text); !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	p := &Parser{Currency: "USD"}
+	got, err := p.Parse("4.5 coffee")
+	if err != nil || got.Currency != "USD" || got.Amount != 450 {
+		t.Errorf("Parse() = %+v, %v, want 450 USD", got, err)
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	mexico, err := time.LoadLocation("America/Mexico_City")
+	if err != nil {
+		t.Skip(err)
+	}
+	// 2024-03-20 03:00 UTC is still the 19th in Mexico City
+	sent := time.Date(2024, time.March, 20, 3, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		message telegram.Message
+		want    Expense
+	}{
+		{
+			name: "command",
+			message: telegram.Message{
+				Text: "/gasto@test_bot 23.50 tacos #Food #cena",
+				Entities: []telegram.MessageEntity{
+					{Type: telegram.EntityBotCommand, Offset: 0, Length: 15},
+					{Type: telegram.EntityHashtag, Offset: 28, Length: 5},
+					{Type: telegram.EntityHashtag, Offset: 34, Length: 5},
+				},
+			},
+			want: Expense{Amount: 2350, Currency: "MXN", Tags: []string{"food", "cena"}, Notes: "tacos", Date: time.Date(2024, time.March, 19, 0, 0, 0, 0, mexico)},
+		},
+		{
+			name:    "caption without entities",
+			message: telegram.Message{Caption: "80 #farmacia ayer"},
+			want:    Expense{Amount: 8000, Currency: "MXN", Date: time.Date(2024, time.March, 18, 0, 0, 0, 0, mexico)},
+		},
+	}
+	p := &Parser{Location: mexico, Now: func() time.Time { return time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC) }}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.message.Date = sent.Unix()
+			tt.message.Chat = &telegram.Chat{ID: -100}
+			tt.message.From = &telegram.User{ID: 7, FirstName: "Ana", LastName: "López"}
+			tt.want.ChatID, tt.want.UserID, tt.want.Payer = -100, 7, "Ana López"
+
+			got, err := p.ParseMessage(&tt.message)
+			if err != nil {
+				t.Fatalf("ParseMessage() error = %v", err)
+			}
+			if !got.Date.Equal(tt.want.Date) {
+				t.Errorf("ParseMessage() date = %s, want %s", got.Date, tt.want.Date)
+			}
+			got.Date = tt.want.Date
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseMessage() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package expenses
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/keyboard"
+)
+
+// Callback actions of the buttons sent with a receipt draft, their argument is the expense ID.
+const (
+	ActionConfirmDraft = "draft.ok"
+	ActionEditDraft    = "draft.edit"
+	ActionDiscardDraft = "draft.del"
+)
+
+// DraftKeyboard returns the buttons to confirm, edit or discard the draft id of userID,
+// signed so only that user can press them.
+func DraftKeyboard(codec *keyboard.CallbackCodec, userID int64, id string) *telegram.InlineKeyboardMarkup {
+	return keyboard.NewInline().
+		Row(
+			keyboard.Callback("✅ Confirmar", codec.MustEncode(userID, keyboard.NewCallbackData(ActionConfirmDraft, id))),
+			keyboard.Callback("✏️ Editar", codec.MustEncode(userID, keyboard.NewCallbackData(ActionEditDraft, id))),
+		).
+		Row(keyboard.Callback("🗑 Descartar", codec.MustEncode(userID, keyboard.NewCallbackData(ActionDiscardDraft, id)))).
+		Build()
+}
+
+// DraftTTL is how long a draft waits to be confirmed before it is removed.
+const DraftTTL = 48 * time.Hour
+
+// DraftID returns the ID of the draft of the receipt sent in the message
+// messageID of chatID on date, the day the message was sent. It is always the
+// same for a message, so a retried message finds the draft already created.
+// The draft keeps it even if the receipt has another date, Update moves the
+// expense to the ID of its date when it is confirmed.
+func DraftID(date time.Time, chatID, messageID int64) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%d/%d", chatID, messageID))
+	return dayKey(date) + "-" + hex.EncodeToString(sum[:8])
+}
+
+// ReceiptObjectKey returns the S3 key of the picture of the receipt of an
+// expense, e.g. "receipts/42/20261017-9f86d081884c7d65.jpg".
+func ReceiptObjectKey(userID int64, id, extension string) string {
+	return fmt.Sprintf("receipts/%d/%s%s", userID, id, extension)
+}
+
+// Receipt is the data read from the picture of a receipt. The fields not
+// found are empty.
+type Receipt struct {
+	Merchant string
+	// Total is in minor units of Currency.
+	Total    int64
+	Currency string
+	Date     time.Time
+	Items    []ReceiptItem
+}
+
+// ReceiptItem is a line of a receipt.
+type ReceiptItem struct {
+	Description string
+	// Price is in minor units of the currency of the receipt.
+	Price int64
+}
+
+// ReceiptExtractor reads the picture of a receipt stored in S3.
+type ReceiptExtractor interface {
+	Extract(ctx context.Context, bucket, key string) (*Receipt, error)
+}
+
+// Apply fills expense with the data of the receipt, the items become the notes.
+func (r *Receipt) Apply(expense *Expense) {
+	if r.Total > 0 {
+		expense.Amount = r.Total
+		expense.Currency = r.Currency
+	}
+	if r.Merchant != "" {
+		expense.Merchant = r.Merchant
+	}
+	if !r.Date.IsZero() {
+		expense.Date = r.Date
+	}
+	var items []string
+	for _, item := range r.Items {
+		if item.Description != "" {
+			items = append(items, item.Description)
+		}
+	}
+	if len(items) > 0 {
+		expense.Notes = strings.Join(items, ", ")
+	}
+}
+
+// StubExtractor is a ReceiptExtractor that reads nothing, it returns Receipt
+// for every picture. It is for tests and local runs without Textract.
+type StubExtractor struct {
+	Receipt Receipt
+}
+
+// Extract implements ReceiptExtractor.
+func (s *StubExtractor) Extract(ctx context.Context, bucket, key string) (*Receipt, error) {
+	receipt := s.Receipt
+	receipt.Items = append([]ReceiptItem(nil), s.Receipt.Items...)
+	return &receipt, nil
+}
@@ -0,0 +1,130 @@
+package expenses
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/textract"
+	"github.com/aws/aws-sdk-go-v2/service/textract/types"
+)
+
+// TextractAPI is the subset of the Textract client used by TextractExtractor.
+type TextractAPI interface {
+	AnalyzeExpense(ctx context.Context, params *textract.AnalyzeExpenseInput, optFns ...func(*textract.Options)) (*textract.AnalyzeExpenseOutput, error)
+}
+
+// receiptDateLayouts are the dates accepted on receipts, day first as printed in Mexico.
+var receiptDateLayouts = []string{"02/01/2006", "2/1/2006", "02/01/06", "2/1/06", "02-01-2006", "2006-01-02", "02.01.2006", "2 Jan 2006", "Jan 2, 2006"}
+
+// TextractExtractor is a ReceiptExtractor over the AnalyzeExpense API of Textract.
+type TextractExtractor struct {
+	client TextractAPI
+	// Currency is used when the receipt does not state one. Textract reads "$"
+	// as USD, so USD is also taken as Currency.
+	Currency string
+	// Location is where the dates of the receipts are.
+	Location *time.Location
+}
+
+// NewTextractExtractor creates a TextractExtractor with the DefaultCurrency and UTC dates.
+func NewTextractExtractor(client TextractAPI) *TextractExtractor {
+	return &TextractExtractor{client: client, Currency: DefaultCurrency, Location: time.UTC}
+}
+
+// Extract implements ReceiptExtractor. Textract needs s3:GetObject on the object.
+func (t *TextractExtractor) Extract(ctx context.Context, bucket, key string) (*Receipt, error) {
+	out, err := t.client.AnalyzeExpense(ctx, &textract.AnalyzeExpenseInput{
+		Document: &types.Document{S3Object: &types.S3Object{Bucket: aws.String(bucket), Name: aws.String(key)}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("analyzing receipt %s: %w", key, err)
+	}
+
+	receipt := &Receipt{Currency: t.Currency}
+	if len(out.ExpenseDocuments) == 0 {
+		return receipt, nil
+	}
+	document := out.ExpenseDocuments[0]
+
+	// The total is the first of these found, in order of preference
+	totals := map[string]int{"TOTAL": 1, "AMOUNT_PAID": 2, "AMOUNT_DUE": 3, "SUBTOTAL": 4}
+	totalRank := len(totals) + 1
+	for _, field := range document.SummaryFields {
+		fieldType, value := expenseFieldText(field)
+		switch {
+		case totals[fieldType] > 0 && totals[fieldType] < totalRank:
+			currency := receipt.Currency
+			if field.Currency != nil && field.Currency.Code != nil && *field.Currency.Code != "USD" {
+				currency = strings.ToUpper(*field.Currency.Code)
+			}
+			if amount, err := ParseAmount(cleanAmount(value), currency); err == nil {
+				receipt.Total, receipt.Currency, totalRank = amount, currency, totals[fieldType]
+			}
+		case fieldType == "VENDOR_NAME" && receipt.Merchant == "":
+			receipt.Merchant = strings.Join(strings.Fields(value), " ")
+		case fieldType == "INVOICE_RECEIPT_DATE" && receipt.Date.IsZero():
+			receipt.Date = t.parseDate(value)
+		}
+	}
+
+	for _, group := range document.LineItemGroups {
+		for _, line := range group.LineItems {
+			var item ReceiptItem
+			for _, field := range line.LineItemExpenseFields {
+				fieldType, value := expenseFieldText(field)
+				switch fieldType {
+				case "ITEM":
+					item.Description = strings.Join(strings.Fields(value), " ")
+				case "PRICE":
+					item.Price, _ = ParseAmount(cleanAmount(value), receipt.Currency)
+				}
+			}
+			if item.Description != "" || item.Price > 0 {
+				receipt.Items = append(receipt.Items, item)
+			}
+		}
+	}
+	return receipt, nil
+}
+
+// parseDate parses a receipt date at midnight in the Location, or returns zero.
+func (t *TextractExtractor) parseDate(text string) time.Time {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, ' '); i > 0 && strings.Contains(text[:i], "/") {
+		// Drop the time, e.g. "17/10/2026 14:32"
+		text = text[:i]
+	}
+	for _, layout := range receiptDateLayouts {
+		if date, err := time.ParseInLocation(layout, text, t.Location); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+func expenseFieldText(field types.ExpenseField) (string, string) {
+	var fieldType, value string
+	if field.Type != nil {
+		fieldType = aws.ToString(field.Type.Text)
+	}
+	if field.ValueDetection != nil {
+		value = aws.ToString(field.ValueDetection.Text)
+	}
+	return fieldType, value
+}
+
+// cleanAmount removes the currency and spaces of an amount, e.g. "MXN $ 1,234.50".
+func cleanAmount(text string) string {
+	return strings.TrimFunc(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, text), func(r rune) bool {
+		return !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,114 @@
+package reports
+
+import (
+	"slices"
+	"time"
+)
+
+// DefaultHour is the hour the reports are sent when the user does not choose one.
+const DefaultHour = 8
+
+// Preferences are the reports a user receives. The reports of the day are
+// sent at Hour, the weekly ones on Monday and the monthly ones on the 1st,
+// each about the period that just ended.
+type Preferences struct {
+	UserID int64 `json:"user_id"`
+	// ChatID is where the reports are sent.
+	ChatID      int64       `json:"chat_id"`
+	Frequencies []Frequency `json:"frequencies,omitempty"`
+	// Hour is the local hour, 0-23.
+	Hour int `json:"hour"`
+	// TimeZone is the IANA time zone of the user, e.g. "America/Mexico_City".
+	// The reports, and the periods of the budgets, are in it. Empty is the
+	// time zone of the bot.
+	TimeZone string `json:"time_zone,omitempty"`
+	// Version is used by the stores for optimistic locking, do not change it.
+	Version int64 `json:"version"`
+}
+
+// NewPreferences returns the preferences of a user without reports.
+func NewPreferences(userID int64) *Preferences {
+	return &Preferences{UserID: userID, Hour: DefaultHour}
+}
+
+// Location returns the location of TimeZone, or fallback when it is empty or unknown.
+func (p *Preferences) Location(fallback *time.Location) *time.Location {
+	if p.TimeZone == "" {
+		return fallback
+	}
+	location, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return fallback
+	}
+	return location
+}
+
+// Subscribed reports if the user receives the reports of frequency.
+func (p *Preferences) Subscribed(frequency Frequency) bool {
+	return slices.Contains(p.Frequencies, frequency)
+}
+
+// Set subscribes the user to the reports of frequency, or unsubscribes it.
+func (p *Preferences) Set(frequency Frequency, subscribed bool) {
+	p.Frequencies = slices.DeleteFunc(p.Frequencies, func(f Frequency) bool { return f == frequency })
+	if subscribed {
+		p.Frequencies = append(p.Frequencies, frequency)
+	}
+	// Keep them from the shortest, like Frequencies
+	slices.SortFunc(p.Frequencies, func(a, b Frequency) int {
+		return slices.Index(Frequencies, a) - slices.Index(Frequencies, b)
+	})
+}
+
+// SendHour returns the hour in UTC when the reports are sent on the day of t,
+// Hour in the time zone of the user, or in fallback when it has none. It
+// changes with the daylight saving time of the time zone.
+//
+// The reports are sent at the start of each hour in UTC, so in the time zones
+// with half hours, e.g. Asia/Kolkata, it is the first one inside Hour.
+func (p *Preferences) SendHour(t time.Time, fallback *time.Location) int {
+	location := p.Location(fallback)
+	year, month, day := t.In(location).Date()
+	start := time.Date(year, month, day, p.Hour, 0, 0, 0, location).UTC()
+	if start.Minute() > 0 || start.Second() > 0 {
+		return (start.Hour() + 1) % 24
+	}
+	return start.Hour()
+}
+
+// StandardSendHour returns SendHour on a day without daylight saving time in
+// the year of t. Unlike SendHour it does not change during the year, the
+// stores index it and look for the users in daylight saving time an hour away.
+func (p *Preferences) StandardSendHour(t time.Time, fallback *time.Location) int {
+	location := p.Location(fallback)
+	year := t.In(location).Year()
+	day := time.Date(year, time.January, 1, 12, 0, 0, 0, location)
+	if day.IsDST() {
+		// The southern hemisphere saves daylight in January
+		day = time.Date(year, time.July, 1, 12, 0, 0, 0, location)
+	}
+	return p.SendHour(day, fallback)
+}
+
+// DueAt returns the reports to send at t in the time zone of the user, or in
+// fallback when it has none. See Due.
+func (p *Preferences) DueAt(t time.Time, fallback *time.Location) []Frequency {
+	return p.Due(t.In(p.Location(fallback)))
+}
+
+// Due returns the reports to send at now, a time in the location of the user.
+func (p *Preferences) Due(now time.Time) []Frequency {
+	if now.Hour() != p.Hour {
+		return nil
+	}
+	var due []Frequency
+	for _, frequency := range p.Frequencies {
+		switch {
+		case frequency == Daily,
+			frequency == Weekly && now.Weekday() == time.Monday,
+			frequency == Monthly && now.Day() == 1:
+			due = append(due, frequency)
+		}
+	}
+	return due
+}
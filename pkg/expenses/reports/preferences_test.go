@@ -0,0 +1,132 @@
+package reports
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skip(err)
+	}
+	return location
+}
+
+func TestSendHour(t *testing.T) {
+	mexico := loadLocation(t, "America/Mexico_City")
+	tests := []struct {
+		name     string
+		timeZone string
+		hour     int
+		day      time.Time
+		want     int
+		standard int
+	}{
+		// Mexico City has no daylight saving time since 2022
+		{"fallback", "", 8, time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC), 14, 14},
+		{"winter in madrid", "Europe/Madrid", 8, time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC), 7, 7},
+		{"summer in madrid", "Europe/Madrid", 8, time.Date(2024, time.July, 15, 12, 0, 0, 0, time.UTC), 6, 7},
+		// Europe changes the clocks on the last Sunday of March
+		{"before the change in madrid", "Europe/Madrid", 8, time.Date(2024, time.March, 30, 12, 0, 0, 0, time.UTC), 7, 7},
+		{"after the change in madrid", "Europe/Madrid", 8, time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC), 6, 7},
+		// The United States changes them on the second Sunday of March
+		{"before the change in new york", "America/New_York", 20, time.Date(2024, time.March, 9, 12, 0, 0, 0, time.UTC), 1, 1},
+		{"after the change in new york", "America/New_York", 20, time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC), 0, 1},
+		// The southern hemisphere saves daylight in January
+		{"summer in sydney", "Australia/Sydney", 8, time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC), 21, 22},
+		{"winter in sydney", "Australia/Sydney", 8, time.Date(2024, time.July, 15, 12, 0, 0, 0, time.UTC), 22, 22},
+		// 8:00 in Kolkata is 2:30 UTC, the first run inside it is at 3:00 UTC
+		{"half hour", "Asia/Kolkata", 8, time.Date(2024, time.July, 15, 12, 0, 0, 0, time.UTC), 3, 3},
+		{"quarter hour", "Asia/Kathmandu", 0, time.Date(2024, time.July, 15, 12, 0, 0, 0, time.UTC), 19, 19},
+		{"unknown time zone", "Mars/Olympus", 0, time.Date(2024, time.July, 15, 12, 0, 0, 0, time.UTC), 6, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preferences := &Preferences{Hour: tt.hour, TimeZone: tt.timeZone}
+			if got := preferences.SendHour(tt.day, mexico); got != tt.want {
+				t.Errorf("SendHour(%s) = %d, want %d", tt.day, got, tt.want)
+			}
+			if got := preferences.StandardSendHour(tt.day, mexico); got != tt.standard {
+				t.Errorf("StandardSendHour(%s) = %d, want %d", tt.day, got, tt.standard)
+			}
+		})
+	}
+}
+
+func TestDue(t *testing.T) {
+	mexico := loadLocation(t, "America/Mexico_City")
+	preferences := &Preferences{Frequencies: []Frequency{Daily, Weekly, Monthly}, Hour: 8}
+	tests := []struct {
+		name string
+		now  time.Time
+		want []Frequency
+	}{
+		// Monday, April 1st
+		{"all", time.Date(2024, time.April, 1, 8, 0, 0, 0, mexico), []Frequency{Daily, Weekly, Monthly}},
+		{"late in the hour", time.Date(2024, time.April, 1, 8, 59, 0, 0, mexico), []Frequency{Daily, Weekly, Monthly}},
+		{"monday", time.Date(2024, time.April, 8, 8, 0, 0, 0, mexico), []Frequency{Daily, Weekly}},
+		{"first of the month", time.Date(2024, time.May, 1, 8, 0, 0, 0, mexico), []Frequency{Daily, Monthly}},
+		{"another day", time.Date(2024, time.April, 3, 8, 0, 0, 0, mexico), []Frequency{Daily}},
+		{"another hour", time.Date(2024, time.April, 1, 9, 0, 0, 0, mexico), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := preferences.Due(tt.now); !slices.Equal(got, tt.want) {
+				t.Errorf("Due(%s) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+	if got := (&Preferences{Hour: 8}).Due(time.Date(2024, time.April, 1, 8, 0, 0, 0, mexico)); got != nil {
+		t.Errorf("Due() without reports = %v, want none", got)
+	}
+}
+
+func TestDueAt(t *testing.T) {
+	mexico := loadLocation(t, "America/Mexico_City")
+	tests := []struct {
+		name     string
+		timeZone string
+		at       time.Time
+		want     []Frequency
+	}{
+		// 14:00 UTC on Monday, April 1st is 8:00 in Mexico City
+		{"fallback", "", time.Date(2024, time.April, 1, 14, 0, 0, 0, time.UTC), []Frequency{Daily, Weekly, Monthly}},
+		{"fallback at another hour", "", time.Date(2024, time.April, 1, 8, 0, 0, 0, time.UTC), nil},
+		// 23:00 UTC on Sunday is 8:00 on Monday in Tokyo
+		{"tokyo", "Asia/Tokyo", time.Date(2024, time.March, 31, 23, 0, 0, 0, time.UTC), []Frequency{Daily, Weekly, Monthly}},
+		// 8:00 in Madrid is 7:00 UTC in winter and 6:00 UTC in summer
+		{"madrid in winter", "Europe/Madrid", time.Date(2024, time.January, 1, 7, 0, 0, 0, time.UTC), []Frequency{Daily, Weekly, Monthly}},
+		{"madrid in summer", "Europe/Madrid", time.Date(2024, time.July, 1, 6, 0, 0, 0, time.UTC), []Frequency{Daily, Weekly, Monthly}},
+		{"madrid in summer at the winter hour", "Europe/Madrid", time.Date(2024, time.July, 1, 7, 0, 0, 0, time.UTC), nil},
+		{"kolkata", "Asia/Kolkata", time.Date(2024, time.July, 1, 3, 0, 0, 0, time.UTC), []Frequency{Daily, Weekly, Monthly}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preferences := &Preferences{Frequencies: []Frequency{Daily, Weekly, Monthly}, Hour: 8, TimeZone: tt.timeZone}
+			if got := preferences.DueAt(tt.at, mexico); !slices.Equal(got, tt.want) {
+				t.Errorf("DueAt(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+			// The reports are due at the send hour of the day
+			if tt.want != nil && preferences.SendHour(tt.at, mexico) != tt.at.Hour() {
+				t.Errorf("SendHour(%s) = %d, want %d", tt.at, preferences.SendHour(tt.at, mexico), tt.at.Hour())
+			}
+		})
+	}
+}
+
+func TestPreferencesSet(t *testing.T) {
+	preferences := NewPreferences(1)
+	preferences.Set(Monthly, true)
+	preferences.Set(Daily, true)
+	preferences.Set(Monthly, true)
+	if want := []Frequency{Daily, Monthly}; !slices.Equal(preferences.Frequencies, want) {
+		t.Errorf("Frequencies = %v, want %v", preferences.Frequencies, want)
+	}
+	preferences.Set(Daily, false)
+	if !preferences.Subscribed(Monthly) || preferences.Subscribed(Daily) {
+		t.Errorf("Frequencies after unsubscribing = %v, want [monthly]", preferences.Frequencies)
+	}
+}
@@ -0,0 +1,213 @@
+// Package reports summarizes the expenses of a user by day, week or month,
+// with the totals per category, the top merchants and the comparison with
+// the period before, for the users who opted in to receive them.
+//
+//	scheduled, err := store.Scheduled(ctx, now)
+//	...
+//	for _, preferences := range scheduled {
+//		local := now.In(preferences.Location(fallback))
+//		for _, frequency := range preferences.Due(local) {
+//			summary, err := reports.Build(ctx, repository, preferences.UserID, frequency, local)
+//			...
+//			send(preferences.ChatID, summary.Text())
+//		}
+//	}
+package reports
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+)
+
+// Frequency is how often a report is sent.
+type Frequency string
+
+// Frequencies of the reports. The weeks start on Monday.
+const (
+	Daily   Frequency = "daily"
+	Weekly  Frequency = "weekly"
+	Monthly Frequency = "monthly"
+)
+
+// Frequencies are all the frequencies, from the shortest.
+var Frequencies = []Frequency{Daily, Weekly, Monthly}
+
+// frequencyWords are the names of the frequencies the users can write.
+var frequencyWords = map[string]Frequency{
+	"daily": Daily, "diario": Daily, "diaria": Daily,
+	"weekly": Weekly, "semanal": Weekly,
+	"monthly": Monthly, "mensual": Monthly,
+}
+
+// ParseFrequency parses the name of a frequency in Spanish or English, e.g. "semanal" or "daily".
+func ParseFrequency(text string) (Frequency, bool) {
+	frequency, ok := frequencyWords[strings.ToLower(strings.TrimSpace(text))]
+	return frequency, ok
+}
+
+// Name returns the frequency in Spanish, e.g. "semanal".
+func (f Frequency) Name() string {
+	switch f {
+	case Daily:
+		return "diario"
+	case Weekly:
+		return "semanal"
+	}
+	return "mensual"
+}
+
+// Bounds returns the first day of the last complete period before now and
+// the first day after it, e.g. yesterday for Daily. The days start at
+// midnight in the location of now.
+func (f Frequency) Bounds(now time.Time) (from, to time.Time) {
+	year, month, day := now.Date()
+	switch f {
+	case Daily:
+		to = time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+		return to.AddDate(0, 0, -1), to
+	case Weekly:
+		// time.Weekday starts on Sunday
+		offset := (int(now.Weekday()) + 6) % 7
+		to = time.Date(year, month, day-offset, 0, 0, 0, 0, now.Location())
+		return to.AddDate(0, 0, -7), to
+	}
+	to = time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+	return to.AddDate(0, -1, 0), to
+}
+
+// MaxMerchants is how many merchants a summary lists.
+const MaxMerchants = 5
+
+// Amount is the total of a group of expenses.
+type Amount struct {
+	Name  string
+	Total int64
+}
+
+// Summary is the report of the expenses of a user in a period.
+type Summary struct {
+	Frequency Frequency
+	// From is the first day of the period and To the first day after it.
+	From, To time.Time
+	// Totals are in minor units of Currency, only the expenses in Currency count.
+	Currency string
+	Total    int64
+	Count    int
+	// Previous is the total of the period before.
+	Previous int64
+	// Categories are sorted from the greatest total.
+	Categories []Amount
+	// Merchants are the MaxMerchants with the greatest total.
+	Merchants []Amount
+}
+
+// Build summarizes the expenses of userID in the last complete period of
+// frequency before now, in expenses.DefaultCurrency.
+func Build(ctx context.Context, repository expenses.Repository, userID int64, frequency Frequency, now time.Time) (*Summary, error) {
+	from, to := frequency.Bounds(now)
+	previousFrom, _ := frequency.Bounds(from)
+	list, err := repository.List(ctx, userID, expenses.Query{From: previousFrom, To: to})
+	if err != nil {
+		return nil, err
+	}
+	summary := &Summary{Frequency: frequency, From: from, To: to, Currency: expenses.DefaultCurrency}
+	summary.add(list)
+	return summary, nil
+}
+
+// add sums the confirmed expenses in Currency, those before From count as the period before.
+func (s *Summary) add(list []*expenses.Expense) {
+	categories := make(map[string]int64)
+	merchants := make(map[string]int64)
+	from := s.From.Format("20060102")
+	for _, expense := range list {
+		if !counts(expense, s.Currency) {
+			continue
+		}
+		if expense.Date.Format("20060102") < from {
+			s.Previous += expense.Amount
+			continue
+		}
+		s.Total += expense.Amount
+		s.Count++
+		categories[categoryOf(expense.Category)] += expense.Amount
+		if expense.Merchant != "" {
+			merchants[expense.Merchant] += expense.Amount
+		}
+	}
+	s.Categories = sortedAmounts(categories)
+	s.Merchants = sortedAmounts(merchants)
+	if len(s.Merchants) > MaxMerchants {
+		s.Merchants = s.Merchants[:MaxMerchants]
+	}
+}
+
+// sortedAmounts returns the totals from the greatest, by name when they tie.
+func sortedAmounts(totals map[string]int64) []Amount {
+	amounts := make([]Amount, 0, len(totals))
+	for name, total := range totals {
+		amounts = append(amounts, Amount{Name: name, Total: total})
+	}
+	slices.SortFunc(amounts, func(a, b Amount) int {
+		return cmp.Or(cmp.Compare(b.Total, a.Total), strings.Compare(a.Name, b.Name))
+	})
+	return amounts
+}
+
+// Text returns the summary in Spanish, to send it to the user.
+func (s *Summary) Text() string {
+	var lines []string
+	switch s.Frequency {
+	case Daily:
+		lines = append(lines, "📊 Resumen del "+s.From.Format("02/01/2006"))
+	default:
+		lines = append(lines, fmt.Sprintf("📊 Resumen %s del %s al %s", s.Frequency.Name(),
+			s.From.Format("02/01/2006"), s.To.AddDate(0, 0, -1).Format("02/01/2006")))
+	}
+	switch s.Count {
+	case 0:
+		lines = append(lines, "No registraste gastos.")
+	case 1:
+		lines = append(lines, fmt.Sprintf("Total: %s en 1 gasto", expenses.FormatAmount(s.Total, s.Currency)))
+	default:
+		lines = append(lines, fmt.Sprintf("Total: %s en %d gastos", expenses.FormatAmount(s.Total, s.Currency), s.Count))
+	}
+	lines = append(lines, s.comparison())
+
+	if len(s.Categories) > 0 {
+		lines = append(lines, "", "Por categoría:")
+		for _, category := range s.Categories {
+			lines = append(lines, fmt.Sprintf("• %s: %s (%d%%)", category.Name,
+				expenses.FormatAmount(category.Total, s.Currency), category.Total*100/s.Total))
+		}
+	}
+	if len(s.Merchants) > 0 {
+		lines = append(lines, "", "Principales comercios:")
+		for _, merchant := range s.Merchants {
+			lines = append(lines, fmt.Sprintf("• %s: %s", merchant.Name, expenses.FormatAmount(merchant.Total, s.Currency)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// comparison compares the total with the period before.
+func (s *Summary) comparison() string {
+	previous := expenses.FormatAmount(s.Previous, s.Currency)
+	switch {
+	case s.Previous == 0 && s.Total == 0:
+		return "Tampoco hubo gastos en el periodo anterior."
+	case s.Previous == 0:
+		return "No hubo gastos en el periodo anterior."
+	case s.Total > s.Previous:
+		return fmt.Sprintf("📈 %d%% más que el periodo anterior (%s)", (s.Total-s.Previous)*100/s.Previous, previous)
+	case s.Total < s.Previous:
+		return fmt.Sprintf("📉 %d%% menos que el periodo anterior (%s)", (s.Previous-s.Total)*100/s.Previous, previous)
+	}
+	return fmt.Sprintf("Igual que el periodo anterior (%s)", previous)
+}
@@ -0,0 +1,139 @@
+package reports
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+)
+
+func TestFrequencyBounds(t *testing.T) {
+	madrid := loadLocation(t, "Europe/Madrid")
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, madrid)
+	}
+	tests := []struct {
+		name      string
+		frequency Frequency
+		now       time.Time
+		from, to  time.Time
+	}{
+		{"daily", Daily, time.Date(2024, time.March, 20, 8, 0, 0, 0, madrid), day(2024, time.March, 19), day(2024, time.March, 20)},
+		{"daily on the first", Daily, time.Date(2024, time.March, 1, 8, 0, 0, 0, madrid), day(2024, time.February, 29), day(2024, time.March, 1)},
+		// The day the clocks change has 23 hours
+		{"daily after the change", Daily, time.Date(2024, time.April, 1, 8, 0, 0, 0, madrid), day(2024, time.March, 31), day(2024, time.April, 1)},
+		{"weekly on monday", Weekly, time.Date(2024, time.April, 1, 8, 0, 0, 0, madrid), day(2024, time.March, 25), day(2024, time.April, 1)},
+		{"weekly on sunday", Weekly, time.Date(2024, time.March, 31, 8, 0, 0, 0, madrid), day(2024, time.March, 18), day(2024, time.March, 25)},
+		{"monthly", Monthly, time.Date(2024, time.April, 1, 8, 0, 0, 0, madrid), day(2024, time.March, 1), day(2024, time.April, 1)},
+		{"monthly in january", Monthly, time.Date(2024, time.January, 1, 8, 0, 0, 0, madrid), day(2023, time.December, 1), day(2024, time.January, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := tt.frequency.Bounds(tt.now)
+			if !from.Equal(tt.from) || !to.Equal(tt.to) {
+				t.Errorf("Bounds(%s) = %s, %s, want %s, %s", tt.now, from, to, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	mexico := loadLocation(t, "America/Mexico_City")
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, mexico)
+	}
+	repository := expenses.NewMemoryRepository()
+	ctx := context.Background()
+	for _, expense := range []*expenses.Expense{
+		// Before the period before
+		{Amount: 100, Category: "comida", Date: day(time.January, 31)},
+		// The period before
+		{Amount: 1000, Category: "comida", Date: day(time.February, 10)},
+		{Amount: 2000, Category: "comida", Merchant: "Oxxo", Date: day(time.March, 1)},
+		{Amount: 3000, Category: "casa", Merchant: "Home Depot", Date: day(time.March, 15)},
+		{Amount: 500, Merchant: "Oxxo", Date: day(time.March, 31)},
+		// Another currency, a draft and the day of the report do not count
+		{Amount: 9999, Currency: "USD", Category: "comida", Date: day(time.March, 15)},
+		{Amount: 9999, Category: "comida", Date: day(time.March, 20), ExpiresAt: time.Now().Add(time.Hour)},
+		{Amount: 700, Category: "comida", Date: day(time.April, 1)},
+	} {
+		expense.UserID = 1
+		if expense.Currency == "" {
+			expense.Currency = expenses.DefaultCurrency
+		}
+		if err := repository.Create(ctx, expense); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	summary, err := Build(ctx, repository, 1, Monthly, time.Date(2024, time.April, 1, 8, 0, 0, 0, mexico))
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if !summary.From.Equal(day(time.March, 1)) || !summary.To.Equal(day(time.April, 1)) {
+		t.Errorf("period = %s - %s, want March", summary.From, summary.To)
+	}
+	if summary.Total != 5500 || summary.Count != 3 || summary.Previous != 1000 {
+		t.Errorf("Total, Count, Previous = %d, %d, %d, want 5500, 3, 1000", summary.Total, summary.Count, summary.Previous)
+	}
+	if want := []Amount{{"casa", 3000}, {"comida", 2000}, {Uncategorized, 500}}; !reflect.DeepEqual(summary.Categories, want) {
+		t.Errorf("Categories = %v, want %v", summary.Categories, want)
+	}
+	if want := []Amount{{"Home Depot", 3000}, {"Oxxo", 2500}}; !reflect.DeepEqual(summary.Merchants, want) {
+		t.Errorf("Merchants = %v, want %v", summary.Merchants, want)
+	}
+	text := summary.Text()
+	for _, line := range []string{
+		"📊 Resumen mensual del 01/03/2024 al 31/03/2024",
+		"Total: $55.00 MXN en 3 gastos",
+		"📈 450% más que el periodo anterior ($10.00 MXN)",
+		"• casa: $30.00 MXN (54%)",
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("Text() = %q, want the line %q", text, line)
+		}
+	}
+
+	// Nothing in the last day
+	summary, err = Build(ctx, repository, 1, Daily, time.Date(2024, time.March, 17, 8, 0, 0, 0, mexico))
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if summary.Total != 0 || summary.Previous != 3000 || len(summary.Categories) != 0 {
+		t.Errorf("Build(daily) = %+v, want only the day before", summary)
+	}
+}
+
+func TestTotals(t *testing.T) {
+	expense := func(amount int64, currency, category string, date time.Time) *expenses.Expense {
+		return &expenses.Expense{Amount: amount, Currency: currency, Category: category, Date: date}
+	}
+	draft := expense(9999, "MXN", "comida", time.Date(2024, time.January, 5, 0, 0, 0, 0, time.UTC))
+	draft.ExpiresAt = draft.Date
+	list := []*expenses.Expense{
+		expense(100, "MXN", "comida", time.Date(2023, time.November, 30, 0, 0, 0, 0, time.UTC)),
+		expense(200, "MXN", "comida", time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC)),
+		expense(300, "mxn", "", time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC)),
+		expense(400, "MXN", "casa", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)),
+		expense(500, "USD", "casa", time.Date(2024, time.January, 5, 0, 0, 0, 0, time.UTC)),
+		expense(600, "MXN", "casa", time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)),
+		draft,
+	}
+
+	// The ties are sorted by name
+	want := []Amount{{"casa", 1000}, {Uncategorized, 300}, {"comida", 300}}
+	if got := ByCategory(list, "MXN"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ByCategory() = %v, want %v", got, want)
+	}
+	// From December to February, across the year
+	if got := ByMonth(list, "MXN", time.Date(2023, time.December, 15, 0, 0, 0, 0, time.UTC), 3); !slices.Equal(got, []int64{500, 0, 400}) {
+		t.Errorf("ByMonth() = %v, want [500 0 400]", got)
+	}
+	if got := ByMonth(nil, "MXN", time.Now(), 2); !slices.Equal(got, []int64{0, 0}) {
+		t.Errorf("ByMonth(nil) = %v, want [0 0]", got)
+	}
+}
@@ -0,0 +1,97 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrConflict is returned by Store.Save when the preferences were changed since they were loaded.
+var ErrConflict = errors.New("reports: preferences changed concurrently")
+
+// Store persists the report preferences of the users.
+type Store interface {
+	// Load returns the preferences of userID, or NewPreferences if it has none.
+	Load(ctx context.Context, userID int64) (*Preferences, error)
+	// Save stores preferences if their Version matches the stored one, and increments it.
+	Save(ctx context.Context, preferences *Preferences) error
+	// Scheduled returns the preferences of the users subscribed to any report
+	// sent around t: at least those whose Hour, in their time zone, is the
+	// hour of t. The caller checks which reports are due with DueAt.
+	Scheduled(ctx context.Context, t time.Time) ([]*Preferences, error)
+}
+
+// MemoryStore is a Store for tests and local runs, the preferences are lost on restart.
+type MemoryStore struct {
+	mu          sync.Mutex
+	preferences map[int64]Preferences
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{preferences: make(map[int64]Preferences)}
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(ctx context.Context, userID int64) (*Preferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	preferences, ok := s.preferences[userID]
+	if !ok {
+		return NewPreferences(userID), nil
+	}
+	return copyPreferences(preferences), nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(ctx context.Context, preferences *Preferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.preferences[preferences.UserID].Version != preferences.Version {
+		return ErrConflict
+	}
+	preferences.Version++
+	s.preferences[preferences.UserID] = *copyPreferences(*preferences)
+	return nil
+}
+
+// Scheduled implements Store, it returns every subscribed user.
+func (s *MemoryStore) Scheduled(ctx context.Context, t time.Time) ([]*Preferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var subscribed []*Preferences
+	for _, preferences := range s.preferences {
+		if len(preferences.Frequencies) > 0 {
+			subscribed = append(subscribed, copyPreferences(preferences))
+		}
+	}
+	sort.Slice(subscribed, func(i, j int) bool { return subscribed[i].UserID < subscribed[j].UserID })
+	return subscribed, nil
+}
+
+// Update loads the preferences of userID, changes them with update and saves
+// them, loading them again when they were changed concurrently.
+func Update(ctx context.Context, store Store, userID int64, update func(preferences *Preferences) error) (*Preferences, error) {
+	for attempt := 0; ; attempt++ {
+		preferences, err := store.Load(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := update(preferences); err != nil {
+			return nil, err
+		}
+		err = store.Save(ctx, preferences)
+		if !errors.Is(err, ErrConflict) || attempt == 2 {
+			return preferences, err
+		}
+	}
+}
+
+// copyPreferences copies preferences without sharing their frequencies.
+func copyPreferences(preferences Preferences) *Preferences {
+	preferences.Frequencies = slices.Clone(preferences.Frequencies)
+	return &preferences
+}
@@ -0,0 +1,197 @@
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by DynamoDBStore.
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+// ReportHourIndex is the global secondary index of the settings table over
+// "report_hour", the Preferences.StandardSendHour of the user. Only the items
+// of the subscribed users have it.
+const ReportHourIndex = "report_hour"
+
+// daylightShifts are how many hours the send hour of a user in daylight
+// saving time is before its StandardSendHour: one, or minus one in the time
+// zones whose standard time is the summer one, e.g. Europe/Dublin.
+var daylightShifts = []int{0, 1, -1}
+
+// DynamoDBStore is a Store over the settings table of the users, a DynamoDB
+// table with a string partition key "pk" and the index ReportHourIndex.
+//
+// The preferences are in the item of the user, with the key "USER#<id>", as
+// JSON in "reports" and saved with optimistic locking on "reports_version".
+// The other attributes of the item are left as they are.
+type DynamoDBStore struct {
+	client    DynamoDBAPI
+	tableName string
+	location  *time.Location
+	now       func() time.Time
+}
+
+// NewDynamoDBStore creates a DynamoDBStore over tableName. location is the
+// time zone of the users without one.
+func NewDynamoDBStore(client DynamoDBAPI, tableName string, location *time.Location) *DynamoDBStore {
+	return &DynamoDBStore{client: client, tableName: tableName, location: location, now: time.Now}
+}
+
+// CreateTableInput returns the definition of the settings table, the same as
+// the one of the infrastructure. It is meant for DynamoDB Local.
+func CreateTableInput(tableName string) *dynamodb.CreateTableInput {
+	return &dynamodb.CreateTableInput{
+		TableName: aws.String(tableName),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("report_hour"), AttributeType: types.ScalarAttributeTypeN},
+		},
+		KeySchema: []types.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash}},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{{
+			IndexName: aws.String(ReportHourIndex),
+			KeySchema: []types.KeySchemaElement{{AttributeName: aws.String("report_hour"), KeyType: types.KeyTypeHash}},
+			Projection: &types.Projection{
+				ProjectionType:   types.ProjectionTypeInclude,
+				NonKeyAttributes: []string{"reports", "reports_version"},
+			},
+		}},
+		BillingMode: types.BillingModePayPerRequest,
+	}
+}
+
+// Load implements Store.
+func (s *DynamoDBStore) Load(ctx context.Context, userID int64) (*Preferences, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:            aws.String(s.tableName),
+		Key:                  itemKey(userID),
+		ConsistentRead:       aws.Bool(true),
+		ProjectionExpression: aws.String("reports, reports_version"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading report preferences of %d: %w", userID, err)
+	}
+	if _, ok := out.Item["reports"]; !ok {
+		return NewPreferences(userID), nil
+	}
+	preferences, err := decodePreferences(out.Item)
+	if err != nil {
+		return nil, fmt.Errorf("loading report preferences of %d: %w", userID, err)
+	}
+	return preferences, nil
+}
+
+// Save implements Store.
+func (s *DynamoDBStore) Save(ctx context.Context, preferences *Preferences) error {
+	version := preferences.Version
+	preferences.Version = version + 1
+	encoded, err := json.Marshal(preferences)
+	if err != nil {
+		preferences.Version = version
+		return fmt.Errorf("saving report preferences of %d: %w", preferences.UserID, err)
+	}
+
+	condition := "attribute_not_exists(reports_version)"
+	values := map[string]types.AttributeValue{
+		":reports": &types.AttributeValueMemberS{Value: string(encoded)},
+		":next":    numberValue(version + 1),
+	}
+	if version > 0 {
+		condition = "reports_version = :version"
+		values[":version"] = numberValue(version)
+	}
+	// Without reports the user leaves the index
+	update := "SET reports = :reports, reports_version = :next REMOVE report_hour"
+	if len(preferences.Frequencies) > 0 {
+		update = "SET reports = :reports, reports_version = :next, report_hour = :hour"
+		values[":hour"] = numberValue(int64(preferences.StandardSendHour(s.now(), s.location)))
+	}
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.tableName),
+		Key:                       itemKey(preferences.UserID),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		preferences.Version = version
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return ErrConflict
+		}
+		return fmt.Errorf("saving report preferences of %d: %w", preferences.UserID, err)
+	}
+	return nil
+}
+
+// Scheduled implements Store. It queries ReportHourIndex for the hour of t,
+// for the users in standard time, and for the hours daylightShifts away, for
+// those in daylight saving time. The index does not depend on the day the
+// preferences were saved, so it does not go stale when the clocks change.
+func (s *DynamoDBStore) Scheduled(ctx context.Context, t time.Time) ([]*Preferences, error) {
+	var scheduled []*Preferences
+	hour := t.UTC().Hour()
+	for _, shift := range daylightShifts {
+		paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+			TableName:                 aws.String(s.tableName),
+			IndexName:                 aws.String(ReportHourIndex),
+			KeyConditionExpression:    aws.String("report_hour = :hour"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{":hour": numberValue(int64((hour + shift + 24) % 24))},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("listing report preferences: %w", err)
+			}
+			for _, item := range page.Items {
+				preferences, err := decodePreferences(item)
+				if err != nil {
+					return nil, fmt.Errorf("listing report preferences: %w", err)
+				}
+				if len(preferences.Frequencies) > 0 {
+					scheduled = append(scheduled, preferences)
+				}
+			}
+		}
+	}
+	return scheduled, nil
+}
+
+func decodePreferences(item map[string]types.AttributeValue) (*Preferences, error) {
+	encoded, ok := item["reports"].(*types.AttributeValueMemberS)
+	if !ok {
+		return nil, errors.New("reports attribute is missing")
+	}
+	var preferences Preferences
+	if err := json.Unmarshal([]byte(encoded.Value), &preferences); err != nil {
+		return nil, err
+	}
+	version, ok := item["reports_version"].(*types.AttributeValueMemberN)
+	if !ok {
+		return nil, errors.New("reports_version attribute is missing")
+	}
+	var err error
+	if preferences.Version, err = strconv.ParseInt(version.Value, 10, 64); err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+func itemKey(userID int64) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "USER#" + strconv.FormatInt(userID, 10)}}
+}
+
+func numberValue(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
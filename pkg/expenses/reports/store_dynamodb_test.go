@@ -0,0 +1,86 @@
+package reports
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/dynamotest"
+)
+
+func TestDynamoDBStoreScheduled(t *testing.T) {
+	client := dynamotest.Client(t)
+	mexico, _ := time.LoadLocation("America/Mexico_City")
+	store := NewDynamoDBStore(client, dynamotest.NewTable(t, client, CreateTableInput("")), mexico)
+	store.now = func() time.Time { return time.Date(2024, time.March, 20, 12, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	for _, preferences := range []*Preferences{
+		// 8:00 in Mexico City is 14:00 UTC
+		{UserID: 1, ChatID: 1, Frequencies: []Frequency{Daily}, Hour: 8},
+		// 23:00 in Tokyo is 14:00 UTC
+		{UserID: 2, ChatID: 2, Frequencies: []Frequency{Weekly}, Hour: 23, TimeZone: "Asia/Tokyo"},
+		{UserID: 3, ChatID: 3, Frequencies: []Frequency{Daily}, Hour: 20},
+		{UserID: 4, ChatID: 4, Hour: 8},
+	} {
+		if err := store.Save(ctx, preferences); err != nil {
+			t.Fatalf("Save(%d) error = %v", preferences.UserID, err)
+		}
+	}
+
+	users := func(at time.Time) []int64 {
+		t.Helper()
+		scheduled, err := store.Scheduled(ctx, at)
+		if err != nil {
+			t.Fatalf("Scheduled() error = %v", err)
+		}
+		var ids []int64
+		for _, preferences := range scheduled {
+			ids = append(ids, preferences.UserID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	if got := users(time.Date(2024, time.March, 21, 14, 0, 0, 0, time.UTC)); !slices.Equal(got, []int64{1, 2}) {
+		t.Errorf("Scheduled() at 14:00 UTC = %v, want [1 2]", got)
+	}
+	// An hour away is included for the changes of the daylight saving time
+	if got := users(time.Date(2024, time.March, 21, 15, 0, 0, 0, time.UTC)); !slices.Equal(got, []int64{1, 2}) {
+		t.Errorf("Scheduled() at 15:00 UTC = %v, want [1 2]", got)
+	}
+	if got := users(time.Date(2024, time.March, 21, 18, 0, 0, 0, time.UTC)); len(got) != 0 {
+		t.Errorf("Scheduled() at 18:00 UTC = %v, want none", got)
+	}
+
+	// The index does not change with the daylight saving time: 8:00 in Madrid
+	// is 7:00 UTC in winter and 6:00 UTC in summer, whenever it was saved
+	for _, now := range []time.Time{
+		time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC),
+		time.Date(2024, time.July, 15, 12, 0, 0, 0, time.UTC),
+	} {
+		store.now = func() time.Time { return now }
+		preferences := &Preferences{UserID: 10 + int64(now.Month()), ChatID: 5, Frequencies: []Frequency{Daily}, Hour: 8, TimeZone: "Europe/Madrid"}
+		if err := store.Save(ctx, preferences); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	for _, at := range []time.Time{
+		time.Date(2024, time.February, 1, 7, 0, 0, 0, time.UTC),
+		time.Date(2024, time.August, 1, 6, 0, 0, 0, time.UTC),
+	} {
+		if got := users(at); !slices.Equal(got, []int64{11, 17}) {
+			t.Errorf("Scheduled() at %s = %v, want [11 17]", at, got)
+		}
+	}
+
+	// Unsubscribing removes the user from the index
+	preferences, _ := store.Load(ctx, 1)
+	preferences.Set(Daily, false)
+	if err := store.Save(ctx, preferences); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if got := users(time.Date(2024, time.March, 21, 14, 0, 0, 0, time.UTC)); !slices.Equal(got, []int64{2}) {
+		t.Errorf("Scheduled() after unsubscribing = %v, want [2]", got)
+	}
+}
@@ -0,0 +1,52 @@
+package reports
+
+import (
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/expenses"
+)
+
+// Uncategorized names the expenses without category in the totals.
+const Uncategorized = "Sin categoría"
+
+// counts reports if expense adds to the totals in currency: only the confirmed expenses do.
+func counts(expense *expenses.Expense, currency string) bool {
+	return !expense.IsDraft() && strings.EqualFold(expense.Currency, currency)
+}
+
+// ByCategory returns the totals per category of the expenses of list in
+// currency, from the greatest.
+func ByCategory(list []*expenses.Expense, currency string) []Amount {
+	totals := make(map[string]int64)
+	for _, expense := range list {
+		if counts(expense, currency) {
+			totals[categoryOf(expense.Category)] += expense.Amount
+		}
+	}
+	return sortedAmounts(totals)
+}
+
+// ByMonth returns the totals of the expenses of list in currency in each of
+// the months months from the month of from, in order.
+func ByMonth(list []*expenses.Expense, currency string, from time.Time, months int) []int64 {
+	totals := make([]int64, months)
+	for _, expense := range list {
+		if !counts(expense, currency) {
+			continue
+		}
+		month := (expense.Date.Year()-from.Year())*12 + int(expense.Date.Month()-from.Month())
+		if month >= 0 && month < months {
+			totals[month] += expense.Amount
+		}
+	}
+	return totals
+}
+
+// categoryOf returns the category of the totals of an expense, Uncategorized if it has none.
+func categoryOf(category string) string {
+	if category == "" {
+		return Uncategorized
+	}
+	return category
+}
@@ -0,0 +1,211 @@
+package expenses
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxTags is the maximum number of tags of an expense.
+const MaxTags = 20
+
+var (
+	// ErrNotFound is returned when the expense does not exist.
+	ErrNotFound = errors.New("expenses: expense not found")
+	// ErrConflict is returned by Repository.Update and Repository.Delete when the
+	// expense was changed since it was loaded.
+	ErrConflict = errors.New("expenses: expense changed concurrently")
+)
+
+// Query selects the expenses of a user.
+type Query struct {
+	// From is the first day included, zero for no lower bound.
+	From time.Time
+	// To is the first day excluded, zero for no upper bound.
+	To time.Time
+	// Category selects the expenses of a category, ignoring the case.
+	Category string
+	// Tag selects the expenses with a tag.
+	Tag string
+	// Limit is the maximum number of expenses returned, zero means no limit.
+	Limit int
+	// Descending returns the newest expenses first.
+	Descending bool
+}
+
+// Matches reports if expense is selected by the query.
+func (q Query) Matches(expense *Expense) bool {
+	day := dayKey(expense.Date)
+	switch {
+	case !q.From.IsZero() && day < dayKey(q.From):
+		return false
+	case !q.To.IsZero() && day >= dayKey(q.To):
+		return false
+	case q.Category != "" && !strings.EqualFold(expense.Category, q.Category):
+		return false
+	case q.Tag != "" && !expense.HasTag(q.Tag):
+		return false
+	}
+	return true
+}
+
+// Repository persists the expenses, partitioned per user.
+type Repository interface {
+	// Create stores a new expense, setting its ID, CreatedAt, UpdatedAt and Version.
+	Create(ctx context.Context, expense *Expense) error
+	// Get returns the expense id of userID, or ErrNotFound.
+	Get(ctx context.Context, userID int64, id string) (*Expense, error)
+	// Update stores expense if its Version matches the stored one, and
+	// increments it. The ID changes when the Date does, to keep the expenses
+	// sorted by date.
+	Update(ctx context.Context, expense *Expense) error
+	// Delete removes the expense id of userID, or returns ErrNotFound.
+	Delete(ctx context.Context, userID int64, id string) error
+	// List returns the expenses of userID selected by query, sorted by date.
+	List(ctx context.Context, userID int64, query Query) ([]*Expense, error)
+}
+
+// NewID returns a new expense ID for date. The IDs sort by date, e.g.
+// "20260317-9f86d081884c7d65".
+func NewID(date time.Time) string {
+	random := make([]byte, 8)
+	rand.Read(random)
+	return dayKey(date) + "-" + hex.EncodeToString(random)
+}
+
+// dayKey formats the day of t so the days sort as strings.
+func dayKey(t time.Time) string {
+	return t.Format("20060102")
+}
+
+// IDMatchesDate reports if id was created for the day of date, the IDs of
+// the expenses whose date changed are replaced to keep them sorted.
+func IDMatchesDate(id string, date time.Time) bool {
+	return strings.HasPrefix(id, dayKey(date)+"-")
+}
+
+// MemoryRepository is a Repository for tests and local runs, the expenses are lost on restart.
+type MemoryRepository struct {
+	mu       sync.Mutex
+	expenses map[int64]map[string]*Expense
+	now      func() time.Time
+}
+
+// NewMemoryRepository creates an empty MemoryRepository.
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{expenses: make(map[int64]map[string]*Expense), now: time.Now}
+}
+
+// Create implements Repository.
+func (r *MemoryRepository) Create(ctx context.Context, expense *Expense) error {
+	if err := expense.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if expense.ID == "" {
+		expense.ID = NewID(expense.Date)
+	}
+	if _, ok := r.expenses[expense.UserID][expense.ID]; ok {
+		return ErrConflict
+	}
+	expense.CreatedAt = r.now()
+	expense.UpdatedAt = expense.CreatedAt
+	expense.Version = 1
+	r.put(expense)
+	return nil
+}
+
+// Get implements Repository.
+func (r *MemoryRepository) Get(ctx context.Context, userID int64, id string) (*Expense, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expense, ok := r.expenses[userID][id]
+	if !ok || r.expired(expense) {
+		return nil, ErrNotFound
+	}
+	return copyExpense(expense), nil
+}
+
+// Update implements Repository.
+func (r *MemoryRepository) Update(ctx context.Context, expense *Expense) error {
+	if err := expense.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.expenses[expense.UserID][expense.ID]
+	if !ok || r.expired(current) {
+		return ErrNotFound
+	}
+	if current.Version != expense.Version {
+		return ErrConflict
+	}
+	delete(r.expenses[expense.UserID], expense.ID)
+	if !IDMatchesDate(expense.ID, expense.Date) {
+		expense.ID = NewID(expense.Date)
+	}
+	expense.UpdatedAt = r.now()
+	expense.Version++
+	r.put(expense)
+	return nil
+}
+
+// Delete implements Repository.
+func (r *MemoryRepository) Delete(ctx context.Context, userID int64, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expense, ok := r.expenses[userID][id]
+	if !ok || r.expired(expense) {
+		return ErrNotFound
+	}
+	delete(r.expenses[userID], id)
+	return nil
+}
+
+// List implements Repository.
+func (r *MemoryRepository) List(ctx context.Context, userID int64, query Query) ([]*Expense, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var selected []*Expense
+	for _, expense := range r.expenses[userID] {
+		if query.Matches(expense) && !r.expired(expense) {
+			selected = append(selected, copyExpense(expense))
+		}
+	}
+	slices.SortFunc(selected, func(a, b *Expense) int {
+		if query.Descending {
+			a, b = b, a
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	if query.Limit > 0 && len(selected) > query.Limit {
+		selected = selected[:query.Limit]
+	}
+	return selected, nil
+}
+
+func (r *MemoryRepository) put(expense *Expense) {
+	if r.expenses[expense.UserID] == nil {
+		r.expenses[expense.UserID] = make(map[string]*Expense)
+	}
+	expense.Tags = normalizeTags(expense.Tags)
+	r.expenses[expense.UserID][expense.ID] = copyExpense(expense)
+}
+
+// expired reports if the TTL of a draft passed, like DynamoDB would remove it.
+func (r *MemoryRepository) expired(expense *Expense) bool {
+	return !expense.ExpiresAt.IsZero() && r.now().After(expense.ExpiresAt)
+}
+
+// copyExpense copies expense without sharing its tags.
+func copyExpense(expense *Expense) *Expense {
+	c := *expense
+	c.Tags = slices.Clone(expense.Tags)
+	return &c
+}
@@ -0,0 +1,388 @@
+package expenses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Names of the indexes of the expenses table.
+const (
+	CategoryIndex = "category"
+	TagIndex      = "tag"
+)
+
+const (
+	expensePrefix = "EXPENSE#"
+	tagPrefix     = "TAG#"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by DynamoDBRepository.
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
+// DynamoDBRepository is a Repository over a single DynamoDB table, created by
+// the ExpensesTable construct of the infrastructure or by CreateTableInput.
+//
+// The items of a user share the partition key "USER#<id>". Each expense is an
+// item with the sort key "EXPENSE#<id>", sorted by date because the IDs
+// start with it, and the Expense as JSON in "expense". The category index
+// selects the expenses of a category, and each tag of an expense has a copy
+// of it with the sort key "TAG#<id>#<tag>" in the tag index. The copies are
+// written in the same transaction as the expense, with optimistic locking on
+// "version".
+//
+// To use DynamoDB Local set the BaseEndpoint of the client, e.g.
+//
+//	client := dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+//		o.BaseEndpoint = aws.String("http://localhost:8000")
+//	})
+//	client.CreateTable(ctx, expenses.CreateTableInput("EM-Expenses"))
+type DynamoDBRepository struct {
+	client    DynamoDBAPI
+	tableName string
+	now       func() time.Time
+}
+
+// NewDynamoDBRepository creates a DynamoDBRepository over tableName.
+func NewDynamoDBRepository(client DynamoDBAPI, tableName string) *DynamoDBRepository {
+	return &DynamoDBRepository{client: client, tableName: tableName, now: time.Now}
+}
+
+// CreateTableInput returns the definition of the expenses table, the same as
+// the one deployed, to create it in DynamoDB Local.
+func CreateTableInput(tableName string) *dynamodb.CreateTableInput {
+	attributes := []types.AttributeDefinition{}
+	for _, name := range []string{"pk", "sk", "category_pk", "category_sk", "tag_pk", "tag_sk"} {
+		attributes = append(attributes, types.AttributeDefinition{AttributeName: aws.String(name), AttributeType: types.ScalarAttributeTypeS})
+	}
+	index := func(name string) types.GlobalSecondaryIndex {
+		return types.GlobalSecondaryIndex{
+			IndexName: aws.String(name),
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String(name + "_pk"), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String(name + "_sk"), KeyType: types.KeyTypeRange},
+			},
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		}
+	}
+	return &dynamodb.CreateTableInput{
+		TableName:            aws.String(tableName),
+		AttributeDefinitions: attributes,
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String("sk"), KeyType: types.KeyTypeRange},
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{index(CategoryIndex), index(TagIndex)},
+		BillingMode:            types.BillingModePayPerRequest,
+	}
+}
+
+// Create implements Repository.
+func (r *DynamoDBRepository) Create(ctx context.Context, expense *Expense) error {
+	if err := expense.Validate(); err != nil {
+		return err
+	}
+	stored := copyExpense(expense)
+	if stored.ID == "" {
+		stored.ID = NewID(stored.Date)
+	}
+	stored.Tags = normalizeTags(stored.Tags)
+	stored.CreatedAt = r.now()
+	stored.UpdatedAt = stored.CreatedAt
+	stored.Version = 1
+
+	items, err := r.items(stored)
+	if err != nil {
+		return fmt.Errorf("creating expense %s: %w", stored.ID, err)
+	}
+	var writes []types.TransactWriteItem
+	for i, item := range items {
+		put := &types.Put{TableName: aws.String(r.tableName), Item: item}
+		if i == 0 {
+			put.ConditionExpression = aws.String("attribute_not_exists(pk)")
+		}
+		writes = append(writes, types.TransactWriteItem{Put: put})
+	}
+	if err := r.write(ctx, writes); err != nil {
+		return fmt.Errorf("creating expense %s: %w", stored.ID, err)
+	}
+	*expense = *stored
+	return nil
+}
+
+// Get implements Repository.
+func (r *DynamoDBRepository) Get(ctx context.Context, userID int64, id string) (*Expense, error) {
+	out, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		Key:            itemKey(userID, expensePrefix+id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting expense %s: %w", id, err)
+	}
+	if out.Item == nil {
+		return nil, ErrNotFound
+	}
+	expense, err := decodeExpense(out.Item)
+	if err != nil {
+		return nil, fmt.Errorf("getting expense %s: %w", id, err)
+	}
+	// The TTL removes the items some time after they expire
+	if !expense.ExpiresAt.IsZero() && r.now().After(expense.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return expense, nil
+}
+
+// Update implements Repository.
+func (r *DynamoDBRepository) Update(ctx context.Context, expense *Expense) error {
+	if err := expense.Validate(); err != nil {
+		return err
+	}
+	current, err := r.Get(ctx, expense.UserID, expense.ID)
+	if err != nil {
+		return err
+	}
+	if current.Version != expense.Version {
+		return ErrConflict
+	}
+
+	stored := copyExpense(expense)
+	if !IDMatchesDate(stored.ID, stored.Date) {
+		stored.ID = NewID(stored.Date)
+	}
+	stored.Tags = normalizeTags(stored.Tags)
+	stored.UpdatedAt = r.now()
+	stored.Version++
+
+	items, err := r.items(stored)
+	if err != nil {
+		return fmt.Errorf("updating expense %s: %w", expense.ID, err)
+	}
+	versionCheck := map[string]types.AttributeValue{":version": numberValue(current.Version)}
+	var writes []types.TransactWriteItem
+	if stored.ID == current.ID {
+		writes = append(writes, types.TransactWriteItem{Put: &types.Put{
+			TableName:                 aws.String(r.tableName),
+			Item:                      items[0],
+			ConditionExpression:       aws.String("version = :version"),
+			ExpressionAttributeValues: versionCheck,
+		}})
+	} else {
+		// The date changed, move the expense to its new sort key
+		writes = append(writes,
+			types.TransactWriteItem{Delete: &types.Delete{
+				TableName:                 aws.String(r.tableName),
+				Key:                       itemKey(current.UserID, expensePrefix+current.ID),
+				ConditionExpression:       aws.String("version = :version"),
+				ExpressionAttributeValues: versionCheck,
+			}},
+			types.TransactWriteItem{Put: &types.Put{
+				TableName:           aws.String(r.tableName),
+				Item:                items[0],
+				ConditionExpression: aws.String("attribute_not_exists(pk)"),
+			}},
+		)
+	}
+	for _, tag := range current.Tags {
+		if stored.ID != current.ID || !stored.HasTag(tag) {
+			writes = append(writes, types.TransactWriteItem{Delete: &types.Delete{
+				TableName: aws.String(r.tableName),
+				Key:       itemKey(current.UserID, tagKey(current.ID, tag)),
+			}})
+		}
+	}
+	for _, item := range items[1:] {
+		writes = append(writes, types.TransactWriteItem{Put: &types.Put{TableName: aws.String(r.tableName), Item: item}})
+	}
+
+	if err := r.write(ctx, writes); err != nil {
+		return fmt.Errorf("updating expense %s: %w", expense.ID, err)
+	}
+	*expense = *stored
+	return nil
+}
+
+// Delete implements Repository.
+func (r *DynamoDBRepository) Delete(ctx context.Context, userID int64, id string) error {
+	current, err := r.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	writes := []types.TransactWriteItem{{Delete: &types.Delete{
+		TableName:                 aws.String(r.tableName),
+		Key:                       itemKey(userID, expensePrefix+id),
+		ConditionExpression:       aws.String("version = :version"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":version": numberValue(current.Version)},
+	}}}
+	for _, tag := range current.Tags {
+		writes = append(writes, types.TransactWriteItem{Delete: &types.Delete{
+			TableName: aws.String(r.tableName),
+			Key:       itemKey(userID, tagKey(id, tag)),
+		}})
+	}
+	if err := r.write(ctx, writes); err != nil {
+		return fmt.Errorf("deleting expense %s: %w", id, err)
+	}
+	return nil
+}
+
+// List implements Repository. It reads the tag index when the query has a
+// tag, the category index when it has a category, or the table otherwise.
+func (r *DynamoDBRepository) List(ctx context.Context, userID int64, query Query) ([]*Expense, error) {
+	from, to := "0", "~"
+	if !query.From.IsZero() {
+		from = dayKey(query.From)
+	}
+	if !query.To.IsZero() {
+		// BETWEEN is inclusive, but the IDs of that day are greater than the day alone
+		to = dayKey(query.To)
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("#pk = :pk AND #sk BETWEEN :from AND :to"),
+		ScanIndexForward:       aws.Bool(!query.Descending),
+	}
+	partition, sortPrefix := "pk", expensePrefix
+	switch {
+	case query.Tag != "":
+		input.IndexName = aws.String(TagIndex)
+		partition, sortPrefix = "tag_pk", ""
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{":pk": stringValue(tagPartition(userID, query.Tag))}
+	case query.Category != "":
+		input.IndexName = aws.String(CategoryIndex)
+		partition, sortPrefix = "category_pk", ""
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{":pk": stringValue(categoryPartition(userID, query.Category))}
+	default:
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{":pk": stringValue(userPartition(userID))}
+	}
+	input.ExpressionAttributeNames = map[string]string{"#pk": partition, "#sk": strings.TrimSuffix(partition, "pk") + "sk"}
+	input.ExpressionAttributeValues[":from"] = stringValue(sortPrefix + from)
+	input.ExpressionAttributeValues[":to"] = stringValue(sortPrefix + to)
+
+	var selected []*Expense
+	paginator := dynamodb.NewQueryPaginator(r.client, input)
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing expenses of %d: %w", userID, err)
+		}
+		for _, item := range out.Items {
+			expense, err := decodeExpense(item)
+			if err != nil {
+				return nil, fmt.Errorf("listing expenses of %d: %w", userID, err)
+			}
+			expired := !expense.ExpiresAt.IsZero() && r.now().After(expense.ExpiresAt)
+			if !query.Matches(expense) || expired {
+				continue
+			}
+			selected = append(selected, expense)
+			if query.Limit > 0 && len(selected) == query.Limit {
+				return selected, nil
+			}
+		}
+	}
+	return selected, nil
+}
+
+// items returns the items of expense: the expense itself followed by a copy per tag.
+func (r *DynamoDBRepository) items(expense *Expense) ([]map[string]types.AttributeValue, error) {
+	encoded, err := json.Marshal(expense)
+	if err != nil {
+		return nil, err
+	}
+	item := itemKey(expense.UserID, expensePrefix+expense.ID)
+	item["expense"] = stringValue(string(encoded))
+	item["version"] = numberValue(expense.Version)
+	if !expense.ExpiresAt.IsZero() {
+		item["expires_at"] = numberValue(expense.ExpiresAt.Unix())
+	}
+	if expense.Category != "" {
+		item["category_pk"] = stringValue(categoryPartition(expense.UserID, expense.Category))
+		item["category_sk"] = stringValue(expense.ID)
+	}
+
+	items := []map[string]types.AttributeValue{item}
+	for _, tag := range expense.Tags {
+		tagItem := itemKey(expense.UserID, tagKey(expense.ID, tag))
+		tagItem["expense"] = item["expense"]
+		tagItem["version"] = item["version"]
+		if expiresAt, ok := item["expires_at"]; ok {
+			tagItem["expires_at"] = expiresAt
+		}
+		tagItem["tag_pk"] = stringValue(tagPartition(expense.UserID, tag))
+		tagItem["tag_sk"] = stringValue(expense.ID)
+		items = append(items, tagItem)
+	}
+	return items, nil
+}
+
+// write runs writes in a transaction, returning ErrConflict when a condition fails.
+func (r *DynamoDBRepository) write(ctx context.Context, writes []types.TransactWriteItem) error {
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: writes})
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		for _, reason := range canceled.CancellationReasons {
+			if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+				return ErrConflict
+			}
+		}
+	}
+	return err
+}
+
+func decodeExpense(item map[string]types.AttributeValue) (*Expense, error) {
+	encoded, ok := item["expense"].(*types.AttributeValueMemberS)
+	if !ok {
+		return nil, errors.New("expense attribute is missing")
+	}
+	var expense Expense
+	if err := json.Unmarshal([]byte(encoded.Value), &expense); err != nil {
+		return nil, err
+	}
+	return &expense, nil
+}
+
+func userPartition(userID int64) string {
+	return "USER#" + strconv.FormatInt(userID, 10)
+}
+
+func categoryPartition(userID int64, category string) string {
+	return userPartition(userID) + "#CATEGORY#" + strings.ToLower(category)
+}
+
+func tagPartition(userID int64, tag string) string {
+	return userPartition(userID) + "#TAG#" + normalizeTag(tag)
+}
+
+func tagKey(id, tag string) string {
+	return tagPrefix + id + "#" + normalizeTag(tag)
+}
+
+func itemKey(userID int64, sortKey string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"pk": stringValue(userPartition(userID)),
+		"sk": stringValue(sortKey),
+	}
+}
+
+func stringValue(s string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: s}
+}
+
+func numberValue(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
@@ -0,0 +1,66 @@
+package expenses
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/betofloresbaca/expenses-manager/pkg/dynamotest"
+)
+
+func TestDynamoDBRepository(t *testing.T) {
+	client := dynamotest.Client(t)
+	testRepository(t, func(t *testing.T, clock *testClock) Repository {
+		repository := NewDynamoDBRepository(client, dynamotest.NewTable(t, client, CreateTableInput("")))
+		repository.now = clock.Now
+		return repository
+	})
+}
+
+// TestDynamoDBRepositoryTTL checks the attribute of the table TTL, DynamoDB removes the drafts with it.
+func TestDynamoDBRepositoryTTL(t *testing.T) {
+	client := dynamotest.Client(t)
+	table := dynamotest.NewTable(t, client, CreateTableInput(""))
+	repository := NewDynamoDBRepository(client, table)
+	ctx := context.Background()
+
+	draft := testExpense(day(18), "Comida", "trabajo")
+	draft.ExpiresAt = time.Now().Add(DraftTTL).Truncate(time.Second)
+	if err := repository.Create(ctx, draft); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	expiresAt := func(sortKey string) string {
+		t.Helper()
+		out, err := client.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(table), Key: itemKey(42, sortKey)})
+		if err != nil {
+			t.Fatalf("GetItem(%s) error = %v", sortKey, err)
+		}
+		if value, ok := out.Item["expires_at"].(*types.AttributeValueMemberN); ok {
+			return value.Value
+		}
+		return ""
+	}
+	want := strconv.FormatInt(draft.ExpiresAt.Unix(), 10)
+	if got := expiresAt(expensePrefix + draft.ID); got != want {
+		t.Errorf("expires_at of the draft = %q, want %q", got, want)
+	}
+	if got := expiresAt(tagKey(draft.ID, "trabajo")); got != want {
+		t.Errorf("expires_at of the tag copy = %q, want %q", got, want)
+	}
+
+	// A confirmed expense has no expiration
+	draft.ExpiresAt = time.Time{}
+	if err := repository.Update(ctx, draft); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := expiresAt(expensePrefix + draft.ID); got != "" {
+		t.Errorf("expires_at of the confirmed expense = %q, want none", got)
+	}
+	if got := expiresAt(tagKey(draft.ID, "trabajo")); got != "" {
+		t.Errorf("expires_at of the confirmed tag copy = %q, want none", got)
+	}
+}
@@ -0,0 +1,260 @@
+package expenses
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testClock is the current time of the repositories under test, the tests move it to expire the drafts.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+// newTestRepository creates an empty Repository whose current time is clock.Now.
+type newTestRepository func(t *testing.T, clock *testClock) Repository
+
+// day returns the midnight of a day of March 2024.
+func day(d int) time.Time {
+	return time.Date(2024, time.March, d, 0, 0, 0, 0, time.UTC)
+}
+
+// testExpense returns a valid expense of user 42.
+func testExpense(date time.Time, category string, tags ...string) *Expense {
+	return &Expense{UserID: 42, ChatID: 42, Amount: 2350, Currency: DefaultCurrency, Category: category, Tags: tags, Date: date}
+}
+
+// testRepository runs the Repository contract against the repositories made by newRepository.
+func testRepository(t *testing.T, newRepository newTestRepository) {
+	ctx := context.Background()
+	start := time.Date(2024, time.March, 20, 12, 0, 0, 0, time.UTC)
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		clock := &testClock{now: start}
+		repository := newRepository(t, clock)
+
+		expense := testExpense(day(15), "Comida", "Trabajo")
+		expense.Merchant, expense.Notes = "Starbucks", "café"
+		if err := repository.Create(ctx, expense); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if !IDMatchesDate(expense.ID, expense.Date) || expense.Version != 1 || !expense.CreatedAt.Equal(start) || !expense.UpdatedAt.Equal(start) {
+			t.Errorf("Create() = %+v, want an ID of the date, version 1 and the current time", expense)
+		}
+
+		got, err := repository.Get(ctx, 42, expense.ID)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if got.Merchant != "Starbucks" || got.Notes != "café" || got.Version != 1 || len(got.Tags) != 1 || got.Tags[0] != "trabajo" || !got.Date.Equal(day(15)) {
+			t.Errorf("Get() = %+v, want the created expense with normalized tags", got)
+		}
+		if _, err := repository.Get(ctx, 7, expense.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() of another user error = %v, want ErrNotFound", err)
+		}
+
+		duplicate := testExpense(day(15), "Comida")
+		duplicate.ID = expense.ID
+		if err := repository.Create(ctx, duplicate); !errors.Is(err, ErrConflict) {
+			t.Errorf("Create() of an existing ID error = %v, want ErrConflict", err)
+		}
+		if err := repository.Create(ctx, &Expense{UserID: 42, Currency: DefaultCurrency, Date: day(15)}); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Create() without amount error = %v, want ErrInvalidAmount", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		clock := &testClock{now: start}
+		repository := newRepository(t, clock)
+		expense := testExpense(day(15), "Comida", "trabajo")
+		if err := repository.Create(ctx, expense); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		stale := *expense
+
+		clock.now = start.Add(time.Hour)
+		expense.Amount, expense.Tags = 5000, []string{"viaje"}
+		if err := repository.Update(ctx, expense); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		if expense.Version != 2 || !expense.UpdatedAt.Equal(clock.now) || !expense.CreatedAt.Equal(start) {
+			t.Errorf("Update() = %+v, want version 2 updated now", expense)
+		}
+		if got, _ := repository.Get(ctx, 42, expense.ID); got == nil || got.Amount != 5000 {
+			t.Errorf("Get() after Update() = %+v, want the new amount", got)
+		}
+		if list, _ := repository.List(ctx, 42, Query{Tag: "trabajo"}); len(list) != 0 {
+			t.Errorf("List() of a removed tag = %+v, want none", list)
+		}
+		if list, _ := repository.List(ctx, 42, Query{Tag: "viaje"}); len(list) != 1 || list[0].Amount != 5000 {
+			t.Errorf("List() of the new tag = %+v, want the updated expense", list)
+		}
+
+		// The copy loaded before the update is stale
+		stale.Amount = 100
+		if err := repository.Update(ctx, &stale); !errors.Is(err, ErrConflict) {
+			t.Errorf("Update() of a stale expense error = %v, want ErrConflict", err)
+		}
+
+		// Changing the date changes the ID, to keep the expenses sorted
+		oldID := expense.ID
+		expense.Date = day(1)
+		if err := repository.Update(ctx, expense); err != nil {
+			t.Fatalf("Update() of the date error = %v", err)
+		}
+		if expense.ID == oldID || !IDMatchesDate(expense.ID, day(1)) || expense.Version != 3 {
+			t.Errorf("Update() of the date = %+v, want a new ID of the date at version 3", expense)
+		}
+		if _, err := repository.Get(ctx, 42, oldID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() of the old ID error = %v, want ErrNotFound", err)
+		}
+		if list, _ := repository.List(ctx, 42, Query{Tag: "viaje"}); len(list) != 1 || list[0].ID != expense.ID {
+			t.Errorf("List() of the tag after moving = %+v, want only the new ID", list)
+		}
+
+		missing := testExpense(day(2), "Comida")
+		missing.ID, missing.Version = NewID(day(2)), 1
+		if err := repository.Update(ctx, missing); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Update() of a missing expense error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repository := newRepository(t, &testClock{now: start})
+		expense := testExpense(day(15), "Comida", "trabajo")
+		if err := repository.Create(ctx, expense); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if err := repository.Delete(ctx, 42, expense.ID); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if _, err := repository.Get(ctx, 42, expense.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+		}
+		if list, _ := repository.List(ctx, 42, Query{Tag: "trabajo"}); len(list) != 0 {
+			t.Errorf("List() of its tag after Delete() = %+v, want none", list)
+		}
+		if err := repository.Delete(ctx, 42, expense.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete() of a missing expense error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		clock := &testClock{now: start}
+		repository := newRepository(t, clock)
+		seed := []*Expense{
+			testExpense(day(1), "Comida", "trabajo"),
+			testExpense(day(10), "Transporte"),
+			testExpense(day(15), "comida"),
+			testExpense(day(19), "Hogar", "trabajo"),
+			testExpense(day(20), ""),
+		}
+		draft := testExpense(day(18), "Comida")
+		draft.Amount, draft.ExpiresAt = 0, start.Add(time.Hour)
+		seed = append(seed, draft)
+		for _, expense := range seed {
+			if err := repository.Create(ctx, expense); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+		}
+		if err := repository.Create(ctx, &Expense{UserID: 7, Amount: 100, Currency: DefaultCurrency, Date: day(15)}); err != nil {
+			t.Fatalf("Create() of another user error = %v", err)
+		}
+
+		tests := []struct {
+			name  string
+			query Query
+			want  []time.Time
+		}{
+			{"all", Query{}, []time.Time{day(1), day(10), day(15), day(18), day(19), day(20)}},
+			{"from", Query{From: day(15)}, []time.Time{day(15), day(18), day(19), day(20)}},
+			{"to excluded", Query{To: day(15)}, []time.Time{day(1), day(10)}},
+			{"range", Query{From: day(10), To: day(19)}, []time.Time{day(10), day(15), day(18)}},
+			{"category ignoring case", Query{Category: "COMIDA"}, []time.Time{day(1), day(15), day(18)}},
+			{"category in range", Query{Category: "comida", From: day(2), To: day(16)}, []time.Time{day(15)}},
+			{"tag", Query{Tag: "#Trabajo"}, []time.Time{day(1), day(19)}},
+			{"limit", Query{Limit: 2}, []time.Time{day(1), day(10)}},
+			{"descending", Query{Descending: true, Limit: 2}, []time.Time{day(20), day(19)}},
+			{"empty range", Query{From: day(21)}, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				list, err := repository.List(ctx, 42, tt.query)
+				if err != nil {
+					t.Fatalf("List() error = %v", err)
+				}
+				var dates []time.Time
+				for _, expense := range list {
+					dates = append(dates, expense.Date)
+				}
+				if len(dates) != len(tt.want) {
+					t.Fatalf("List(%+v) dates = %v, want %v", tt.query, dates, tt.want)
+				}
+				for i := range dates {
+					if !dates[i].Equal(tt.want[i]) {
+						t.Fatalf("List(%+v) dates = %v, want %v", tt.query, dates, tt.want)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("Drafts", func(t *testing.T) {
+		clock := &testClock{now: start}
+		repository := newRepository(t, clock)
+
+		draft := testExpense(day(18), "")
+		draft.Amount, draft.ExpiresAt, draft.ReceiptKey = 0, start.Add(DraftTTL), "receipts/42/draft.jpg"
+		if err := repository.Create(ctx, draft); err != nil {
+			t.Fatalf("Create() of a draft without amount error = %v", err)
+		}
+		got, err := repository.Get(ctx, 42, draft.ID)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if !got.IsDraft() || !got.ExpiresAt.Equal(draft.ExpiresAt) || got.ReceiptKey != draft.ReceiptKey {
+			t.Errorf("Get() = %+v, want the draft with its expiration and receipt", got)
+		}
+
+		// Confirming the draft clears its expiration, it is kept after the TTL
+		got.Amount, got.Category, got.ExpiresAt = 12350, "Comida", time.Time{}
+		if err := repository.Update(ctx, got); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		expired := testExpense(day(18), "Comida")
+		expired.ExpiresAt = start.Add(DraftTTL)
+		if err := repository.Create(ctx, expired); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		clock.now = start.Add(DraftTTL + time.Minute)
+		if _, err := repository.Get(ctx, 42, got.ID); err != nil {
+			t.Errorf("Get() of the confirmed draft after the TTL error = %v", err)
+		}
+		if _, err := repository.Get(ctx, 42, expired.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() of an expired draft error = %v, want ErrNotFound", err)
+		}
+		if list, _ := repository.List(ctx, 42, Query{}); len(list) != 1 || list[0].ID != got.ID {
+			t.Errorf("List() after the TTL = %+v, want only the confirmed draft", list)
+		}
+		if err := repository.Update(ctx, expired); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Update() of an expired draft error = %v, want ErrNotFound", err)
+		}
+		if err := repository.Delete(ctx, 42, expired.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Delete() of an expired draft error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T, clock *testClock) Repository {
+		repository := NewMemoryRepository()
+		repository.now = clock.Now
+		return repository
+	})
+}
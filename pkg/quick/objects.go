@@ -0,0 +1,79 @@
+package quick
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/betofloresbaca/expenses-manager/pkg/clients"
+)
+
+// PutObject streams body into bucket/key without buffering it. size is
+// required for non seekable bodies like a Telegram file download.
+func PutObject(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string) error {
+	s3Client := clients.GetClient(func(cfg aws.Config) *s3.Client {
+		return s3.NewFromConfig(cfg)
+	})
+
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	_, err := s3Client.PutObject(ctx, input)
+	return err
+}
+
+// GetObject opens the content of bucket/key, the caller must close it.
+func GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	s3Client := clients.GetClient(func(cfg aws.Config) *s3.Client {
+		return s3.NewFromConfig(cfg)
+	})
+
+	object, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return object.Body, nil
+}
+
+// ObjectExists reports if bucket/key exists. The role needs s3:ListBucket on
+// the bucket, otherwise S3 answers 403 instead of 404 for a missing key.
+func ObjectExists(ctx context.Context, bucket, key string) (bool, error) {
+	s3Client := clients.GetClient(func(cfg aws.Config) *s3.Client {
+		return s3.NewFromConfig(cfg)
+	})
+
+	_, err := s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteObject removes bucket/key, it does not fail if it does not exist.
+func DeleteObject(ctx context.Context, bucket, key string) error {
+	s3Client := clients.GetClient(func(cfg aws.Config) *s3.Client {
+		return s3.NewFromConfig(cfg)
+	})
+
+	_, err := s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
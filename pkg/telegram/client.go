@@ -0,0 +1,200 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultBaseURL is the base URL of the public Telegram Bot API server.
+const DefaultBaseURL = "https://api.telegram.org"
+
+// Client is a Telegram Bot API client bound to a single bot token.
+// It is safe for concurrent use.
+type Client struct {
+	token      string
+	baseURL    string
+	httpClient *http.Client
+	limiter    *RateLimiter
+}
+
+// ClientOption configures optional settings of a Client.
+type ClientOption func(*Client)
+
+// WithBaseURL overrides the Bot API server URL (default: DefaultBaseURL).
+// Useful for a local Bot API server or a fake server in tests.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for every request (default: http.DefaultClient).
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRateLimiter makes the client wait on limiter before each send.
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
+
+// NewClient creates a new Client for the given bot token.
+func NewClient(token string, opts ...ClientOption) *Client {
+	c := &Client{
+		token:      token,
+		baseURL:    DefaultBaseURL,
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SendMessage sends a text message.
+// Flood control waits and group migrations are handled transparently, see send:
+// req is not modified and, after a migration, the message is in the new chat.
+func (c *Client) SendMessage(ctx context.Context, req *SendMessageRequest) (*Message, error) {
+	return send[*Message](ctx, c, "sendMessage", req)
+}
+
+// EditMessageText edits the text of a message.
+// When editing an inline message (InlineMessageID) Telegram returns no message and the result is nil.
+func (c *Client) EditMessageText(ctx context.Context, req *EditMessageTextRequest) (*Message, error) {
+	raw, err := call[json.RawMessage](ctx, c, "editMessageText", req)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(raw, []byte("true")) {
+		return nil, nil
+	}
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, fmt.Errorf("telegram editMessageText: decoding result: %w", err)
+	}
+	return &msg, nil
+}
+
+// DeleteMessage deletes a message.
+func (c *Client) DeleteMessage(ctx context.Context, req *DeleteMessageRequest) error {
+	_, err := call[bool](ctx, c, "deleteMessage", req)
+	return err
+}
+
+// AnswerCallbackQuery sends an answer to a callback query sent from an inline keyboard.
+func (c *Client) AnswerCallbackQuery(ctx context.Context, req *AnswerCallbackQueryRequest) error {
+	_, err := call[bool](ctx, c, "answerCallbackQuery", req)
+	return err
+}
+
+// GetMe returns basic information about the bot.
+func (c *Client) GetMe(ctx context.Context) (*User, error) {
+	return call[*User](ctx, c, "getMe", nil)
+}
+
+// SetMyCommands changes the command menu of the bot for a scope and language.
+func (c *Client) SetMyCommands(ctx context.Context, req *SetMyCommandsRequest) error {
+	_, err := call[bool](ctx, c, "setMyCommands", req)
+	return err
+}
+
+// GetMyCommands returns the command menu of the bot for a scope and language.
+func (c *Client) GetMyCommands(ctx context.Context, req *GetMyCommandsRequest) ([]BotCommand, error) {
+	return call[[]BotCommand](ctx, c, "getMyCommands", req)
+}
+
+// DeleteMyCommands deletes the command menu of the bot for a scope and language,
+// so the menu of a broader scope is shown instead.
+func (c *Client) DeleteMyCommands(ctx context.Context, req *DeleteMyCommandsRequest) error {
+	_, err := call[bool](ctx, c, "deleteMyCommands", req)
+	return err
+}
+
+// GetFile returns basic information about a file and prepares it for downloading.
+func (c *Client) GetFile(ctx context.Context, req *GetFileRequest) (*File, error) {
+	return call[*File](ctx, c, "getFile", req)
+}
+
+// call invokes a Bot API method and returns its typed result. The payload is
+// sent as JSON, or as multipart/form-data when it uploads files.
+// Responses with ok=false are returned as *Error.
+func call[T any](ctx context.Context, c *Client, method string, payload interface{}) (T, error) {
+	var zero T
+	var httpReq *http.Request
+	var err error
+	if files := uploads(payload); len(files) > 0 {
+		httpReq, err = c.newMultipartRequest(ctx, method, payload, files)
+	} else {
+		httpReq, err = c.newJSONRequest(ctx, method, payload)
+	}
+	if err != nil {
+		return zero, err
+	}
+	return do[T](c, method, httpReq)
+}
+
+// newJSONRequest builds a request with payload encoded as JSON.
+func (c *Client) newJSONRequest(ctx context.Context, method string, payload interface{}) (*http.Request, error) {
+	body := []byte("{}")
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("telegram %s: encoding request: %w", method, err)
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.methodURL(method), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("telegram %s: %w", method, c.redact(err))
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	return httpReq, nil
+}
+
+// do sends a prepared request and decodes the APIResponse envelope.
+func do[T any](c *Client, method string, httpReq *http.Request) (T, error) {
+	var zero T
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return zero, fmt.Errorf("telegram %s: %w", method, c.redact(err))
+	}
+	defer resp.Body.Close()
+
+	var apiResp APIResponse[T]
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return zero, fmt.Errorf("telegram %s: decoding response (%s): %w", method, resp.Status, err)
+	}
+	if !apiResp.OK {
+		return zero, &Error{
+			Method:      method,
+			ErrorCode:   apiResp.ErrorCode,
+			Description: apiResp.Description,
+			Parameters:  apiResp.Parameters,
+		}
+	}
+	return apiResp.Result, nil
+}
+
+// methodURL builds the URL of a Bot API method.
+func (c *Client) methodURL(method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+}
+
+// redact removes the bot token from transport errors, which embed the request URL.
+func (c *Client) redact(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = strings.ReplaceAll(urlErr.URL, c.token, "<token>")
+	}
+	return err
+}
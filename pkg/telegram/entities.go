@@ -0,0 +1,143 @@
+package telegram
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// Entity types reported in MessageEntity.Type.
+const (
+	EntityMention              = "mention"
+	EntityHashtag              = "hashtag"
+	EntityCashtag              = "cashtag"
+	EntityBotCommand           = "bot_command"
+	EntityURL                  = "url"
+	EntityEmail                = "email"
+	EntityPhoneNumber          = "phone_number"
+	EntityBold                 = "bold"
+	EntityItalic               = "italic"
+	EntityUnderline            = "underline"
+	EntityStrikethrough        = "strikethrough"
+	EntitySpoiler              = "spoiler"
+	EntityBlockquote           = "blockquote"
+	EntityExpandableBlockquote = "expandable_blockquote"
+	EntityCode                 = "code"
+	EntityPre                  = "pre"
+	EntityTextLink             = "text_link"
+	EntityTextMention          = "text_mention"
+	EntityCustomEmoji          = "custom_emoji"
+)
+
+// Command is a bot command parsed from the beginning of a message,
+// e.g. "/start@MyBot payload" is {Name: "start", BotUsername: "MyBot", Args: "payload"}.
+type Command struct {
+	// Name is the lowercased command without the leading slash.
+	Name string
+	// BotUsername is the "@botname" suffix without the "@", empty if not present.
+	BotUsername string
+	// Args is the trimmed text after the command.
+	Args string
+}
+
+// Fields splits the command arguments on white space.
+func (c Command) Fields() []string {
+	return strings.Fields(c.Args)
+}
+
+// UTF16Len returns the length of s in UTF-16 code units, the unit used by
+// Telegram for entity offsets and lengths.
+func UTF16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// EntityText returns the part of text covered by the entity, slicing by UTF-16
+// code units. It returns an empty string if the entity is out of range.
+func EntityText(text string, entity MessageEntity) string {
+	units := utf16.Encode([]rune(text))
+	end := entity.Offset + entity.Length
+	if entity.Offset < 0 || entity.Length < 0 || end > len(units) {
+		return ""
+	}
+	return string(utf16.Decode(units[entity.Offset:end]))
+}
+
+// Command returns the bot command the message starts with, if any.
+func (m *Message) Command() (Command, bool) {
+	text, entities := m.textWithEntities()
+	if len(entities) == 0 || entities[0].Type != EntityBotCommand || entities[0].Offset != 0 {
+		return Command{}, false
+	}
+
+	name := strings.TrimPrefix(EntityText(text, entities[0]), "/")
+	command := Command{Name: name}
+	if i := strings.Index(name, "@"); i >= 0 {
+		command.Name, command.BotUsername = name[:i], name[i+1:]
+	}
+	command.Name = strings.ToLower(command.Name)
+
+	units := utf16.Encode([]rune(text))
+	if end := entities[0].Length; end <= len(units) {
+		command.Args = strings.TrimSpace(string(utf16.Decode(units[end:])))
+	}
+	return command, true
+}
+
+// Hashtags returns the hashtags of the message without the "#", e.g. usable as expense tags.
+func (m *Message) Hashtags() []string {
+	return m.entityValues(func(e MessageEntity, value string) (string, bool) {
+		return strings.TrimPrefix(value, "#"), e.Type == EntityHashtag
+	})
+}
+
+// Cashtags returns the cashtags of the message without the "$", e.g. "USD".
+func (m *Message) Cashtags() []string {
+	return m.entityValues(func(e MessageEntity, value string) (string, bool) {
+		return strings.TrimPrefix(value, "$"), e.Type == EntityCashtag
+	})
+}
+
+// Mentions returns the "@username" mentions of the message without the "@".
+func (m *Message) Mentions() []string {
+	return m.entityValues(func(e MessageEntity, value string) (string, bool) {
+		return strings.TrimPrefix(value, "@"), e.Type == EntityMention
+	})
+}
+
+// URLs returns the URLs of the message, both typed in the text and behind text links.
+func (m *Message) URLs() []string {
+	return m.entityValues(func(e MessageEntity, value string) (string, bool) {
+		switch e.Type {
+		case EntityURL:
+			return value, true
+		case EntityTextLink:
+			return e.URL, true
+		default:
+			return "", false
+		}
+	})
+}
+
+// entityValues collects the values selected by pick from the message entities.
+func (m *Message) entityValues(pick func(e MessageEntity, value string) (string, bool)) []string {
+	text, entities := m.textWithEntities()
+	var values []string
+	for _, entity := range entities {
+		if value, ok := pick(entity, EntityText(text, entity)); ok && value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// textWithEntities returns the text and entities of the message, or the
+// caption and its entities for media messages.
+func (m *Message) textWithEntities() (string, []MessageEntity) {
+	if m.Text != "" {
+		return m.Text, m.Entities
+	}
+	return m.Caption, m.CaptionEntities
+}
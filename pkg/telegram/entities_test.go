@@ -0,0 +1,155 @@
+package telegram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUTF16Len(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"tacos", 5},
+		{"café", 4},
+		{"😀", 2},
+		{"a😀b", 4},
+		{"🇲🇽", 4},
+	}
+	for _, tt := range tests {
+		if got := UTF16Len(tt.text); got != tt.want {
+			t.Errorf("UTF16Len(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEntityText(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		entity MessageEntity
+		want   string
+	}{
+		{"ascii", "pay #rent now", MessageEntity{Offset: 4, Length: 5}, "#rent"},
+		{"after emoji", "😀#food", MessageEntity{Offset: 2, Length: 5}, "#food"},
+		{"after accents", "cañón #comida", MessageEntity{Offset: 6, Length: 7}, "#comida"},
+		{"astral inside", "#a😀b", MessageEntity{Offset: 0, Length: 5}, "#a😀b"},
+		{"at the end", "pay #rent", MessageEntity{Offset: 4, Length: 5}, "#rent"},
+		{"past the end", "pay #rent", MessageEntity{Offset: 5, Length: 5}, ""},
+		{"negative offset", "pay #rent", MessageEntity{Offset: -1, Length: 2}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EntityText(tt.text, tt.entity); got != tt.want {
+				t.Errorf("EntityText(%q, %+v) = %q, want %q", tt.text, tt.entity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		message Message
+		want    Command
+		ok      bool
+	}{
+		{
+			name:    "plain",
+			message: Message{Text: "/start", Entities: []MessageEntity{{Type: EntityBotCommand, Offset: 0, Length: 6}}},
+			want:    Command{Name: "start"},
+			ok:      true,
+		},
+		{
+			name:    "with bot name and args",
+			message: Message{Text: "/Gasto@Test_Bot  23.50 tacos ", Entities: []MessageEntity{{Type: EntityBotCommand, Offset: 0, Length: 15}}},
+			want:    Command{Name: "gasto", BotUsername: "Test_Bot", Args: "23.50 tacos"},
+			ok:      true,
+		},
+		{
+			name:    "args with emoji",
+			message: Message{Text: "/gasto 😀 100 #food", Entities: []MessageEntity{{Type: EntityBotCommand, Offset: 0, Length: 6}, {Type: EntityHashtag, Offset: 14, Length: 5}}},
+			want:    Command{Name: "gasto", Args: "😀 100 #food"},
+			ok:      true,
+		},
+		{
+			name:    "caption",
+			message: Message{Caption: "/recibo hoy", CaptionEntities: []MessageEntity{{Type: EntityBotCommand, Offset: 0, Length: 7}}},
+			want:    Command{Name: "recibo", Args: "hoy"},
+			ok:      true,
+		},
+		{
+			name:    "not at the start",
+			message: Message{Text: "hola /start", Entities: []MessageEntity{{Type: EntityBotCommand, Offset: 5, Length: 6}}},
+		},
+		{
+			name:    "first entity is not a command",
+			message: Message{Text: "#food /start", Entities: []MessageEntity{{Type: EntityHashtag, Offset: 0, Length: 5}}},
+		},
+		{
+			name:    "no entities",
+			message: Message{Text: "/start"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.message.Command()
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("Command() = %+v, %t, want %+v, %t", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCommandFields(t *testing.T) {
+	command := Command{Name: "presupuesto", Args: "comida   3000\tmes"}
+	if got, want := command.Fields(), []string{"comida", "3000", "mes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %q, want %q", got, want)
+	}
+}
+
+func TestMessageEntityValues(t *testing.T) {
+	// "😀 " takes 3 UTF-16 code units and "é" takes one
+	message := &Message{
+		Text: "😀 café #comida con @luis $USD en https://oxxo.mx #fin",
+		Entities: []MessageEntity{
+			{Type: EntityHashtag, Offset: 8, Length: 7},
+			{Type: EntityMention, Offset: 20, Length: 5},
+			{Type: EntityCashtag, Offset: 26, Length: 4},
+			{Type: EntityURL, Offset: 34, Length: 15},
+			{Type: EntityHashtag, Offset: 50, Length: 4},
+		},
+	}
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"Hashtags", message.Hashtags(), []string{"comida", "fin"}},
+		{"Mentions", message.Mentions(), []string{"luis"}},
+		{"Cashtags", message.Cashtags(), []string{"USD"}},
+		{"URLs", message.URLs(), []string{"https://oxxo.mx"}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s() = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestMessageURLsTextLink(t *testing.T) {
+	message := &Message{
+		Caption: "ver ticket",
+		CaptionEntities: []MessageEntity{
+			{Type: EntityTextLink, Offset: 4, Length: 6, URL: "https://example.com/ticket"},
+			{Type: EntityBold, Offset: 0, Length: 3},
+		},
+	}
+	if got, want := message.URLs(), []string{"https://example.com/ticket"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("URLs() = %q, want %q", got, want)
+	}
+	if got := message.Hashtags(); got != nil {
+		t.Errorf("Hashtags() = %q, want none", got)
+	}
+}
@@ -0,0 +1,42 @@
+package telegram
+
+import (
+	"fmt"
+	"time"
+)
+
+// Error is returned by the Client when the Bot API answers with ok=false.
+// Use errors.As to inspect it:
+//
+//	var apiErr *telegram.Error
+//	if errors.As(err, &apiErr) && apiErr.ErrorCode == 403 { ... }
+type Error struct {
+	// Method is the Bot API method that failed (e.g. "sendMessage").
+	Method      string
+	ErrorCode   int
+	Description string
+	// Parameters is only set when Telegram explains how the request can be retried.
+	Parameters *Parameters
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("telegram %s: api error %d: %s", e.Method, e.ErrorCode, e.Description)
+}
+
+// RetryAfter returns how long to wait before repeating the request, or zero
+// if Telegram did not ask to wait (flood control, error 429).
+func (e *Error) RetryAfter() time.Duration {
+	if e.Parameters == nil {
+		return 0
+	}
+	return time.Duration(e.Parameters.RetryAfter) * time.Second
+}
+
+// MigrateToChatID returns the new identifier of a group that was migrated to
+// a supergroup, or zero if the error is not about a migration.
+func (e *Error) MigrateToChatID() int64 {
+	if e.Parameters == nil {
+		return 0
+	}
+	return e.Parameters.MigrateToChatID
+}
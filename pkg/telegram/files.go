@@ -0,0 +1,112 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// MaxDownloadSize is the maximum size of a file bots can download with the Bot API (20 MB).
+const MaxDownloadSize = 20 << 20
+
+// ErrFileTooBig is returned when a file exceeds MaxDownloadSize.
+var ErrFileTooBig = errors.New("telegram: file exceeds the 20 MB download limit")
+
+// BestPhotoSize returns the largest size of a photo whose FileSize fits in
+// maxBytes (MaxDownloadSize if zero). Sizes without FileSize are assumed to fit.
+func BestPhotoSize(sizes []PhotoSize, maxBytes int) (PhotoSize, bool) {
+	if maxBytes <= 0 || maxBytes > MaxDownloadSize {
+		maxBytes = MaxDownloadSize
+	}
+	var best PhotoSize
+	found := false
+	for _, size := range sizes {
+		if size.FileSize > maxBytes {
+			continue
+		}
+		if !found || size.Width*size.Height > best.Width*best.Height {
+			best, found = size, true
+		}
+	}
+	return best, found
+}
+
+// FileURL returns the download URL of a file resolved with GetFile.
+// The URL contains the bot token, never log it nor send it to users.
+func (c *Client) FileURL(file *File) string {
+	return fmt.Sprintf("%s/file/bot%s/%s", c.baseURL, c.token, file.FilePath)
+}
+
+// DownloadFile opens the content of a file resolved with GetFile, the caller
+// must close it. If file.FileSize is unknown it is filled from the response.
+// Reading more than MaxDownloadSize bytes fails with ErrFileTooBig, even if the
+// response does not announce its length.
+func (c *Client) DownloadFile(ctx context.Context, file *File) (io.ReadCloser, error) {
+	if file.FileSize > MaxDownloadSize {
+		return nil, ErrFileTooBig
+	}
+	if file.FilePath == "" {
+		return nil, fmt.Errorf("telegram download %s: file has no path, resolve it with GetFile", file.FileID)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.FileURL(file), nil)
+	if err != nil {
+		return nil, fmt.Errorf("telegram download %s: %w", file.FileID, c.redact(err))
+	}
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("telegram download %s: %w", file.FileID, c.redact(err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("telegram download %s: %s", file.FileID, resp.Status)
+	}
+	if resp.ContentLength > MaxDownloadSize {
+		resp.Body.Close()
+		return nil, ErrFileTooBig
+	}
+	if file.FileSize == 0 && resp.ContentLength > 0 {
+		file.FileSize = resp.ContentLength
+	}
+	return &limitedBody{body: resp.Body, reader: io.LimitReader(resp.Body, MaxDownloadSize+1)}, nil
+}
+
+// limitedBody is a download that fails with ErrFileTooBig once more than
+// MaxDownloadSize bytes are read.
+type limitedBody struct {
+	body   io.ReadCloser
+	reader io.Reader
+	read   int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.read > MaxDownloadSize {
+		return 0, ErrFileTooBig
+	}
+	n, err := b.reader.Read(p)
+	b.read += int64(n)
+	if over := b.read - MaxDownloadSize; over > 0 {
+		return n - int(over), ErrFileTooBig
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// OpenFile resolves fileID (e.g. PhotoSize.FileID or Document.FileID) and opens
+// its content, the caller must close it.
+func (c *Client) OpenFile(ctx context.Context, fileID string) (io.ReadCloser, *File, error) {
+	file, err := c.GetFile(ctx, &GetFileRequest{FileID: fileID})
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := c.DownloadFile(ctx, file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, file, nil
+}
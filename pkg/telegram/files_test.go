@@ -0,0 +1,137 @@
+package telegram_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/telegramtest"
+)
+
+func TestOpenFile(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+	fileID := server.AddFile("ticket.jpg", []byte("picture"))
+
+	file, err := client.GetFile(ctx, &telegram.GetFileRequest{FileID: fileID})
+	if err != nil || file.FilePath == "" || file.FileSize != 7 {
+		t.Fatalf("GetFile() = %+v, %v, want the file path and size", file, err)
+	}
+	if url := client.FileURL(file); !strings.Contains(url, "/file/bot"+telegramtest.Token+"/"+file.FilePath) {
+		t.Errorf("FileURL() = %q, want the download route", url)
+	}
+
+	body, opened, err := client.OpenFile(ctx, fileID)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	defer body.Close()
+	if content, err := io.ReadAll(body); err != nil || string(content) != "picture" {
+		t.Errorf("content = %q, %v, want %q", content, err, "picture")
+	}
+	if opened.FileID != fileID {
+		t.Errorf("File = %+v, want %s", opened, fileID)
+	}
+
+	if _, _, err := client.OpenFile(ctx, "unknown"); err == nil {
+		t.Error("OpenFile() of an unknown file error = nil")
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+	file, err := client.GetFile(ctx, &telegram.GetFileRequest{FileID: server.AddFile("ticket.jpg", []byte("picture"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The size is filled from the response when unknown
+	file.FileSize = 0
+	body, err := client.DownloadFile(ctx, file)
+	if err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	body.Close()
+	if file.FileSize != 7 {
+		t.Errorf("FileSize = %d, want 7", file.FileSize)
+	}
+
+	tests := []struct {
+		name string
+		file *telegram.File
+		want error
+	}{
+		{"too big", &telegram.File{FileID: "big", FilePath: file.FilePath, FileSize: telegram.MaxDownloadSize + 1}, telegram.ErrFileTooBig},
+		{"without path", &telegram.File{FileID: "unresolved"}, nil},
+		{"not found", &telegram.File{FileID: "missing", FilePath: "photos/missing.jpg"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := client.DownloadFile(ctx, tt.file)
+			if err == nil {
+				body.Close()
+				t.Fatal("DownloadFile() error = nil")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("DownloadFile() error = %v, want %v", err, tt.want)
+			}
+			if strings.Contains(err.Error(), telegramtest.Token) {
+				t.Errorf("DownloadFile() error = %v, want the token redacted", err)
+			}
+		})
+	}
+}
+
+func TestDownloadFileLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		size          int
+		contentLength bool
+		want          error
+	}{
+		{"chunked at the limit", telegram.MaxDownloadSize, false, nil},
+		{"chunked over the limit", telegram.MaxDownloadSize + 1, false, telegram.ErrFileTooBig},
+		{"announced over the limit", telegram.MaxDownloadSize + 1, true, telegram.ErrFileTooBig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentLength {
+					w.Header().Set("Content-Length", strconv.Itoa(tt.size))
+				}
+				chunk := bytes.Repeat([]byte("a"), 64<<10)
+				for written := 0; written < tt.size; written += len(chunk) {
+					w.Write(chunk[:min(len(chunk), tt.size-written)])
+					w.(http.Flusher).Flush()
+				}
+			}))
+			defer server.Close()
+			client := telegram.NewClient(telegramtest.Token, telegram.WithBaseURL(server.URL))
+
+			body, err := client.DownloadFile(context.Background(), &telegram.File{FileID: "f", FilePath: "documents/f.pdf"})
+			if err == nil {
+				var n int64
+				n, err = io.Copy(io.Discard, body)
+				body.Close()
+				if err == nil && n != int64(tt.size) {
+					t.Errorf("read %d bytes, want %d", n, tt.size)
+				}
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("DownloadFile() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+var (
+	markdownV2Replacer = newBackslashReplacer("\\_*[]()~`>#+-=|{}.!")
+	// Inside code and pre entities only "`" and "\" must be escaped.
+	markdownV2CodeReplacer = newBackslashReplacer("\\`")
+	// Inside the (...) part of inline links only ")" and "\" must be escaped.
+	markdownV2URLReplacer = newBackslashReplacer("\\)")
+
+	htmlReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+)
+
+// Escape escapes s so it is shown literally when sent with parseMode.
+// Text for an empty parse mode is returned unchanged.
+func Escape(parseMode, s string) string {
+	switch parseMode {
+	case telegram.ParseModeMarkdownV2:
+		return escapeMarkdownV2(s)
+	case telegram.ParseModeHTML:
+		return escapeHTML(s)
+	default:
+		return s
+	}
+}
+
+func escapeMarkdownV2(s string) string {
+	return markdownV2Replacer.Replace(s)
+}
+
+func escapeMarkdownV2Code(s string) string {
+	return markdownV2CodeReplacer.Replace(s)
+}
+
+func escapeMarkdownV2URL(s string) string {
+	return markdownV2URLReplacer.Replace(s)
+}
+
+func escapeHTML(s string) string {
+	return htmlReplacer.Replace(s)
+}
+
+// newBackslashReplacer builds a replacer that prefixes each of chars with a backslash.
+func newBackslashReplacer(chars string) *strings.Replacer {
+	pairs := make([]string, 0, 2*len(chars))
+	for _, c := range chars {
+		pairs = append(pairs, string(c), "\\"+string(c))
+	}
+	return strings.NewReplacer(pairs...)
+}
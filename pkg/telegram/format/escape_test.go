@@ -0,0 +1,37 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+func TestEscapeMarkdownV2ReservedCharacters(t *testing.T) {
+	for _, c := range "\\_*[]()~`>#+-=|{}.!" {
+		if got, want := Escape(telegram.ParseModeMarkdownV2, string(c)), "\\"+string(c); got != want {
+			t.Errorf("Escape(MarkdownV2, %q) = %q, want %q", c, got, want)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		parseMode string
+		in        string
+		want      string
+	}{
+		{telegram.ParseModeMarkdownV2, "Total: $1,200.50 (renta)", `Total: $1,200\.50 \(renta\)`},
+		{telegram.ParseModeMarkdownV2, "café 😀 sin cambios", "café 😀 sin cambios"},
+		{telegram.ParseModeMarkdownV2, `C:\tickets\*.pdf`, `C:\\tickets\\\*\.pdf`},
+		{telegram.ParseModeHTML, "<b>Tom & Jerry</b>", "&lt;b&gt;Tom &amp; Jerry&lt;/b&gt;"},
+		{telegram.ParseModeHTML, `a "quote"`, "a &quot;quote&quot;"},
+		{telegram.ParseModeHTML, "&amp;", "&amp;amp;"},
+		{telegram.ParseModeHTML, "*_[]`", "*_[]`"},
+		{"", "<b>*raw*</b>", "<b>*raw*</b>"},
+	}
+	for _, tt := range tests {
+		if got := Escape(tt.parseMode, tt.in); got != tt.want {
+			t.Errorf("Escape(%q, %q) = %q, want %q", tt.parseMode, tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+// Package format builds Telegram message texts that are safe to send whatever
+// user content they contain.
+//
+// A Builder escapes every piece of text for the chosen parse mode, or with an
+// empty parse mode produces plain text plus the []telegram.MessageEntity that
+// describe the formatting, so no escaping is needed at all:
+//
+//	b := format.New(telegram.ParseModeMarkdownV2).
+//		Bold("Total: ").Text(amount).Newline().
+//		Italic(description)
+//	b.Apply(&req)
+package format
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// Builder accumulates formatted text for a parse mode.
+// The zero value is not usable, create builders with New.
+type Builder struct {
+	parseMode string
+	text      strings.Builder
+	entities  []telegram.MessageEntity
+	// length is the length of text in UTF-16 code units, used for entity offsets.
+	length int
+}
+
+// New creates a Builder for parseMode: telegram.ParseModeMarkdownV2,
+// telegram.ParseModeHTML, or "" for plain text with entities.
+func New(parseMode string) *Builder {
+	return &Builder{parseMode: parseMode}
+}
+
+// Text appends unformatted text.
+func (b *Builder) Text(s string) *Builder {
+	b.write(Escape(b.parseMode, s))
+	return b
+}
+
+// Newline appends a line break.
+func (b *Builder) Newline() *Builder {
+	b.write("\n")
+	return b
+}
+
+// Bold appends bold text.
+func (b *Builder) Bold(s string) *Builder {
+	return b.span(telegram.EntityBold, s)
+}
+
+// Italic appends italic text.
+func (b *Builder) Italic(s string) *Builder {
+	return b.span(telegram.EntityItalic, s)
+}
+
+// Underline appends underlined text.
+func (b *Builder) Underline(s string) *Builder {
+	return b.span(telegram.EntityUnderline, s)
+}
+
+// Strikethrough appends strikethrough text.
+func (b *Builder) Strikethrough(s string) *Builder {
+	return b.span(telegram.EntityStrikethrough, s)
+}
+
+// Spoiler appends text hidden behind a spoiler.
+func (b *Builder) Spoiler(s string) *Builder {
+	return b.span(telegram.EntitySpoiler, s)
+}
+
+// Code appends inline monospace text.
+func (b *Builder) Code(s string) *Builder {
+	if s == "" {
+		return b
+	}
+	switch b.parseMode {
+	case telegram.ParseModeMarkdownV2:
+		b.write("`" + escapeMarkdownV2Code(s) + "`")
+	case telegram.ParseModeHTML:
+		b.write("<code>" + escapeHTML(s) + "</code>")
+	default:
+		b.entity(telegram.MessageEntity{Type: telegram.EntityCode}, s)
+	}
+	return b
+}
+
+// Pre appends a pre-formatted code block, language is optional.
+// Characters that cannot be part of a language name are dropped from language.
+func (b *Builder) Pre(code, language string) *Builder {
+	if code == "" {
+		return b
+	}
+	language = cleanLanguage(language)
+	switch b.parseMode {
+	case telegram.ParseModeMarkdownV2:
+		b.write("```" + language + "\n" + escapeMarkdownV2Code(code) + "\n```")
+	case telegram.ParseModeHTML:
+		if language == "" {
+			b.write("<pre>" + escapeHTML(code) + "</pre>")
+		} else {
+			b.write(`<pre><code class="language-` + escapeHTML(language) + `">` + escapeHTML(code) + "</code></pre>")
+		}
+	default:
+		b.entity(telegram.MessageEntity{Type: telegram.EntityPre, Language: language}, code)
+	}
+	return b
+}
+
+// Link appends text that links to url.
+func (b *Builder) Link(text, url string) *Builder {
+	if text == "" {
+		return b
+	}
+	switch b.parseMode {
+	case telegram.ParseModeMarkdownV2:
+		b.write("[" + escapeMarkdownV2(text) + "](" + escapeMarkdownV2URL(url) + ")")
+	case telegram.ParseModeHTML:
+		b.write(`<a href="` + escapeHTML(url) + `">` + escapeHTML(text) + "</a>")
+	default:
+		b.entity(telegram.MessageEntity{Type: telegram.EntityTextLink, URL: url}, text)
+	}
+	return b
+}
+
+// Blockquote appends a block quotation, it should start on its own line.
+func (b *Builder) Blockquote(s string) *Builder {
+	if s == "" {
+		return b
+	}
+	switch b.parseMode {
+	case telegram.ParseModeMarkdownV2:
+		lines := strings.Split(s, "\n")
+		for i, line := range lines {
+			lines[i] = ">" + escapeMarkdownV2(line)
+		}
+		b.write(strings.Join(lines, "\n"))
+	case telegram.ParseModeHTML:
+		b.write("<blockquote>" + escapeHTML(s) + "</blockquote>")
+	default:
+		b.entity(telegram.MessageEntity{Type: telegram.EntityBlockquote}, s)
+	}
+	return b
+}
+
+// Nest appends the text built by fn with the style of entityType, one of
+// telegram.EntityBold, EntityItalic, EntityUnderline, EntityStrikethrough or
+// EntitySpoiler, so styles can be combined:
+//
+//	b.Nest(telegram.EntityBold, func(b *format.Builder) {
+//		b.Text("Total: ").Italic(amount)
+//	})
+func (b *Builder) Nest(entityType string, fn func(b *Builder)) *Builder {
+	markers, ok := spanMarkers[entityType]
+	if !ok {
+		panic("format: cannot nest entity type " + entityType)
+	}
+	inner := New(b.parseMode)
+	fn(inner)
+	if inner.length == 0 {
+		return b
+	}
+
+	switch b.parseMode {
+	case telegram.ParseModeMarkdownV2:
+		b.writeMarker(markers.mdOpen)
+		b.write(inner.String())
+		b.writeMarker(markers.mdClose)
+	case telegram.ParseModeHTML:
+		b.write(markers.htmlOpen + inner.String() + markers.htmlClose)
+	default:
+		offset := b.length
+		b.entities = append(b.entities, telegram.MessageEntity{Type: entityType, Offset: offset, Length: inner.length})
+		for _, entity := range inner.entities {
+			entity.Offset += offset
+			b.entities = append(b.entities, entity)
+		}
+		b.write(inner.String())
+	}
+	return b
+}
+
+// String returns the built text.
+func (b *Builder) String() string {
+	return b.text.String()
+}
+
+// ParseMode returns the parse mode the text was built for.
+func (b *Builder) ParseMode() string {
+	return b.parseMode
+}
+
+// Entities returns the formatting entities, only set when the parse mode is empty.
+func (b *Builder) Entities() []telegram.MessageEntity {
+	return b.entities
+}
+
+// Apply sets the text, parse mode and entities of req.
+func (b *Builder) Apply(req *telegram.SendMessageRequest) {
+	req.Text = b.String()
+	req.ParseMode = b.parseMode
+	req.Entities = b.entities
+}
+
+// markers are the opening and closing markers of a style in each parse mode.
+type markers struct {
+	mdOpen, mdClose     string
+	htmlOpen, htmlClose string
+}
+
+// spanMarkers are the markers of the styles that Nest and span can apply.
+var spanMarkers = map[string]markers{
+	telegram.EntityBold:          {"*", "*", "<b>", "</b>"},
+	telegram.EntityItalic:        {"_", "_", "<i>", "</i>"},
+	telegram.EntityUnderline:     {"__", "__", "<u>", "</u>"},
+	telegram.EntityStrikethrough: {"~", "~", "<s>", "</s>"},
+	telegram.EntitySpoiler:       {"||", "||", "<tg-spoiler>", "</tg-spoiler>"},
+}
+
+// span appends s wrapped in the markers of the parse mode, or as an entity of entityType.
+// An empty s appends nothing, since Telegram rejects empty markers like "**".
+func (b *Builder) span(entityType, s string) *Builder {
+	if s == "" {
+		return b
+	}
+	markers := spanMarkers[entityType]
+	switch b.parseMode {
+	case telegram.ParseModeMarkdownV2:
+		b.writeMarker(markers.mdOpen)
+		b.write(escapeMarkdownV2(s))
+		b.writeMarker(markers.mdClose)
+	case telegram.ParseModeHTML:
+		b.write(markers.htmlOpen + escapeHTML(s) + markers.htmlClose)
+	default:
+		b.entity(telegram.MessageEntity{Type: entityType}, s)
+	}
+	return b
+}
+
+// entity appends s as plain text covered by entity.
+func (b *Builder) entity(entity telegram.MessageEntity, s string) {
+	entity.Offset = b.length
+	entity.Length = telegram.UTF16Len(s)
+	if entity.Length > 0 {
+		b.entities = append(b.entities, entity)
+	}
+	b.write(s)
+}
+
+// writeMarker appends a MarkdownV2 marker. A "\r", which Telegram ignores, separates
+// markers starting with "_" from a preceding "_", otherwise the end of an italic
+// span followed by the end of an underline span ("___") would be read as underline first.
+func (b *Builder) writeMarker(marker string) {
+	if strings.HasPrefix(marker, "_") && strings.HasSuffix(b.text.String(), "_") {
+		b.write("\r")
+	}
+	b.write(marker)
+}
+
+// cleanLanguage keeps only letters, digits and "+#-_." of a code block language,
+// e.g. "c++" or "objective-c", so it cannot end the language line or the block.
+func cleanLanguage(language string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("+#-_.", r) {
+			return r
+		}
+		return -1
+	}, language)
+}
+
+// write appends already formatted text.
+func (b *Builder) write(s string) {
+	b.text.WriteString(s)
+	b.length += telegram.UTF16Len(s)
+}
@@ -0,0 +1,186 @@
+package format
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    func(b *Builder)
+		markdown string
+		html     string
+		plain    string
+		entities []telegram.MessageEntity
+	}{
+		{
+			name: "styles",
+			build: func(b *Builder) {
+				b.Bold("Total:").Text(" ").Italic("1.5").Text(" ").Underline("u").Strikethrough("s").Spoiler("x")
+			},
+			markdown: `*Total:* _1\.5_ __u__~s~||x||`,
+			html:     "<b>Total:</b> <i>1.5</i> <u>u</u><s>s</s><tg-spoiler>x</tg-spoiler>",
+			plain:    "Total: 1.5 usx",
+			entities: []telegram.MessageEntity{
+				{Type: telegram.EntityBold, Offset: 0, Length: 6},
+				{Type: telegram.EntityItalic, Offset: 7, Length: 3},
+				{Type: telegram.EntityUnderline, Offset: 11, Length: 1},
+				{Type: telegram.EntityStrikethrough, Offset: 12, Length: 1},
+				{Type: telegram.EntitySpoiler, Offset: 13, Length: 1},
+			},
+		},
+		{
+			name:     "user content",
+			build:    func(b *Builder) { b.Bold("<a> & *b*").Newline().Text("1+1=2!") },
+			markdown: "*<a\\> & \\*b\\**\n1\\+1\\=2\\!",
+			html:     "<b>&lt;a&gt; &amp; *b*</b>\n1+1=2!",
+			plain:    "<a> & *b*\n1+1=2!",
+			entities: []telegram.MessageEntity{{Type: telegram.EntityBold, Offset: 0, Length: 9}},
+		},
+		{
+			name:     "offsets after emoji",
+			build:    func(b *Builder) { b.Text("😀 ").Bold("café") },
+			markdown: "😀 *café*",
+			html:     "😀 <b>café</b>",
+			plain:    "😀 café",
+			entities: []telegram.MessageEntity{{Type: telegram.EntityBold, Offset: 3, Length: 4}},
+		},
+		{
+			name: "empty spans",
+			build: func(b *Builder) {
+				b.Text("a").Bold("").Italic("").Code("").Pre("", "go").Link("", "https://x.mx").Blockquote("").Text("b")
+			},
+			markdown: "ab",
+			html:     "ab",
+			plain:    "ab",
+		},
+		{
+			name:     "code",
+			build:    func(b *Builder) { b.Code("a`b\\c*d<e>") },
+			markdown: "`a\\`b\\\\c*d<e>`",
+			html:     "<code>a`b\\c*d&lt;e&gt;</code>",
+			plain:    "a`b\\c*d<e>",
+			entities: []telegram.MessageEntity{{Type: telegram.EntityCode, Offset: 0, Length: 10}},
+		},
+		{
+			name:     "pre",
+			build:    func(b *Builder) { b.Pre("x := `y`", "go") },
+			markdown: "```go\nx := \\`y\\`\n```",
+			html:     `<pre><code class="language-go">x := ` + "`y`" + `</code></pre>`,
+			plain:    "x := `y`",
+			entities: []telegram.MessageEntity{{Type: telegram.EntityPre, Offset: 0, Length: 8, Language: "go"}},
+		},
+		{
+			name:     "pre language",
+			build:    func(b *Builder) { b.Pre("1", "c++ `\n```\"><x") },
+			markdown: "```c++x\n1\n```",
+			html:     `<pre><code class="language-c++x">1</code></pre>`,
+			plain:    "1",
+			entities: []telegram.MessageEntity{{Type: telegram.EntityPre, Offset: 0, Length: 1, Language: "c++x"}},
+		},
+		{
+			name:     "link",
+			build:    func(b *Builder) { b.Link("[ticket]", `https://x.mx/a_(b)?q="1"`) },
+			markdown: `[\[ticket\]](https://x.mx/a_(b\)?q="1")`,
+			html:     `<a href="https://x.mx/a_(b)?q=&quot;1&quot;">[ticket]</a>`,
+			plain:    "[ticket]",
+			entities: []telegram.MessageEntity{{Type: telegram.EntityTextLink, Offset: 0, Length: 8, URL: `https://x.mx/a_(b)?q="1"`}},
+		},
+		{
+			name:     "blockquote",
+			build:    func(b *Builder) { b.Blockquote("uno.\ndos!") },
+			markdown: ">uno\\.\n>dos\\!",
+			html:     "<blockquote>uno.\ndos!</blockquote>",
+			plain:    "uno.\ndos!",
+			entities: []telegram.MessageEntity{{Type: telegram.EntityBlockquote, Offset: 0, Length: 9}},
+		},
+		{
+			name: "nested styles",
+			build: func(b *Builder) {
+				b.Text("> ").Nest(telegram.EntityBold, func(b *Builder) {
+					b.Text("Total: ").Italic("$5.00").Text(" ").Code("x")
+				})
+			},
+			markdown: "\\> *Total: _$5\\.00_ `x`*",
+			html:     "&gt; <b>Total: <i>$5.00</i> <code>x</code></b>",
+			plain:    "> Total: $5.00 x",
+			entities: []telegram.MessageEntity{
+				{Type: telegram.EntityBold, Offset: 2, Length: 14},
+				{Type: telegram.EntityItalic, Offset: 9, Length: 5},
+				{Type: telegram.EntityCode, Offset: 15, Length: 1},
+			},
+		},
+		{
+			name: "italic inside underline",
+			build: func(b *Builder) {
+				b.Nest(telegram.EntityUnderline, func(b *Builder) { b.Italic("iu") })
+			},
+			markdown: "___iu_\r__",
+			html:     "<u><i>iu</i></u>",
+			plain:    "iu",
+			entities: []telegram.MessageEntity{
+				{Type: telegram.EntityUnderline, Offset: 0, Length: 2},
+				{Type: telegram.EntityItalic, Offset: 0, Length: 2},
+			},
+		},
+		{
+			name: "empty nest",
+			build: func(b *Builder) {
+				b.Text("a").Nest(telegram.EntityBold, func(b *Builder) { b.Italic("") })
+			},
+			markdown: "a",
+			html:     "a",
+			plain:    "a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for parseMode, want := range map[string]string{
+				telegram.ParseModeMarkdownV2: tt.markdown,
+				telegram.ParseModeHTML:       tt.html,
+				"":                           tt.plain,
+			} {
+				b := New(parseMode)
+				tt.build(b)
+				if got := b.String(); got != want {
+					t.Errorf("%q: String() = %q, want %q", parseMode, got, want)
+				}
+				if parseMode != "" && b.Entities() != nil {
+					t.Errorf("%q: Entities() = %+v, want none", parseMode, b.Entities())
+				}
+			}
+
+			b := New("")
+			tt.build(b)
+			if got := b.Entities(); !reflect.DeepEqual(got, tt.entities) {
+				t.Errorf("Entities() = %+v, want %+v", got, tt.entities)
+			}
+			for _, entity := range b.Entities() {
+				if end := entity.Offset + entity.Length; end > telegram.UTF16Len(b.String()) {
+					t.Errorf("entity %+v ends after the text", entity)
+				}
+			}
+		})
+	}
+}
+
+func TestBuilderApply(t *testing.T) {
+	b := New("").Text("Gasto ").Bold("$23.50")
+	var req telegram.SendMessageRequest
+	b.Apply(&req)
+	if req.Text != "Gasto $23.50" || req.ParseMode != "" || len(req.Entities) != 1 {
+		t.Errorf("Apply() = %+v", req)
+	}
+}
+
+func TestNestUnsupportedType(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Nest(code) did not panic")
+		}
+	}()
+	New("").Nest(telegram.EntityCode, func(b *Builder) { b.Text("x") })
+}
@@ -0,0 +1,149 @@
+package keyboard
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxCallbackDataLength is the maximum size of callback_data, in bytes.
+const MaxCallbackDataLength = 64
+
+// defaultSignatureLength is the number of HMAC bytes kept, 8 bytes take 11 characters.
+const defaultSignatureLength = 8
+
+// callbackSeparator separates the action, the arguments and the signature.
+const callbackSeparator = "|"
+
+var (
+	// ErrCallbackDataTooLong is returned when the encoded data exceeds MaxCallbackDataLength.
+	ErrCallbackDataTooLong = errors.New("callback data exceeds 64 bytes")
+	// ErrInvalidCallbackData is returned when the data cannot be decoded.
+	ErrInvalidCallbackData = errors.New("invalid callback data")
+	// ErrInvalidSignature is returned when signed data was not produced with the codec key.
+	ErrInvalidSignature = errors.New("invalid callback data signature")
+)
+
+// CallbackData is an action and its arguments packed in a button callback_data,
+// encoded as "action|arg1|arg2".
+type CallbackData struct {
+	Action string
+	Args   []string
+}
+
+// NewCallbackData creates CallbackData, integer arguments are encoded with FormatID.
+func NewCallbackData(action string, args ...interface{}) CallbackData {
+	data := CallbackData{Action: action}
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case int64:
+			data.Args = append(data.Args, FormatID(v))
+		case int:
+			data.Args = append(data.Args, FormatID(int64(v)))
+		default:
+			data.Args = append(data.Args, fmt.Sprint(v))
+		}
+	}
+	return data
+}
+
+// ID parses the argument i as an integer encoded with FormatID.
+func (d CallbackData) ID(i int) (int64, error) {
+	if i < 0 || i >= len(d.Args) {
+		return 0, fmt.Errorf("%w: missing argument %d", ErrInvalidCallbackData, i)
+	}
+	id, err := ParseID(d.Args[i])
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidCallbackData, err)
+	}
+	return id, nil
+}
+
+// FormatID encodes an integer in base 36 to save callback data space.
+func FormatID(id int64) string {
+	return strconv.FormatInt(id, 36)
+}
+
+// ParseID decodes an integer encoded with FormatID.
+func ParseID(s string) (int64, error) {
+	return strconv.ParseInt(s, 36, 64)
+}
+
+// CallbackCodec encodes CallbackData into callback_data strings.
+//
+// With a Key, an HMAC-SHA256 of the data and a scope is appended so callback
+// data forged by modified clients is rejected on Decode. The scope is the ID
+// of the chat or user the buttons are meant for: it is not part of the
+// callback data, but data signed for one scope is rejected in any other.
+type CallbackCodec struct {
+	// Key signs the data, leave it empty for unsigned data.
+	Key []byte
+	// SignatureLength is the number of HMAC bytes kept (default: 8).
+	SignatureLength int
+}
+
+// Encode packs data for scope, it fails if the result exceeds MaxCallbackDataLength or
+// a field contains the "|" separator.
+func (c *CallbackCodec) Encode(scope int64, data CallbackData) (string, error) {
+	fields := append([]string{data.Action}, data.Args...)
+	for _, field := range fields {
+		if strings.Contains(field, callbackSeparator) {
+			return "", fmt.Errorf("%w: %q contains %q", ErrInvalidCallbackData, field, callbackSeparator)
+		}
+	}
+
+	encoded := strings.Join(fields, callbackSeparator)
+	if len(c.Key) > 0 {
+		encoded += callbackSeparator + c.sign(scope, encoded)
+	}
+	if len(encoded) > MaxCallbackDataLength {
+		return "", fmt.Errorf("%w: %d bytes", ErrCallbackDataTooLong, len(encoded))
+	}
+	return encoded, nil
+}
+
+// MustEncode is like Encode but panics on error, for data known to fit at compile time.
+func (c *CallbackCodec) MustEncode(scope int64, data CallbackData) string {
+	encoded, err := c.Encode(scope, data)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// Decode unpacks callback data produced by Encode for scope, verifying its signature
+// when the codec has a Key.
+func (c *CallbackCodec) Decode(scope int64, s string) (CallbackData, error) {
+	if s == "" {
+		return CallbackData{}, ErrInvalidCallbackData
+	}
+	if len(c.Key) > 0 {
+		i := strings.LastIndex(s, callbackSeparator)
+		if i < 0 {
+			return CallbackData{}, ErrInvalidSignature
+		}
+		payload, signature := s[:i], s[i+1:]
+		if !hmac.Equal([]byte(signature), []byte(c.sign(scope, payload))) {
+			return CallbackData{}, ErrInvalidSignature
+		}
+		s = payload
+	}
+
+	fields := strings.Split(s, callbackSeparator)
+	return CallbackData{Action: fields[0], Args: fields[1:]}, nil
+}
+
+// sign returns the truncated base64url HMAC of scope and payload.
+func (c *CallbackCodec) sign(scope int64, payload string) string {
+	mac := hmac.New(sha256.New, c.Key)
+	mac.Write([]byte(FormatID(scope) + callbackSeparator + payload))
+	length := c.SignatureLength
+	if length <= 0 || length > sha256.Size {
+		length = defaultSignatureLength
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:length])
+}
@@ -0,0 +1,112 @@
+package keyboard
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCallbackRoundTrip(t *testing.T) {
+	codecs := map[string]*CallbackCodec{
+		"unsigned":         {},
+		"signed":           {Key: []byte("secret")},
+		"longer signature": {Key: []byte("secret"), SignatureLength: 16},
+	}
+	tests := []CallbackData{
+		NewCallbackData("draft.ok", "20240320-1ab2c3-5f"),
+		NewCallbackData("page", int64(-1001234567890), 3),
+		{Action: "noop"},
+	}
+	for name, codec := range codecs {
+		for _, data := range tests {
+			encoded, err := codec.Encode(42, data)
+			if err != nil {
+				t.Fatalf("%s: Encode(%+v) error = %v", name, data, err)
+			}
+			decoded, err := codec.Decode(42, encoded)
+			if err != nil || decoded.Action != data.Action || !slices.Equal(decoded.Args, data.Args) {
+				t.Errorf("%s: Decode(%q) = %+v, %v, want %+v", name, encoded, decoded, err, data)
+			}
+		}
+	}
+}
+
+func TestCallbackID(t *testing.T) {
+	data := NewCallbackData("page", int64(-1001234567890), "no-id")
+	if id, err := data.ID(0); err != nil || id != -1001234567890 {
+		t.Errorf("ID(0) = %d, %v, want -1001234567890", id, err)
+	}
+	for _, i := range []int{1, 2, -1} {
+		if _, err := data.ID(i); !errors.Is(err, ErrInvalidCallbackData) {
+			t.Errorf("ID(%d) error = %v, want ErrInvalidCallbackData", i, err)
+		}
+	}
+}
+
+func TestCallbackDecodeRejects(t *testing.T) {
+	codec := &CallbackCodec{Key: []byte("secret")}
+	encoded := codec.MustEncode(42, NewCallbackData("draft.del", "20240320-1ab2c3-5f"))
+	i := strings.LastIndex(encoded, callbackSeparator)
+	payload, signature := encoded[:i], encoded[i+1:]
+	flipped := []byte(signature)
+	flipped[0] ^= 1
+
+	tests := []struct {
+		name  string
+		codec *CallbackCodec
+		scope int64
+		data  string
+		want  error
+	}{
+		{"another scope", codec, 43, encoded, ErrInvalidSignature},
+		{"another key", &CallbackCodec{Key: []byte("other")}, 42, encoded, ErrInvalidSignature},
+		{"tampered payload", codec, 42, "draft.ok" + encoded[len("draft.del"):], ErrInvalidSignature},
+		{"tampered signature", codec, 42, payload + callbackSeparator + string(flipped), ErrInvalidSignature},
+		{"truncated signature", codec, 42, encoded[:len(encoded)-1], ErrInvalidSignature},
+		{"without signature", codec, 42, payload, ErrInvalidSignature},
+		{"without separator", codec, 42, "noop", ErrInvalidSignature},
+		{"unsigned", codec, 42, (&CallbackCodec{}).MustEncode(42, NewCallbackData("draft.del", "x")), ErrInvalidSignature},
+		{"empty", codec, 42, "", ErrInvalidCallbackData},
+		{"empty unsigned", &CallbackCodec{}, 42, "", ErrInvalidCallbackData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.codec.Decode(tt.scope, tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("Decode(%d, %q) error = %v, want %v", tt.scope, tt.data, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallbackEncodeLimits(t *testing.T) {
+	signed := &CallbackCodec{Key: []byte("secret")}
+	// An 8-byte signature takes 11 characters plus the separator
+	fits := NewCallbackData(strings.Repeat("a", MaxCallbackDataLength-12))
+	encoded, err := signed.Encode(42, fits)
+	if err != nil || len(encoded) != MaxCallbackDataLength {
+		t.Errorf("Encode() = %q (%d bytes), %v, want exactly %d bytes", encoded, len(encoded), err, MaxCallbackDataLength)
+	}
+	if _, err := signed.Encode(42, NewCallbackData(fits.Action+"a")); !errors.Is(err, ErrCallbackDataTooLong) {
+		t.Errorf("Encode() of 65 bytes error = %v, want ErrCallbackDataTooLong", err)
+	}
+	unsigned := &CallbackCodec{}
+	if _, err := unsigned.Encode(42, NewCallbackData(strings.Repeat("a", MaxCallbackDataLength))); err != nil {
+		t.Errorf("Encode() of 64 unsigned bytes error = %v", err)
+	}
+	if _, err := unsigned.Encode(42, NewCallbackData("a", strings.Repeat("b", MaxCallbackDataLength-1))); !errors.Is(err, ErrCallbackDataTooLong) {
+		t.Errorf("Encode() of 65 unsigned bytes error = %v, want ErrCallbackDataTooLong", err)
+	}
+	if _, err := unsigned.Encode(42, NewCallbackData("a", "b|c")); !errors.Is(err, ErrInvalidCallbackData) {
+		t.Errorf("Encode() with a separator error = %v, want ErrInvalidCallbackData", err)
+	}
+}
+
+func TestCallbackMustEncodePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustEncode() did not panic")
+		}
+	}()
+	(&CallbackCodec{}).MustEncode(42, NewCallbackData(strings.Repeat("a", MaxCallbackDataLength+1)))
+}
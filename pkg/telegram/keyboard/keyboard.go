@@ -0,0 +1,168 @@
+// Package keyboard builds inline and reply keyboards and packs actions into
+// callback data.
+//
+//	markup := keyboard.NewInline().
+//		Row(keyboard.Callback("🍔 Comida", "cat|food")).
+//		Pagination(page, pages, func(p int) string { return "page|" + strconv.Itoa(p) }).
+//		Build()
+package keyboard
+
+import (
+	"fmt"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// Default labels of the preset buttons.
+var (
+	ConfirmText  = "✅ Confirmar"
+	CancelText   = "❌ Cancelar"
+	PreviousText = "‹"
+	NextText     = "›"
+)
+
+// NoopData is the callback data of buttons that only display information,
+// like the page counter of a pagination row. Handlers should just answer it.
+const NoopData = "noop"
+
+// Callback creates an inline button that sends data in a callback query.
+func Callback(text, data string) telegram.InlineKeyboardButton {
+	return telegram.InlineKeyboardButton{Text: text, CallbackData: data}
+}
+
+// URL creates an inline button that opens url.
+func URL(text, url string) telegram.InlineKeyboardButton {
+	return telegram.InlineKeyboardButton{Text: text, URL: url}
+}
+
+// InlineBuilder builds an inline keyboard row by row.
+type InlineBuilder struct {
+	rows [][]telegram.InlineKeyboardButton
+}
+
+// NewInline creates an empty InlineBuilder.
+func NewInline() *InlineBuilder {
+	return &InlineBuilder{}
+}
+
+// Row appends a row with buttons, empty rows are ignored.
+func (b *InlineBuilder) Row(buttons ...telegram.InlineKeyboardButton) *InlineBuilder {
+	if len(buttons) > 0 {
+		b.rows = append(b.rows, buttons)
+	}
+	return b
+}
+
+// Grid appends buttons in rows of at most columns buttons.
+func (b *InlineBuilder) Grid(columns int, buttons ...telegram.InlineKeyboardButton) *InlineBuilder {
+	if columns <= 0 {
+		columns = 1
+	}
+	for start := 0; start < len(buttons); start += columns {
+		b.Row(buttons[start:min(start+columns, len(buttons))]...)
+	}
+	return b
+}
+
+// Pagination appends a "‹ page/pages ›" row for the zero based page.
+// data returns the callback data that opens a page. Nothing is added for a single page.
+func (b *InlineBuilder) Pagination(page, pages int, data func(page int) string) *InlineBuilder {
+	if pages <= 1 {
+		return b
+	}
+	row := []telegram.InlineKeyboardButton{}
+	if page > 0 {
+		row = append(row, Callback(PreviousText, data(page-1)))
+	}
+	row = append(row, Callback(fmt.Sprintf("%d/%d", page+1, pages), NoopData))
+	if page < pages-1 {
+		row = append(row, Callback(NextText, data(page+1)))
+	}
+	return b.Row(row...)
+}
+
+// ConfirmCancel appends a row with the confirm and cancel buttons.
+func (b *InlineBuilder) ConfirmCancel(confirmData, cancelData string) *InlineBuilder {
+	return b.Row(Callback(ConfirmText, confirmData), Callback(CancelText, cancelData))
+}
+
+// Build returns the keyboard markup.
+func (b *InlineBuilder) Build() *telegram.InlineKeyboardMarkup {
+	rows := b.rows
+	if rows == nil {
+		rows = [][]telegram.InlineKeyboardButton{}
+	}
+	return &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// ConfirmCancel creates an inline keyboard with just the confirm and cancel buttons.
+func ConfirmCancel(confirmData, cancelData string) *telegram.InlineKeyboardMarkup {
+	return NewInline().ConfirmCancel(confirmData, cancelData).Build()
+}
+
+// ReplyBuilder builds a custom reply keyboard row by row.
+type ReplyBuilder struct {
+	markup telegram.ReplyKeyboardMarkup
+}
+
+// NewReply creates an empty ReplyBuilder for a keyboard resized to fit its buttons.
+func NewReply() *ReplyBuilder {
+	return &ReplyBuilder{markup: telegram.ReplyKeyboardMarkup{ResizeKeyboard: true}}
+}
+
+// Row appends a row with a text button for each text.
+func (b *ReplyBuilder) Row(texts ...string) *ReplyBuilder {
+	buttons := make([]telegram.KeyboardButton, 0, len(texts))
+	for _, text := range texts {
+		buttons = append(buttons, telegram.KeyboardButton{Text: text})
+	}
+	return b.Buttons(buttons...)
+}
+
+// Buttons appends a row with buttons, empty rows are ignored.
+func (b *ReplyBuilder) Buttons(buttons ...telegram.KeyboardButton) *ReplyBuilder {
+	if len(buttons) > 0 {
+		b.markup.Keyboard = append(b.markup.Keyboard, buttons)
+	}
+	return b
+}
+
+// OneTime hides the keyboard as soon as it has been used.
+func (b *ReplyBuilder) OneTime() *ReplyBuilder {
+	b.markup.OneTimeKeyboard = true
+	return b
+}
+
+// Persistent always shows the keyboard when the regular keyboard is hidden.
+func (b *ReplyBuilder) Persistent() *ReplyBuilder {
+	b.markup.IsPersistent = true
+	return b
+}
+
+// Placeholder sets the placeholder shown in the input field.
+func (b *ReplyBuilder) Placeholder(text string) *ReplyBuilder {
+	b.markup.InputFieldPlaceholder = text
+	return b
+}
+
+// Selective shows the keyboard only to mentioned users and the sender of the replied message.
+func (b *ReplyBuilder) Selective() *ReplyBuilder {
+	b.markup.Selective = true
+	return b
+}
+
+// Build returns the keyboard markup.
+func (b *ReplyBuilder) Build() *telegram.ReplyKeyboardMarkup {
+	markup := b.markup
+	return &markup
+}
+
+// Remove creates a markup that removes the current custom keyboard.
+func Remove() *telegram.ReplyKeyboardRemove {
+	return &telegram.ReplyKeyboardRemove{RemoveKeyboard: true}
+}
+
+// ForceReply creates a markup that opens a reply to the bot message, e.g. to ask for a value.
+func ForceReply(placeholder string) *telegram.ForceReply {
+	return &telegram.ForceReply{ForceReply: true, InputFieldPlaceholder: placeholder}
+}
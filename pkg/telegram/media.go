@@ -0,0 +1,209 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// InputFile is a file to send: a file_id already stored on Telegram servers,
+// an HTTP URL for Telegram to download, or new content uploaded from a Reader.
+type InputFile struct {
+	FileID string
+	URL    string
+	// Name and Reader upload new content using multipart/form-data.
+	Name   string
+	Reader io.Reader
+}
+
+// FileFromID creates an InputFile that reuses a file already stored on Telegram servers.
+func FileFromID(fileID string) *InputFile {
+	return &InputFile{FileID: fileID}
+}
+
+// FileFromURL creates an InputFile that Telegram downloads from url.
+func FileFromURL(url string) *InputFile {
+	return &InputFile{URL: url}
+}
+
+// FileFromReader creates an InputFile uploaded from r, e.g. an S3 object body.
+// The content is streamed, it is never buffered in memory.
+func FileFromReader(name string, r io.Reader) *InputFile {
+	return &InputFile{Name: name, Reader: r}
+}
+
+// MarshalJSON encodes the file as its file_id, URL or, for uploads, a placeholder
+// that the multipart request replaces with "attach://<part>".
+func (f *InputFile) MarshalJSON() ([]byte, error) {
+	switch {
+	case f.Reader != nil:
+		return json.Marshal(f.attachPlaceholder())
+	case f.FileID != "":
+		return json.Marshal(f.FileID)
+	default:
+		return json.Marshal(f.URL)
+	}
+}
+
+// attachPlaceholder identifies the upload in the encoded request until the
+// multipart request gives it a part name. The file itself is never modified,
+// so the same InputFile can be sent in concurrent requests.
+func (f *InputFile) attachPlaceholder() string {
+	return fmt.Sprintf("attach://upload-%p", f)
+}
+
+// isUpload reports if the file content must be uploaded.
+func (f *InputFile) isUpload() bool {
+	return f != nil && f.Reader != nil
+}
+
+// multipartPayload is implemented by the requests that can upload files.
+type multipartPayload interface {
+	inputFiles() []*InputFile
+}
+
+func (r *SendPhotoRequest) inputFiles() []*InputFile {
+	return []*InputFile{r.Photo}
+}
+
+func (r *SendDocumentRequest) inputFiles() []*InputFile {
+	return []*InputFile{r.Document, r.Thumbnail}
+}
+
+func (r *SendVoiceRequest) inputFiles() []*InputFile {
+	return []*InputFile{r.Voice}
+}
+
+func (r *SendMediaGroupRequest) inputFiles() []*InputFile {
+	files := make([]*InputFile, 0, 2*len(r.Media))
+	for _, media := range r.Media {
+		files = append(files, media.Media, media.Thumbnail)
+	}
+	return files
+}
+
+// uploads returns the files of payload whose content must be uploaded.
+func uploads(payload interface{}) []*InputFile {
+	withFiles, ok := payload.(multipartPayload)
+	if !ok {
+		return nil
+	}
+	var files []*InputFile
+	for _, file := range withFiles.inputFiles() {
+		if file.isUpload() {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// SendPhoto sends a photo.
+// Requests that upload content are not retried, since the Reader can only be consumed once.
+func (c *Client) SendPhoto(ctx context.Context, req *SendPhotoRequest) (*Message, error) {
+	return send[*Message](ctx, c, "sendPhoto", req)
+}
+
+// SendDocument sends a general file.
+func (c *Client) SendDocument(ctx context.Context, req *SendDocumentRequest) (*Message, error) {
+	return send[*Message](ctx, c, "sendDocument", req)
+}
+
+// SendVoice sends a voice note.
+func (c *Client) SendVoice(ctx context.Context, req *SendVoiceRequest) (*Message, error) {
+	return send[*Message](ctx, c, "sendVoice", req)
+}
+
+// SendMediaGroup sends a group of photos, videos, documents or audios as an album.
+func (c *Client) SendMediaGroup(ctx context.Context, req *SendMediaGroupRequest) ([]Message, error) {
+	return send[[]Message](ctx, c, "sendMediaGroup", req)
+}
+
+// SendChatAction shows a status like "typing" or "sending photo" for 5 seconds or until the next message.
+func (c *Client) SendChatAction(ctx context.Context, req *SendChatActionRequest) error {
+	_, err := call[bool](ctx, c, "sendChatAction", req)
+	return err
+}
+
+// filePart is an upload sent as the multipart part name.
+type filePart struct {
+	name string
+	file *InputFile
+}
+
+// newMultipartRequest builds a request that streams payload as multipart/form-data.
+// Every field of payload is sent as a form field and every upload as a file part.
+func (c *Client) newMultipartRequest(ctx context.Context, method string, payload interface{}, files []*InputFile) (*http.Request, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("telegram %s: encoding request: %w", method, err)
+	}
+	// Each upload is sent once, even if the payload references it several times
+	named := make(map[*InputFile]bool, len(files))
+	parts := make([]filePart, 0, len(files))
+	for _, file := range files {
+		if named[file] {
+			continue
+		}
+		named[file] = true
+		part := filePart{name: "file" + strconv.Itoa(len(parts)), file: file}
+		placeholder, _ := json.Marshal(file.attachPlaceholder())
+		attach, _ := json.Marshal("attach://" + part.name)
+		encoded = bytes.ReplaceAll(encoded, placeholder, attach)
+		parts = append(parts, part)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, fmt.Errorf("telegram %s: encoding request: %w", method, err)
+	}
+
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		writer.CloseWithError(writeMultipart(form, fields, parts))
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.methodURL(method), body)
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("telegram %s: %w", method, c.redact(err))
+	}
+	httpReq.Header.Set("Content-Type", form.FormDataContentType())
+	return httpReq, nil
+}
+
+// writeMultipart writes the form fields, in a stable order, followed by the file parts.
+func writeMultipart(form *multipart.Writer, fields map[string]json.RawMessage, parts []filePart) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		// Strings are sent as is, any other value as JSON
+		value := string(fields[name])
+		var s string
+		if json.Unmarshal(fields[name], &s) == nil {
+			value = s
+		}
+		if err := form.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+	for _, part := range parts {
+		w, err := form.CreateFormFile(part.name, part.file.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, part.file.Reader); err != nil {
+			return fmt.Errorf("uploading %s: %w", part.file.Name, err)
+		}
+	}
+	return form.Close()
+}
@@ -1,31 +1,151 @@
 package telegram
 
 // Update represents a Telegram update.
+// At most one of the optional fields can be present in any given update, see Kind.
 type Update struct {
-	UpdateID          int64    `json:"update_id"`
-	Message           *Message `json:"message,omitempty"`
-	EditedMessage     *Message `json:"edited_message,omitempty"`
-	ChannelPost       *Message `json:"channel_post,omitempty"`
-	EditedChannelPost *Message `json:"edited_channel_post,omitempty"`
+	UpdateID           int64                   `json:"update_id"`
+	Message            *Message                `json:"message,omitempty"`
+	EditedMessage      *Message                `json:"edited_message,omitempty"`
+	ChannelPost        *Message                `json:"channel_post,omitempty"`
+	EditedChannelPost  *Message                `json:"edited_channel_post,omitempty"`
+	MessageReaction    *MessageReactionUpdated `json:"message_reaction,omitempty"`
+	InlineQuery        *InlineQuery            `json:"inline_query,omitempty"`
+	ChosenInlineResult *ChosenInlineResult     `json:"chosen_inline_result,omitempty"`
+	CallbackQuery      *CallbackQuery          `json:"callback_query,omitempty"`
+	Poll               *Poll                   `json:"poll,omitempty"`
+	PollAnswer         *PollAnswer             `json:"poll_answer,omitempty"`
+	MyChatMember       *ChatMemberUpdated      `json:"my_chat_member,omitempty"`
+	ChatMember         *ChatMemberUpdated      `json:"chat_member,omitempty"`
+	ChatJoinRequest    *ChatJoinRequest        `json:"chat_join_request,omitempty"`
+}
+
+// UpdateKind identifies the optional field set in an Update.
+// Values match the JSON field names, so they can also be used in allowed_updates.
+type UpdateKind string
+
+// Update kinds.
+const (
+	UpdateKindUnknown            UpdateKind = ""
+	UpdateKindMessage            UpdateKind = "message"
+	UpdateKindEditedMessage      UpdateKind = "edited_message"
+	UpdateKindChannelPost        UpdateKind = "channel_post"
+	UpdateKindEditedChannelPost  UpdateKind = "edited_channel_post"
+	UpdateKindMessageReaction    UpdateKind = "message_reaction"
+	UpdateKindInlineQuery        UpdateKind = "inline_query"
+	UpdateKindChosenInlineResult UpdateKind = "chosen_inline_result"
+	UpdateKindCallbackQuery      UpdateKind = "callback_query"
+	UpdateKindPoll               UpdateKind = "poll"
+	UpdateKindPollAnswer         UpdateKind = "poll_answer"
+	UpdateKindMyChatMember       UpdateKind = "my_chat_member"
+	UpdateKindChatMember         UpdateKind = "chat_member"
+	UpdateKindChatJoinRequest    UpdateKind = "chat_join_request"
+)
+
+// Kind returns the kind of the update, or UpdateKindUnknown if none of the
+// modeled fields is set (e.g. an update type introduced in a newer Bot API).
+func (u *Update) Kind() UpdateKind {
+	switch {
+	case u.Message != nil:
+		return UpdateKindMessage
+	case u.EditedMessage != nil:
+		return UpdateKindEditedMessage
+	case u.ChannelPost != nil:
+		return UpdateKindChannelPost
+	case u.EditedChannelPost != nil:
+		return UpdateKindEditedChannelPost
+	case u.MessageReaction != nil:
+		return UpdateKindMessageReaction
+	case u.InlineQuery != nil:
+		return UpdateKindInlineQuery
+	case u.ChosenInlineResult != nil:
+		return UpdateKindChosenInlineResult
+	case u.CallbackQuery != nil:
+		return UpdateKindCallbackQuery
+	case u.Poll != nil:
+		return UpdateKindPoll
+	case u.PollAnswer != nil:
+		return UpdateKindPollAnswer
+	case u.MyChatMember != nil:
+		return UpdateKindMyChatMember
+	case u.ChatMember != nil:
+		return UpdateKindChatMember
+	case u.ChatJoinRequest != nil:
+		return UpdateKindChatJoinRequest
+	default:
+		return UpdateKindUnknown
+	}
+}
+
+// Chat returns the chat where the update happened, or nil if the update has
+// no chat (e.g. inline queries, polls or inaccessible callback messages).
+func (u *Update) Chat() *Chat {
+	var message *Message
+	switch {
+	case u.Message != nil:
+		message = u.Message
+	case u.EditedMessage != nil:
+		message = u.EditedMessage
+	case u.ChannelPost != nil:
+		message = u.ChannelPost
+	case u.EditedChannelPost != nil:
+		message = u.EditedChannelPost
+	case u.CallbackQuery != nil:
+		message = u.CallbackQuery.Message
+	case u.MessageReaction != nil:
+		return u.MessageReaction.Chat
+	case u.MyChatMember != nil:
+		return u.MyChatMember.Chat
+	case u.ChatMember != nil:
+		return u.ChatMember.Chat
+	case u.ChatJoinRequest != nil:
+		return u.ChatJoinRequest.Chat
+	}
+	if message == nil {
+		return nil
+	}
+	return message.Chat
 }
 
 // Message represents a Telegram message.
 type Message struct {
-	MessageID int64           `json:"message_id"`
-	From      *User           `json:"from,omitempty"`
-	Chat      *Chat           `json:"chat"`
-	Date      int64           `json:"date"`
-	EditDate  int64           `json:"edit_date,omitempty"`
-	Text      string          `json:"text,omitempty"`
-	Entities  []MessageEntity `json:"entities,omitempty"`
-	Photo     []PhotoSize     `json:"photo,omitempty"`
-	Document  *Document       `json:"document,omitempty"`
-	Video     *Video          `json:"video,omitempty"`
-	Voice     *Voice          `json:"voice,omitempty"`
-	Audio     *Audio          `json:"audio,omitempty"`
-	Sticker   *Sticker        `json:"sticker,omitempty"`
-	Location  *Location       `json:"location,omitempty"`
-	Poll      *Poll           `json:"poll,omitempty"`
+	MessageID       int64                 `json:"message_id"`
+	MessageThreadID int64                 `json:"message_thread_id,omitempty"`
+	From            *User                 `json:"from,omitempty"`
+	SenderChat      *Chat                 `json:"sender_chat,omitempty"`
+	Chat            *Chat                 `json:"chat"`
+	Date            int64                 `json:"date"`
+	EditDate        int64                 `json:"edit_date,omitempty"`
+	IsTopicMessage  bool                  `json:"is_topic_message,omitempty"`
+	ForwardOrigin   *MessageOrigin        `json:"forward_origin,omitempty"`
+	ReplyToMessage  *Message              `json:"reply_to_message,omitempty"`
+	ViaBot          *User                 `json:"via_bot,omitempty"`
+	MediaGroupID    string                `json:"media_group_id,omitempty"`
+	Text            string                `json:"text,omitempty"`
+	Entities        []MessageEntity       `json:"entities,omitempty"`
+	Photo           []PhotoSize           `json:"photo,omitempty"`
+	Document        *Document             `json:"document,omitempty"`
+	Video           *Video                `json:"video,omitempty"`
+	Voice           *Voice                `json:"voice,omitempty"`
+	Audio           *Audio                `json:"audio,omitempty"`
+	Sticker         *Sticker              `json:"sticker,omitempty"`
+	Caption         string                `json:"caption,omitempty"`
+	CaptionEntities []MessageEntity       `json:"caption_entities,omitempty"`
+	Contact         *Contact              `json:"contact,omitempty"`
+	Location        *Location             `json:"location,omitempty"`
+	Venue           *Venue                `json:"venue,omitempty"`
+	Poll            *Poll                 `json:"poll,omitempty"`
+	WebAppData      *WebAppData           `json:"web_app_data,omitempty"`
+	ReplyMarkup     *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+
+	// Service messages.
+	NewChatMembers    []User       `json:"new_chat_members,omitempty"`
+	LeftChatMember    *User        `json:"left_chat_member,omitempty"`
+	NewChatTitle      string       `json:"new_chat_title,omitempty"`
+	GroupChatCreated  bool         `json:"group_chat_created,omitempty"`
+	MigrateToChatID   int64        `json:"migrate_to_chat_id,omitempty"`
+	MigrateFromChatID int64        `json:"migrate_from_chat_id,omitempty"`
+	UsersShared       *UsersShared `json:"users_shared,omitempty"`
+	ChatShared        *ChatShared  `json:"chat_shared,omitempty"`
 }
 
 // User represents a Telegram user or bot.
@@ -36,6 +156,12 @@ type User struct {
 	LastName     string `json:"last_name,omitempty"`
 	Username     string `json:"username,omitempty"`
 	LanguageCode string `json:"language_code,omitempty"`
+	IsPremium    bool   `json:"is_premium,omitempty"`
+
+	// Only returned in getMe.
+	CanJoinGroups           bool `json:"can_join_groups,omitempty"`
+	CanReadAllGroupMessages bool `json:"can_read_all_group_messages,omitempty"`
+	SupportsInlineQueries   bool `json:"supports_inline_queries,omitempty"`
 }
 
 // Chat represents a Telegram chat.
@@ -48,14 +174,95 @@ type Chat struct {
 	LastName  string `json:"last_name,omitempty"`
 }
 
+// Chat types reported in Chat.Type.
+const (
+	ChatTypePrivate    = "private"
+	ChatTypeGroup      = "group"
+	ChatTypeSupergroup = "supergroup"
+	ChatTypeChannel    = "channel"
+)
+
+// Message origin types reported in MessageOrigin.Type.
+const (
+	MessageOriginUser       = "user"
+	MessageOriginHiddenUser = "hidden_user"
+	MessageOriginChat       = "chat"
+	MessageOriginChannel    = "channel"
+)
+
+// MessageOrigin describes the origin of a forwarded message.
+// The Bot API defines one type per Type, this struct flattens their fields.
+type MessageOrigin struct {
+	Type            string `json:"type"`
+	Date            int64  `json:"date"`
+	SenderUser      *User  `json:"sender_user,omitempty"`      // user
+	SenderUserName  string `json:"sender_user_name,omitempty"` // hidden_user
+	SenderChat      *Chat  `json:"sender_chat,omitempty"`      // chat
+	Chat            *Chat  `json:"chat,omitempty"`             // channel
+	MessageID       int64  `json:"message_id,omitempty"`       // channel
+	AuthorSignature string `json:"author_signature,omitempty"` // chat, channel
+}
+
+// Contact represents a phone contact.
+type Contact struct {
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name,omitempty"`
+	UserID      int64  `json:"user_id,omitempty"`
+	VCard       string `json:"vcard,omitempty"`
+}
+
+// Venue represents a venue.
+type Venue struct {
+	Location        Location `json:"location"`
+	Title           string   `json:"title"`
+	Address         string   `json:"address"`
+	FoursquareID    string   `json:"foursquare_id,omitempty"`
+	FoursquareType  string   `json:"foursquare_type,omitempty"`
+	GooglePlaceID   string   `json:"google_place_id,omitempty"`
+	GooglePlaceType string   `json:"google_place_type,omitempty"`
+}
+
+// UsersShared contains information about the users shared with the bot using a KeyboardButtonRequestUsers button.
+type UsersShared struct {
+	RequestID int          `json:"request_id"`
+	Users     []SharedUser `json:"users"`
+}
+
+// SharedUser contains information about a user that was shared with the bot.
+type SharedUser struct {
+	UserID    int64       `json:"user_id"`
+	FirstName string      `json:"first_name,omitempty"`
+	LastName  string      `json:"last_name,omitempty"`
+	Username  string      `json:"username,omitempty"`
+	Photo     []PhotoSize `json:"photo,omitempty"`
+}
+
+// ChatShared contains information about a chat that was shared with the bot using a KeyboardButtonRequestChat button.
+type ChatShared struct {
+	RequestID int         `json:"request_id"`
+	ChatID    int64       `json:"chat_id"`
+	Title     string      `json:"title,omitempty"`
+	Username  string      `json:"username,omitempty"`
+	Photo     []PhotoSize `json:"photo,omitempty"`
+}
+
+// WebAppData describes data sent from a Web App to the bot.
+type WebAppData struct {
+	Data       string `json:"data"`
+	ButtonText string `json:"button_text"`
+}
+
 // MessageEntity represents one special entity in a text message.
 // For example, hashtags, usernames, URLs, etc.
 type MessageEntity struct {
-	Type   string `json:"type"`
-	Offset int    `json:"offset"`
-	Length int    `json:"length"`
-	URL    string `json:"url,omitempty"`
-	User   *User  `json:"user,omitempty"`
+	Type          string `json:"type"`
+	Offset        int    `json:"offset"`
+	Length        int    `json:"length"`
+	URL           string `json:"url,omitempty"`
+	User          *User  `json:"user,omitempty"`
+	Language      string `json:"language,omitempty"`
+	CustomEmojiID string `json:"custom_emoji_id,omitempty"`
 }
 
 // PhotoSize represents one size of a photo or a file / sticker thumbnail.
@@ -164,11 +371,127 @@ type PollOption struct {
 	VoterCount int    `json:"voter_count"`
 }
 
+// CallbackQuery represents an incoming callback query from a callback button in an inline keyboard.
+type CallbackQuery struct {
+	ID   string `json:"id"`
+	From *User  `json:"from"`
+	// Message is the message with the callback button. If the message is too old
+	// to be accessible only Chat, MessageID and a Date of 0 are set.
+	Message         *Message `json:"message,omitempty"`
+	InlineMessageID string   `json:"inline_message_id,omitempty"`
+	ChatInstance    string   `json:"chat_instance"`
+	Data            string   `json:"data,omitempty"`
+	GameShortName   string   `json:"game_short_name,omitempty"`
+}
+
+// InlineQuery represents an incoming inline query.
+type InlineQuery struct {
+	ID       string    `json:"id"`
+	From     *User     `json:"from"`
+	Query    string    `json:"query"`
+	Offset   string    `json:"offset"`
+	ChatType string    `json:"chat_type,omitempty"`
+	Location *Location `json:"location,omitempty"`
+}
+
+// ChosenInlineResult represents a result of an inline query that was chosen by the user and sent to their chat partner.
+type ChosenInlineResult struct {
+	ResultID        string    `json:"result_id"`
+	From            *User     `json:"from"`
+	Location        *Location `json:"location,omitempty"`
+	InlineMessageID string    `json:"inline_message_id,omitempty"`
+	Query           string    `json:"query"`
+}
+
+// PollAnswer represents an answer of a user in a non-anonymous poll.
+type PollAnswer struct {
+	PollID    string `json:"poll_id"`
+	VoterChat *Chat  `json:"voter_chat,omitempty"`
+	User      *User  `json:"user,omitempty"`
+	OptionIDs []int  `json:"option_ids"`
+}
+
+// Chat member statuses reported in ChatMember.Status.
+const (
+	ChatMemberStatusCreator       = "creator"
+	ChatMemberStatusAdministrator = "administrator"
+	ChatMemberStatusMember        = "member"
+	ChatMemberStatusRestricted    = "restricted"
+	ChatMemberStatusLeft          = "left"
+	ChatMemberStatusKicked        = "kicked"
+)
+
+// ChatMember contains information about one member of a chat.
+// The Bot API defines one type per Status, this struct flattens their common fields.
+type ChatMember struct {
+	Status      string `json:"status"`
+	User        *User  `json:"user"`
+	IsAnonymous bool   `json:"is_anonymous,omitempty"`
+	CustomTitle string `json:"custom_title,omitempty"`
+	CanBeEdited bool   `json:"can_be_edited,omitempty"`
+	IsMember    bool   `json:"is_member,omitempty"`
+	UntilDate   int64  `json:"until_date,omitempty"`
+}
+
+// ChatMemberUpdated represents changes in the status of a chat member.
+type ChatMemberUpdated struct {
+	Chat                    *Chat           `json:"chat"`
+	From                    *User           `json:"from"`
+	Date                    int64           `json:"date"`
+	OldChatMember           *ChatMember     `json:"old_chat_member"`
+	NewChatMember           *ChatMember     `json:"new_chat_member"`
+	InviteLink              *ChatInviteLink `json:"invite_link,omitempty"`
+	ViaJoinRequest          bool            `json:"via_join_request,omitempty"`
+	ViaChatFolderInviteLink bool            `json:"via_chat_folder_invite_link,omitempty"`
+}
+
+// ChatInviteLink represents an invite link for a chat.
+type ChatInviteLink struct {
+	InviteLink              string `json:"invite_link"`
+	Creator                 *User  `json:"creator"`
+	CreatesJoinRequest      bool   `json:"creates_join_request"`
+	IsPrimary               bool   `json:"is_primary"`
+	IsRevoked               bool   `json:"is_revoked"`
+	Name                    string `json:"name,omitempty"`
+	ExpireDate              int64  `json:"expire_date,omitempty"`
+	MemberLimit             int    `json:"member_limit,omitempty"`
+	PendingJoinRequestCount int    `json:"pending_join_request_count,omitempty"`
+}
+
+// ChatJoinRequest represents a join request sent to a chat.
+type ChatJoinRequest struct {
+	Chat       *Chat           `json:"chat"`
+	From       *User           `json:"from"`
+	UserChatID int64           `json:"user_chat_id"`
+	Date       int64           `json:"date"`
+	Bio        string          `json:"bio,omitempty"`
+	InviteLink *ChatInviteLink `json:"invite_link,omitempty"`
+}
+
+// MessageReactionUpdated represents a change of a reaction on a message performed by a user.
+type MessageReactionUpdated struct {
+	Chat        *Chat          `json:"chat"`
+	MessageID   int64          `json:"message_id"`
+	User        *User          `json:"user,omitempty"`
+	ActorChat   *Chat          `json:"actor_chat,omitempty"`
+	Date        int64          `json:"date"`
+	OldReaction []ReactionType `json:"old_reaction"`
+	NewReaction []ReactionType `json:"new_reaction"`
+}
+
+// ReactionType describes the type of a reaction: "emoji", "custom_emoji" or "paid".
+type ReactionType struct {
+	Type          string `json:"type"`
+	Emoji         string `json:"emoji,omitempty"`
+	CustomEmojiID string `json:"custom_emoji_id,omitempty"`
+}
+
 // APIResponse represents a response from the Telegram Bot API.
-// This is the generic wrapper for all API responses.
-type APIResponse struct {
+// This is the generic wrapper for all API responses, T is the type of Result
+// for the called method (e.g. Message, bool, []Update, User or File).
+type APIResponse[T any] struct {
 	OK          bool        `json:"ok"`
-	Result      *Message    `json:"result,omitempty"`
+	Result      T           `json:"result,omitempty"`
 	Description string      `json:"description,omitempty"`
 	ErrorCode   int         `json:"error_code,omitempty"`
 	Parameters  *Parameters `json:"parameters,omitempty"`
@@ -180,6 +503,12 @@ type Parameters struct {
 	RetryAfter      int   `json:"retry_after,omitempty"`
 }
 
+// Parse modes accepted in ParseMode fields. An empty parse mode sends the text as is.
+const (
+	ParseModeMarkdownV2 = "MarkdownV2"
+	ParseModeHTML       = "HTML"
+)
+
 // SendMessageRequest represents a request to send a text message.
 type SendMessageRequest struct {
 	BusinessConnectionID    string                   `json:"business_connection_id,omitempty"`
@@ -196,7 +525,119 @@ type SendMessageRequest struct {
 	MessageEffectID         string                   `json:"message_effect_id,omitempty"`
 	SuggestedPostParameters *SuggestedPostParameters `json:"suggested_post_parameters,omitempty"`
 	ReplyParameters         *ReplyParameters         `json:"reply_parameters,omitempty"`
-	ReplyMarkup             interface{}              `json:"reply_markup,omitempty"` // InlineKeyboardMarkup, ReplyKeyboardMarkup, ReplyKeyboardRemove, or ForceReply
+	ReplyMarkup             ReplyMarkup              `json:"reply_markup,omitempty"`
+}
+
+// SendPhotoRequest represents a request to send a photo.
+type SendPhotoRequest struct {
+	BusinessConnectionID  string           `json:"business_connection_id,omitempty"`
+	ChatID                interface{}      `json:"chat_id"` // Integer or String
+	MessageThreadID       int              `json:"message_thread_id,omitempty"`
+	Photo                 *InputFile       `json:"photo"`
+	Caption               string           `json:"caption,omitempty"`
+	ParseMode             string           `json:"parse_mode,omitempty"`
+	CaptionEntities       []MessageEntity  `json:"caption_entities,omitempty"`
+	ShowCaptionAboveMedia bool             `json:"show_caption_above_media,omitempty"`
+	HasSpoiler            bool             `json:"has_spoiler,omitempty"`
+	DisableNotification   bool             `json:"disable_notification,omitempty"`
+	ProtectContent        bool             `json:"protect_content,omitempty"`
+	ReplyParameters       *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup           ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// SendDocumentRequest represents a request to send a general file.
+type SendDocumentRequest struct {
+	BusinessConnectionID        string           `json:"business_connection_id,omitempty"`
+	ChatID                      interface{}      `json:"chat_id"` // Integer or String
+	MessageThreadID             int              `json:"message_thread_id,omitempty"`
+	Document                    *InputFile       `json:"document"`
+	Thumbnail                   *InputFile       `json:"thumbnail,omitempty"` // Upload only
+	Caption                     string           `json:"caption,omitempty"`
+	ParseMode                   string           `json:"parse_mode,omitempty"`
+	CaptionEntities             []MessageEntity  `json:"caption_entities,omitempty"`
+	DisableContentTypeDetection bool             `json:"disable_content_type_detection,omitempty"`
+	DisableNotification         bool             `json:"disable_notification,omitempty"`
+	ProtectContent              bool             `json:"protect_content,omitempty"`
+	ReplyParameters             *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup                 ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// SendVoiceRequest represents a request to send a voice note (OGG/OPUS, MP3 or M4A).
+type SendVoiceRequest struct {
+	BusinessConnectionID string           `json:"business_connection_id,omitempty"`
+	ChatID               interface{}      `json:"chat_id"` // Integer or String
+	MessageThreadID      int              `json:"message_thread_id,omitempty"`
+	Voice                *InputFile       `json:"voice"`
+	Caption              string           `json:"caption,omitempty"`
+	ParseMode            string           `json:"parse_mode,omitempty"`
+	CaptionEntities      []MessageEntity  `json:"caption_entities,omitempty"`
+	Duration             int              `json:"duration,omitempty"`
+	DisableNotification  bool             `json:"disable_notification,omitempty"`
+	ProtectContent       bool             `json:"protect_content,omitempty"`
+	ReplyParameters      *ReplyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup          ReplyMarkup      `json:"reply_markup,omitempty"`
+}
+
+// SendMediaGroupRequest represents a request to send a group of photos, videos, documents or audios as an album.
+// Documents and audio files can only be grouped with messages of the same type.
+type SendMediaGroupRequest struct {
+	BusinessConnectionID string           `json:"business_connection_id,omitempty"`
+	ChatID               interface{}      `json:"chat_id"` // Integer or String
+	MessageThreadID      int              `json:"message_thread_id,omitempty"`
+	Media                []InputMedia     `json:"media"` // 2-10 items
+	DisableNotification  bool             `json:"disable_notification,omitempty"`
+	ProtectContent       bool             `json:"protect_content,omitempty"`
+	ReplyParameters      *ReplyParameters `json:"reply_parameters,omitempty"`
+}
+
+// Input media types reported in InputMedia.Type.
+const (
+	InputMediaPhoto    = "photo"
+	InputMediaVideo    = "video"
+	InputMediaAudio    = "audio"
+	InputMediaDocument = "document"
+)
+
+// InputMedia represents the content of a media message to be sent in a media group.
+// The Bot API defines one type per Type, this struct flattens their fields.
+type InputMedia struct {
+	Type                        string          `json:"type"`
+	Media                       *InputFile      `json:"media"`
+	Thumbnail                   *InputFile      `json:"thumbnail,omitempty"` // Upload only
+	Caption                     string          `json:"caption,omitempty"`
+	ParseMode                   string          `json:"parse_mode,omitempty"`
+	CaptionEntities             []MessageEntity `json:"caption_entities,omitempty"`
+	HasSpoiler                  bool            `json:"has_spoiler,omitempty"`                    // photo, video
+	Width                       int             `json:"width,omitempty"`                          // video
+	Height                      int             `json:"height,omitempty"`                         // video
+	Duration                    int             `json:"duration,omitempty"`                       // video, audio
+	Performer                   string          `json:"performer,omitempty"`                      // audio
+	Title                       string          `json:"title,omitempty"`                          // audio
+	SupportsStreaming           bool            `json:"supports_streaming,omitempty"`             // video
+	DisableContentTypeDetection bool            `json:"disable_content_type_detection,omitempty"` // document
+}
+
+// Chat actions accepted in SendChatActionRequest.Action.
+const (
+	ChatActionTyping          = "typing"
+	ChatActionUploadPhoto     = "upload_photo"
+	ChatActionRecordVideo     = "record_video"
+	ChatActionUploadVideo     = "upload_video"
+	ChatActionRecordVoice     = "record_voice"
+	ChatActionUploadVoice     = "upload_voice"
+	ChatActionUploadDocument  = "upload_document"
+	ChatActionChooseSticker   = "choose_sticker"
+	ChatActionFindLocation    = "find_location"
+	ChatActionRecordVideoNote = "record_video_note"
+	ChatActionUploadVideoNote = "upload_video_note"
+)
+
+// SendChatActionRequest represents a request to tell the user that something is happening on the bot's side.
+type SendChatActionRequest struct {
+	BusinessConnectionID string      `json:"business_connection_id,omitempty"`
+	ChatID               interface{} `json:"chat_id"` // Integer or String
+	MessageThreadID      int         `json:"message_thread_id,omitempty"`
+	Action               string      `json:"action"`
 }
 
 // LinkPreviewOptions describes link preview generation options for the message.
@@ -231,6 +672,139 @@ type SuggestedPostPrice struct {
 	StarCount int `json:"star_count"`
 }
 
+// GetUpdatesRequest represents a request to receive incoming updates using long polling.
+type GetUpdatesRequest struct {
+	Offset         int64        `json:"offset,omitempty"`
+	Limit          int          `json:"limit,omitempty"`   // 1-100, defaults to 100
+	Timeout        int          `json:"timeout,omitempty"` // Seconds
+	AllowedUpdates []UpdateKind `json:"allowed_updates,omitempty"`
+}
+
+// SetWebhookRequest represents a request to specify the URL that receives incoming updates.
+type SetWebhookRequest struct {
+	URL                string       `json:"url"`
+	IPAddress          string       `json:"ip_address,omitempty"`
+	MaxConnections     int          `json:"max_connections,omitempty"` // 1-100, defaults to 40
+	AllowedUpdates     []UpdateKind `json:"allowed_updates,omitempty"`
+	DropPendingUpdates bool         `json:"drop_pending_updates,omitempty"`
+	// SecretToken is sent in the X-Telegram-Bot-Api-Secret-Token header of every webhook request.
+	SecretToken string `json:"secret_token,omitempty"`
+}
+
+// DeleteWebhookRequest represents a request to remove the webhook integration.
+type DeleteWebhookRequest struct {
+	DropPendingUpdates bool `json:"drop_pending_updates,omitempty"`
+}
+
+// WebhookInfo describes the current status of a webhook.
+type WebhookInfo struct {
+	URL                          string       `json:"url"`
+	HasCustomCertificate         bool         `json:"has_custom_certificate"`
+	PendingUpdateCount           int          `json:"pending_update_count"`
+	IPAddress                    string       `json:"ip_address,omitempty"`
+	LastErrorDate                int64        `json:"last_error_date,omitempty"`
+	LastErrorMessage             string       `json:"last_error_message,omitempty"`
+	LastSynchronizationErrorDate int64        `json:"last_synchronization_error_date,omitempty"`
+	MaxConnections               int          `json:"max_connections,omitempty"`
+	AllowedUpdates               []UpdateKind `json:"allowed_updates,omitempty"`
+}
+
+// EditMessageTextRequest represents a request to edit text and game messages.
+type EditMessageTextRequest struct {
+	BusinessConnectionID string                `json:"business_connection_id,omitempty"`
+	ChatID               interface{}           `json:"chat_id,omitempty"` // Integer or String
+	MessageID            int64                 `json:"message_id,omitempty"`
+	InlineMessageID      string                `json:"inline_message_id,omitempty"`
+	Text                 string                `json:"text"`
+	ParseMode            string                `json:"parse_mode,omitempty"`
+	Entities             []MessageEntity       `json:"entities,omitempty"`
+	LinkPreviewOptions   *LinkPreviewOptions   `json:"link_preview_options,omitempty"`
+	ReplyMarkup          *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// DeleteMessageRequest represents a request to delete a message.
+type DeleteMessageRequest struct {
+	ChatID    interface{} `json:"chat_id"` // Integer or String
+	MessageID int64       `json:"message_id"`
+}
+
+// AnswerCallbackQueryRequest represents a request to answer a callback query sent from an inline keyboard.
+type AnswerCallbackQueryRequest struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+	ShowAlert       bool   `json:"show_alert,omitempty"`
+	URL             string `json:"url,omitempty"`
+	CacheTime       int    `json:"cache_time,omitempty"`
+}
+
+// BotCommand represents a bot command shown in the command menu.
+type BotCommand struct {
+	Command     string `json:"command"`     // 1-32 characters: lowercase letters, digits and underscores
+	Description string `json:"description"` // 1-256 characters
+}
+
+// BotCommandScope represents the scope to which bot commands are applied.
+// ChatID is required by the chat scopes and UserID by BotCommandScopeChatMember.
+type BotCommandScope struct {
+	Type   string      `json:"type"`
+	ChatID interface{} `json:"chat_id,omitempty"` // Integer or String
+	UserID int64       `json:"user_id,omitempty"`
+}
+
+// Bot command scope types accepted in BotCommandScope.Type.
+const (
+	BotCommandScopeDefault               = "default"
+	BotCommandScopeAllPrivateChats       = "all_private_chats"
+	BotCommandScopeAllGroupChats         = "all_group_chats"
+	BotCommandScopeAllChatAdministrators = "all_chat_administrators"
+	BotCommandScopeChat                  = "chat"
+	BotCommandScopeChatAdministrators    = "chat_administrators"
+	BotCommandScopeChatMember            = "chat_member"
+)
+
+// SetMyCommandsRequest represents a request to change the list of the bot commands.
+type SetMyCommandsRequest struct {
+	Commands     []BotCommand     `json:"commands"` // At most 100 commands
+	Scope        *BotCommandScope `json:"scope,omitempty"`
+	LanguageCode string           `json:"language_code,omitempty"`
+}
+
+// GetMyCommandsRequest represents a request to get the list of the bot commands.
+type GetMyCommandsRequest struct {
+	Scope        *BotCommandScope `json:"scope,omitempty"`
+	LanguageCode string           `json:"language_code,omitempty"`
+}
+
+// DeleteMyCommandsRequest represents a request to delete the list of the bot commands.
+type DeleteMyCommandsRequest struct {
+	Scope        *BotCommandScope `json:"scope,omitempty"`
+	LanguageCode string           `json:"language_code,omitempty"`
+}
+
+// GetFileRequest represents a request to get basic information about a file.
+type GetFileRequest struct {
+	FileID string `json:"file_id"`
+}
+
+// File represents a file ready to be downloaded.
+// The file can be downloaded via the link https://api.telegram.org/file/bot<token>/<file_path>.
+type File struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileSize     int64  `json:"file_size,omitempty"`
+	FilePath     string `json:"file_path,omitempty"`
+}
+
+// ReplyMarkup is one of InlineKeyboardMarkup, ReplyKeyboardMarkup, ReplyKeyboardRemove or ForceReply.
+type ReplyMarkup interface {
+	replyMarkup()
+}
+
+func (InlineKeyboardMarkup) replyMarkup() {}
+func (ReplyKeyboardMarkup) replyMarkup()  {}
+func (ReplyKeyboardRemove) replyMarkup()  {}
+func (ForceReply) replyMarkup()           {}
+
 // InlineKeyboardMarkup represents an inline keyboard that appears right next to the message.
 type InlineKeyboardMarkup struct {
 	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
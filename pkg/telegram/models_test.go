@@ -0,0 +1,133 @@
+package telegram
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateRoundTrip(t *testing.T) {
+	tests := []struct {
+		file   string
+		kind   UpdateKind
+		chatID int64
+		check  func(t *testing.T, update *Update)
+	}{
+		{
+			file:   "message.json",
+			kind:   UpdateKindMessage,
+			chatID: 111222333,
+			check: func(t *testing.T, update *Update) {
+				msg := update.Message
+				if msg.ReplyToMessage == nil || msg.ReplyToMessage.MessageID != 305 {
+					t.Errorf("ReplyToMessage = %+v, want message 305", msg.ReplyToMessage)
+				}
+				if msg.ForwardOrigin == nil || msg.ForwardOrigin.Type != MessageOriginUser || msg.ForwardOrigin.SenderUser.FirstName != "Luis" {
+					t.Errorf("ForwardOrigin = %+v, want forwarded from Luis", msg.ForwardOrigin)
+				}
+				if command, ok := msg.Command(); !ok || command.Name != "gasto" || command.BotUsername != "test_bot" {
+					t.Errorf("Command() = %+v, %t, want /gasto@test_bot", command, ok)
+				}
+			},
+		},
+		{
+			file:   "edited_message.json",
+			kind:   UpdateKindEditedMessage,
+			chatID: -4012345678,
+			check: func(t *testing.T, update *Update) {
+				if update.EditedMessage.EditDate != 1710512520 {
+					t.Errorf("EditDate = %d, want 1710512520", update.EditedMessage.EditDate)
+				}
+			},
+		},
+		{
+			file:   "callback_query.json",
+			kind:   UpdateKindCallbackQuery,
+			chatID: 111222333,
+			check: func(t *testing.T, update *Update) {
+				query := update.CallbackQuery
+				if query.Data != "draft:confirm:abc123" || !query.From.IsPremium {
+					t.Errorf("CallbackQuery = %+v", query)
+				}
+				if markup := query.Message.ReplyMarkup; markup == nil || len(markup.InlineKeyboard[0]) != 2 {
+					t.Errorf("ReplyMarkup = %+v, want two buttons", markup)
+				}
+			},
+		},
+		{
+			file:   "photo.json",
+			kind:   UpdateKindMessage,
+			chatID: -1001987654321,
+			check: func(t *testing.T, update *Update) {
+				msg := update.Message
+				if len(msg.Photo) != 2 || msg.Photo[1].Width != 1280 {
+					t.Errorf("Photo = %+v, want two sizes", msg.Photo)
+				}
+				if msg.Caption == "" || len(msg.CaptionEntities) != 1 || msg.CaptionEntities[0].Type != "hashtag" {
+					t.Errorf("Caption = %q with entities %+v", msg.Caption, msg.CaptionEntities)
+				}
+				if msg.Chat.Type != ChatTypeSupergroup {
+					t.Errorf("Chat.Type = %q, want %q", msg.Chat.Type, ChatTypeSupergroup)
+				}
+			},
+		},
+		{
+			file:   "my_chat_member.json",
+			kind:   UpdateKindMyChatMember,
+			chatID: -4012345678,
+			check: func(t *testing.T, update *Update) {
+				member := update.MyChatMember
+				if member.OldChatMember.Status != ChatMemberStatusLeft || member.NewChatMember.Status != ChatMemberStatusMember {
+					t.Errorf("status %q -> %q, want %q -> %q", member.OldChatMember.Status, member.NewChatMember.Status, ChatMemberStatusLeft, ChatMemberStatusMember)
+				}
+			},
+		},
+		{
+			file:   "migrate_to_chat_id.json",
+			kind:   UpdateKindMessage,
+			chatID: -4012345678,
+			check: func(t *testing.T, update *Update) {
+				if update.Message.MigrateToChatID != -1001987654321 {
+					t.Errorf("MigrateToChatID = %d, want -1001987654321", update.Message.MigrateToChatID)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var update Update
+			if err := json.Unmarshal(data, &update); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if kind := update.Kind(); kind != tt.kind {
+				t.Errorf("Kind() = %q, want %q", kind, tt.kind)
+			}
+			if chat := update.Chat(); chat == nil || chat.ID != tt.chatID {
+				t.Errorf("Chat() = %+v, want chat %d", chat, tt.chatID)
+			}
+			tt.check(t, &update)
+
+			// Every field of the fixture is modeled: encoding the update gives the same JSON back
+			encoded, err := json.Marshal(update)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			var want, got interface{}
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(encoded, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip changed the update:\n got: %s\nwant: %s", encoded, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultPollTimeout is the long polling timeout of getUpdates.
+	defaultPollTimeout = 30 * time.Second
+	// maxPollBackoff bounds the wait between failed getUpdates calls.
+	maxPollBackoff = 30 * time.Second
+)
+
+// UpdateHandler processes an incoming update.
+type UpdateHandler interface {
+	HandleUpdate(ctx context.Context, update *Update) error
+}
+
+// UpdateHandlerFunc adapts a function to UpdateHandler.
+type UpdateHandlerFunc func(ctx context.Context, update *Update) error
+
+// HandleUpdate calls f(ctx, update).
+func (f UpdateHandlerFunc) HandleUpdate(ctx context.Context, update *Update) error {
+	return f(ctx, update)
+}
+
+// GetUpdates receives incoming updates using long polling.
+// It fails with a 409 *Error while a webhook is set.
+func (c *Client) GetUpdates(ctx context.Context, req *GetUpdatesRequest) ([]Update, error) {
+	return call[[]Update](ctx, c, "getUpdates", req)
+}
+
+// SetWebhook sets the URL that receives incoming updates.
+func (c *Client) SetWebhook(ctx context.Context, req *SetWebhookRequest) error {
+	_, err := call[bool](ctx, c, "setWebhook", req)
+	return err
+}
+
+// DeleteWebhook removes the webhook integration, to switch back to getUpdates.
+func (c *Client) DeleteWebhook(ctx context.Context, req *DeleteWebhookRequest) error {
+	_, err := call[bool](ctx, c, "deleteWebhook", req)
+	return err
+}
+
+// GetWebhookInfo returns the current webhook status.
+func (c *Client) GetWebhookInfo(ctx context.Context) (*WebhookInfo, error) {
+	return call[*WebhookInfo](ctx, c, "getWebhookInfo", nil)
+}
+
+// Poller fetches updates with getUpdates and feeds them, in order, to a handler.
+// It is meant for local development, in production updates arrive through the webhook.
+type Poller struct {
+	client  *Client
+	handler UpdateHandler
+
+	// Timeout is the long polling timeout (default: 30 seconds).
+	Timeout time.Duration
+	// AllowedUpdates filters the update kinds to receive, empty keeps the previous setting.
+	AllowedUpdates []UpdateKind
+	// Offset is the identifier of the next update to fetch, set it to resume a previous run.
+	Offset int64
+	// OnError is called when fetching or handling updates fails (default: log the error).
+	// Failed updates are not fetched again.
+	OnError func(err error)
+}
+
+// NewPoller creates a Poller that feeds the updates received by client to handler.
+func NewPoller(client *Client, handler UpdateHandler) *Poller {
+	return &Poller{
+		client:  client,
+		handler: handler,
+		Timeout: defaultPollTimeout,
+		OnError: func(err error) {
+			log.Println("Poller error:", err)
+		},
+	}
+}
+
+// Run polls until ctx is done. Updates being handled when ctx is done are
+// completed and confirmed to Telegram before returning, so they are not
+// delivered again on the next run. It returns nil on a graceful shutdown.
+func (p *Poller) Run(ctx context.Context) error {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		// The HTTP request must outlive the long polling timeout
+		pollCtx, cancel := context.WithTimeout(ctx, p.Timeout+10*time.Second)
+		updates, err := p.client.GetUpdates(pollCtx, &GetUpdatesRequest{
+			Offset:         p.Offset,
+			Timeout:        int(p.Timeout.Seconds()),
+			AllowedUpdates: p.AllowedUpdates,
+		})
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			var apiErr *Error
+			if errors.As(err, &apiErr) && apiErr.ErrorCode == http.StatusConflict {
+				return fmt.Errorf("polling updates, delete the webhook first: %w", err)
+			}
+			p.OnError(err)
+			if sleep(ctx, backoff) != nil {
+				break
+			}
+			backoff = min(2*backoff, maxPollBackoff)
+			continue
+		}
+		backoff = time.Second
+
+		// Updates already fetched are always handled, even during a shutdown
+		handlerCtx := context.WithoutCancel(ctx)
+		for i := range updates {
+			if err := p.handler.HandleUpdate(handlerCtx, &updates[i]); err != nil {
+				p.OnError(fmt.Errorf("handling update %d: %w", updates[i].UpdateID, err))
+			}
+			p.Offset = updates[i].UpdateID + 1
+		}
+	}
+	return p.confirm()
+}
+
+// confirm acknowledges the handled updates, Telegram forgets every update
+// older than the offset of the last getUpdates call.
+func (p *Poller) confirm() error {
+	if p.Offset == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := p.client.GetUpdates(ctx, &GetUpdatesRequest{Offset: p.Offset, Limit: 1})
+	return err
+}
@@ -0,0 +1,264 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit describes a token bucket that refills Rate tokens per second up to Burst tokens.
+type Limit struct {
+	Rate  float64
+	Burst float64
+}
+
+// Default limits documented by Telegram for bots.
+var (
+	// GlobalLimit is the limit for all the messages sent by the bot (~30 messages per second).
+	GlobalLimit = Limit{Rate: 30, Burst: 30}
+	// ChatLimit is the limit for messages sent to the same chat (~1 message per second).
+	ChatLimit = Limit{Rate: 1, Burst: 1}
+	// GroupLimit is the additional limit for messages sent to the same group (20 messages per minute).
+	GroupLimit = Limit{Rate: 20.0 / 60.0, Burst: 20}
+)
+
+// BucketStore keeps the state of the token buckets used by a RateLimiter.
+// Implementations must be safe for concurrent use.
+type BucketStore interface {
+	// Reserve takes one token from the bucket identified by key, refilling it
+	// according to limit at time now, and returns how long the caller must wait
+	// before the reserved token is actually available. If that wait would exceed
+	// maxWait nothing is taken, ok is false and the wait is still returned.
+	// A negative maxWait means no maximum.
+	Reserve(ctx context.Context, key string, limit Limit, now time.Time, maxWait time.Duration) (wait time.Duration, ok bool, err error)
+	// Release gives back a token taken by Reserve when the send does not happen.
+	Release(ctx context.Context, key string, limit Limit, now time.Time) error
+}
+
+// RateLimiter throttles sends to stay within Telegram's global and per-chat limits.
+// The bucket state lives in a BucketStore so several Lambda instances can share it.
+type RateLimiter struct {
+	store BucketStore
+	// Global is applied to every send (default: GlobalLimit).
+	Global Limit
+	// GlobalShards splits Global in buckets of Global/GlobalShards, each send
+	// takes from a random one. It spreads the writes of a shared BucketStore
+	// (e.g. DynamoDB partitions) at the cost of a less precise limit (default: 1).
+	GlobalShards int
+	// Chat is applied per chat (default: ChatLimit).
+	Chat Limit
+	// Group is applied per chat on top of Chat for every chat that is not
+	// private: groups, supergroups and channels (default: GroupLimit).
+	Group Limit
+}
+
+// NewRateLimiter creates a RateLimiter with Telegram's default limits backed by store.
+func NewRateLimiter(store BucketStore) *RateLimiter {
+	return &RateLimiter{
+		store:        store,
+		Global:       GlobalLimit,
+		GlobalShards: 1,
+		Chat:         ChatLimit,
+		Group:        GroupLimit,
+	}
+}
+
+// bucket is a token bucket checked by Wait.
+type bucket struct {
+	key   string
+	limit Limit
+}
+
+// Wait blocks until a message can be sent to chat. It returns a *RetryAfterError
+// without waiting if the required wait does not fit before the context deadline,
+// in that case no token is taken so giving up does not delay later sends.
+func (l *RateLimiter) Wait(ctx context.Context, chat *Chat) error {
+	now := time.Now()
+	maxWait := time.Duration(-1)
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = max(time.Until(deadline)-retryDeadlineMargin, 0)
+	}
+
+	var wait time.Duration
+	var reserved []bucket
+	for _, b := range l.buckets(chat) {
+		d, ok, err := l.store.Reserve(ctx, b.key, b.limit, now, maxWait)
+		if err == nil && !ok {
+			err = &RetryAfterError{RetryAfter: d}
+		}
+		if err != nil {
+			// Give back the tokens of the buckets that allowed the send
+			for _, r := range reserved {
+				if releaseErr := l.store.Release(ctx, r.key, r.limit, now); releaseErr != nil {
+					return fmt.Errorf("rate limiter: %w", releaseErr)
+				}
+			}
+			var retryErr *RetryAfterError
+			if errors.As(err, &retryErr) {
+				return err
+			}
+			return fmt.Errorf("rate limiter: %w", err)
+		}
+		reserved = append(reserved, b)
+		wait = max(wait, d)
+	}
+	if wait <= 0 {
+		return nil
+	}
+	return sleep(ctx, wait)
+}
+
+// buckets returns the buckets a send to chat takes a token from.
+func (l *RateLimiter) buckets(chat *Chat) []bucket {
+	global := bucket{key: "global", limit: l.Global}
+	if l.GlobalShards > 1 {
+		shards := float64(l.GlobalShards)
+		global = bucket{
+			key:   fmt.Sprintf("global:%d", rand.IntN(l.GlobalShards)),
+			limit: Limit{Rate: l.Global.Rate / shards, Burst: math.Max(1, l.Global.Burst/shards)},
+		}
+	}
+
+	chatKey := chatBucketKey(chat)
+	buckets := []bucket{global, {key: "chat:" + chatKey, limit: l.Chat}}
+	if chat.Type != ChatTypePrivate {
+		buckets = append(buckets, bucket{key: "group:" + chatKey, limit: l.Group})
+	}
+	return buckets
+}
+
+// chatBucketKey identifies a chat by its ID or, for "@channel" style IDs, by username.
+func chatBucketKey(chat *Chat) string {
+	if chat.ID == 0 && chat.Username != "" {
+		return chat.Username
+	}
+	return fmt.Sprint(chat.ID)
+}
+
+type chatContextKey struct{}
+
+// WithChat returns a copy of ctx that carries chat, the chat whose type is
+// known (e.g. Update.Chat of the update being handled). Sends to it apply the
+// limits of its actual type instead of the one ChatFromID guesses.
+func WithChat(ctx context.Context, chat *Chat) context.Context {
+	if chat == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, chatContextKey{}, chat)
+}
+
+// limitedChat returns the chat a request ChatID is sent to, with the type of
+// the chat in ctx when it is the same chat.
+func limitedChat(ctx context.Context, chatID interface{}) *Chat {
+	chat := ChatFromID(chatID)
+	known, ok := ctx.Value(chatContextKey{}).(*Chat)
+	if !ok || known.Type == "" {
+		return chat
+	}
+	if (chat.ID != 0 && chat.ID == known.ID) || (chat.Username != "" && strings.EqualFold(chat.Username, known.Username)) {
+		chat.Type = known.Type
+	}
+	return chat
+}
+
+// ChatFromID builds a Chat from a request ChatID (integer or "@username").
+//
+// The type is guessed from the ID: users are positive, basic groups negative
+// and supergroups start with -100, but channels also use -100 IDs and
+// "@username" may be a public supergroup as well as a channel. Prefer the
+// chat of the update when it is available, see WithChat.
+func ChatFromID(chatID interface{}) *Chat {
+	var id int64
+	switch v := chatID.(type) {
+	case int64:
+		id = v
+	case int:
+		id = int64(v)
+	case string:
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			id = parsed
+			break
+		}
+		return &Chat{Username: strings.TrimPrefix(v, "@"), Type: ChatTypeChannel}
+	}
+	switch {
+	case id > 0:
+		return &Chat{ID: id, Type: ChatTypePrivate}
+	case id <= -1000000000000:
+		return &Chat{ID: id, Type: ChatTypeSupergroup}
+	default:
+		return &Chat{ID: id, Type: ChatTypeGroup}
+	}
+}
+
+// take computes the tokens of a bucket at now after taking one token.
+// It returns the remaining tokens (negative when in debt) and the wait until the token is available.
+func (limit Limit) take(tokens float64, updated, now time.Time) (float64, time.Duration) {
+	elapsed := math.Max(0, now.Sub(updated).Seconds())
+	tokens = math.Min(limit.Burst, tokens+elapsed*limit.Rate)
+	tokens--
+	if tokens >= 0 || limit.Rate <= 0 {
+		return tokens, 0
+	}
+	return tokens, time.Duration(-tokens / limit.Rate * float64(time.Second))
+}
+
+// MemoryBucketStore is a BucketStore local to the process. In Lambda it only
+// throttles the sends of a single instance.
+type MemoryBucketStore struct {
+	mu      sync.Mutex
+	buckets map[string]memoryBucket
+}
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryBucketStore creates an empty MemoryBucketStore.
+func NewMemoryBucketStore() *MemoryBucketStore {
+	return &MemoryBucketStore{buckets: make(map[string]memoryBucket)}
+}
+
+// Reserve implements BucketStore.
+func (s *MemoryBucketStore) Reserve(_ context.Context, key string, limit Limit, now time.Time, maxWait time.Duration) (time.Duration, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = memoryBucket{tokens: limit.Burst, updated: now}
+	}
+	tokens, wait := limit.take(bucket.tokens, bucket.updated, now)
+	if maxWait >= 0 && wait > maxWait {
+		return wait, false, nil
+	}
+	s.buckets[key] = memoryBucket{tokens: tokens, updated: later(bucket.updated, now)}
+	return wait, true, nil
+}
+
+// Release implements BucketStore.
+func (s *MemoryBucketStore) Release(_ context.Context, key string, limit Limit, _ time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if bucket, ok := s.buckets[key]; ok {
+		bucket.tokens = math.Min(limit.Burst, bucket.tokens+1)
+		s.buckets[key] = bucket
+	}
+	return nil
+}
+
+// later returns the latest of two times.
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
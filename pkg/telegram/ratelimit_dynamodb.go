@@ -0,0 +1,152 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// dynamoBucketMaxAttempts bounds the optimistic locking retries of a reservation.
+const dynamoBucketMaxAttempts = 5
+
+// DynamoDBAPI is the subset of the DynamoDB client used by DynamoDBBucketStore.
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+}
+
+// DynamoDBBucketStore is a BucketStore shared by every Lambda instance through a
+// DynamoDB table with a string partition key "pk" and TTL on "expires_at".
+//
+// Each bucket is one item updated with optimistic locking on "version". Point
+// the DynamoDB client to DynamoDB Local (BaseEndpoint) to use it offline.
+type DynamoDBBucketStore struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+// NewDynamoDBBucketStore creates a DynamoDBBucketStore over tableName.
+func NewDynamoDBBucketStore(client DynamoDBAPI, tableName string) *DynamoDBBucketStore {
+	return &DynamoDBBucketStore{client: client, tableName: tableName}
+}
+
+// Reserve implements BucketStore.
+func (s *DynamoDBBucketStore) Reserve(ctx context.Context, key string, limit Limit, now time.Time, maxWait time.Duration) (time.Duration, bool, error) {
+	for attempt := 0; attempt < dynamoBucketMaxAttempts; attempt++ {
+		out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(s.tableName),
+			Key:            map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: key}},
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return 0, false, fmt.Errorf("getting bucket %s: %w", key, err)
+		}
+
+		tokens, updated, version := limit.Burst, now, int64(0)
+		if out.Item != nil {
+			if tokens, err = numberAttribute(out.Item, "tokens"); err != nil {
+				return 0, false, err
+			}
+			updatedMs, err := numberAttribute(out.Item, "updated_at")
+			if err != nil {
+				return 0, false, err
+			}
+			v, err := numberAttribute(out.Item, "version")
+			if err != nil {
+				return 0, false, err
+			}
+			updated, version = time.UnixMilli(int64(updatedMs)), int64(v)
+		}
+
+		tokens, wait := limit.take(tokens, updated, now)
+		if maxWait >= 0 && wait > maxWait {
+			return wait, false, nil
+		}
+		// The bucket is full again after (Burst - tokens) / Rate, afterwards the item is useless.
+		expiresAt := now.Add(time.Hour)
+		if limit.Rate > 0 {
+			expiresAt = now.Add(time.Duration((limit.Burst-tokens)/limit.Rate*float64(time.Second)) + time.Minute)
+		}
+
+		condition := "attribute_not_exists(pk)"
+		values := map[string]types.AttributeValue{}
+		if out.Item != nil {
+			condition = "version = :version"
+			values[":version"] = numberValue(float64(version))
+		}
+		_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(s.tableName),
+			Item: map[string]types.AttributeValue{
+				"pk":         &types.AttributeValueMemberS{Value: key},
+				"tokens":     numberValue(tokens),
+				"updated_at": numberValue(float64(later(updated, now).UnixMilli())),
+				"version":    numberValue(float64(version + 1)),
+				"expires_at": numberValue(float64(expiresAt.Unix())),
+			},
+			ConditionExpression:       aws.String(condition),
+			ExpressionAttributeValues: nilIfEmpty(values),
+		})
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			// Another instance took a token meanwhile, read the bucket again
+			continue
+		}
+		if err != nil {
+			return 0, false, fmt.Errorf("saving bucket %s: %w", key, err)
+		}
+		return wait, true, nil
+	}
+	return 0, false, fmt.Errorf("saving bucket %s: too much contention", key)
+}
+
+// Release implements BucketStore. The token is added without reading the
+// bucket, the refill in Reserve caps the tokens at Burst.
+func (s *DynamoDBBucketStore) Release(ctx context.Context, key string, _ Limit, _ time.Time) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.tableName),
+		Key:                 map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: key}},
+		UpdateExpression:    aws.String("SET tokens = tokens + :one, version = version + :one"),
+		ConditionExpression: aws.String("attribute_exists(pk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": numberValue(1),
+		},
+	})
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		// The bucket expired, it is full again
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("releasing bucket %s: %w", key, err)
+	}
+	return nil
+}
+
+// numberAttribute reads a numeric attribute of an item.
+func numberAttribute(item map[string]types.AttributeValue, name string) (float64, error) {
+	attr, ok := item[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("bucket attribute %s is missing or not a number", name)
+	}
+	return strconv.ParseFloat(attr.Value, 64)
+}
+
+// numberValue builds a numeric attribute value.
+func numberValue(n float64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatFloat(n, 'f', -1, 64)}
+}
+
+// nilIfEmpty avoids sending an empty ExpressionAttributeValues, which DynamoDB rejects.
+func nilIfEmpty(values map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
@@ -0,0 +1,13 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/dynamotest"
+)
+
+func TestDynamoDBBucketStore(t *testing.T) {
+	client := dynamotest.Client(t)
+	table := dynamotest.NewTable(t, client, dynamotest.StringKeyTable("pk"))
+	testBucketStore(t, NewDynamoDBBucketStore(client, table))
+}
@@ -0,0 +1,164 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testBucketStore runs the BucketStore contract against store.
+func testBucketStore(t *testing.T, store BucketStore) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+	limit := Limit{Rate: 1, Burst: 2}
+
+	t.Run("burst", func(t *testing.T) {
+		for i, want := range []time.Duration{0, 0, time.Second, 2 * time.Second} {
+			wait, ok, err := store.Reserve(ctx, "burst", limit, now, -1)
+			if err != nil {
+				t.Fatalf("Reserve() #%d error = %v", i, err)
+			}
+			if !ok || wait != want {
+				t.Errorf("Reserve() #%d = %s, %t, want %s, true", i, wait, ok, want)
+			}
+		}
+	})
+
+	t.Run("max wait takes nothing", func(t *testing.T) {
+		for range 2 {
+			if _, _, err := store.Reserve(ctx, "max-wait", limit, now, -1); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := range 3 {
+			wait, ok, err := store.Reserve(ctx, "max-wait", limit, now, 500*time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok || wait != time.Second {
+				t.Errorf("Reserve() #%d = %s, %t, want %s, false", i, wait, ok, time.Second)
+			}
+		}
+		if wait, ok, _ := store.Reserve(ctx, "max-wait", limit, now, time.Second); !ok || wait != time.Second {
+			t.Errorf("Reserve() within max wait = %s, %t, want %s, true", wait, ok, time.Second)
+		}
+	})
+
+	t.Run("release", func(t *testing.T) {
+		for range 2 {
+			if _, _, err := store.Reserve(ctx, "release", limit, now, -1); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.Release(ctx, "release", limit, now); err != nil {
+			t.Fatalf("Release() error = %v", err)
+		}
+		if wait, ok, _ := store.Reserve(ctx, "release", limit, now, 0); !ok || wait != 0 {
+			t.Errorf("Reserve() after Release() = %s, %t, want 0, true", wait, ok)
+		}
+		if err := store.Release(ctx, "never-reserved", limit, now); err != nil {
+			t.Errorf("Release() of a missing bucket error = %v", err)
+		}
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		for range 3 {
+			if _, _, err := store.Reserve(ctx, "refill", limit, now, -1); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// One token of debt is paid after 1s, another one is refilled after 2s
+		if wait, ok, _ := store.Reserve(ctx, "refill", limit, now.Add(2*time.Second), 0); !ok || wait != 0 {
+			t.Errorf("Reserve() after refill = %s, %t, want 0, true", wait, ok)
+		}
+		if wait, _, _ := store.Reserve(ctx, "refill", limit, now.Add(2*time.Second), -1); wait != time.Second {
+			t.Errorf("Reserve() beyond the refill = %s, want %s", wait, time.Second)
+		}
+	})
+}
+
+func TestMemoryBucketStore(t *testing.T) {
+	testBucketStore(t, NewMemoryBucketStore())
+}
+
+func TestRateLimiterGivingUpTakesNoTokens(t *testing.T) {
+	limiter := NewRateLimiter(NewMemoryBucketStore())
+	limiter.Global = Limit{Rate: 0.001, Burst: 2}
+	limiter.Chat = Limit{Rate: 1, Burst: 1}
+
+	first := &Chat{ID: 1, Type: ChatTypePrivate}
+	if err := limiter.Wait(context.Background(), first); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	// The chat bucket is empty and the wait does not fit before the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), retryDeadlineMargin+100*time.Millisecond)
+	defer cancel()
+	var retryErr *RetryAfterError
+	if err := limiter.Wait(ctx, first); !errors.As(err, &retryErr) {
+		t.Fatalf("Wait() error = %v, want a *RetryAfterError", err)
+	}
+
+	// The global token taken before giving up was released
+	if err := limiter.Wait(ctx, &Chat{ID: 2, Type: ChatTypePrivate}); err != nil {
+		t.Errorf("Wait() on another chat error = %v, want the global token released", err)
+	}
+}
+
+func TestRateLimiterBuckets(t *testing.T) {
+	limiter := NewRateLimiter(NewMemoryBucketStore())
+	limiter.GlobalShards = 4
+
+	tests := []struct {
+		chat *Chat
+		keys []string
+	}{
+		{&Chat{ID: 42, Type: ChatTypePrivate}, []string{"global:", "chat:42"}},
+		{&Chat{ID: -42, Type: ChatTypeGroup}, []string{"global:", "chat:-42", "group:-42"}},
+		{&Chat{Username: "news", Type: ChatTypeChannel}, []string{"global:", "chat:news", "group:news"}},
+		{&Chat{ID: -1001234567890}, []string{"global:", "chat:-1001234567890", "group:-1001234567890"}},
+	}
+	for _, tt := range tests {
+		buckets := limiter.buckets(tt.chat)
+		if len(buckets) != len(tt.keys) {
+			t.Errorf("buckets(%+v) = %+v, want keys %v", tt.chat, buckets, tt.keys)
+			continue
+		}
+		for i, key := range tt.keys {
+			if !strings.HasPrefix(buckets[i].key, key) {
+				t.Errorf("buckets(%+v)[%d].key = %q, want %q", tt.chat, i, buckets[i].key, key)
+			}
+		}
+		if want := (Limit{Rate: GlobalLimit.Rate / 4, Burst: GlobalLimit.Burst / 4}); buckets[0].limit != want {
+			t.Errorf("global shard limit = %+v, want %+v", buckets[0].limit, want)
+		}
+	}
+}
+
+func TestLimitedChat(t *testing.T) {
+	supergroup := &Chat{ID: -1001234567890, Username: "finanzas", Type: ChatTypeSupergroup}
+	tests := []struct {
+		name   string
+		known  *Chat
+		chatID interface{}
+		want   Chat
+	}{
+		{"user", nil, int64(42), Chat{ID: 42, Type: ChatTypePrivate}},
+		{"basic group", nil, int64(-42), Chat{ID: -42, Type: ChatTypeGroup}},
+		{"numeric string", nil, "-42", Chat{ID: -42, Type: ChatTypeGroup}},
+		{"unknown username", nil, "@finanzas", Chat{Username: "finanzas", Type: ChatTypeChannel}},
+		{"known username", supergroup, "@finanzas", Chat{Username: "finanzas", Type: ChatTypeSupergroup}},
+		{"known channel", &Chat{ID: -1009, Type: ChatTypeChannel}, int64(-1009), Chat{ID: -1009, Type: ChatTypeChannel}},
+		{"another chat", supergroup, int64(42), Chat{ID: 42, Type: ChatTypePrivate}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithChat(context.Background(), tt.known)
+			if got := limitedChat(ctx, tt.chatID); *got != tt.want {
+				t.Errorf("limitedChat(%v) = %+v, want %+v", tt.chatID, *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// maxSendAttempts bounds how many times a send is repeated after flood control or migrations.
+	maxSendAttempts = 3
+	// retryDeadlineMargin is the time kept free before the context deadline to perform the retried request.
+	retryDeadlineMargin = 2 * time.Second
+)
+
+// RetryAfterError is returned when Telegram asks to wait before retrying a send
+// (error 429), or the client rate limiter asks to wait, but the send gives up:
+// the wait does not fit before the context deadline, the attempts ran out or
+// the request uploads files and cannot be repeated. Err is nil when the wait
+// comes from the limiter.
+//
+// Lambdas must return it unwrapped so Step Functions sees "RetryAfterError" as
+// the error name and the state machine Retry block can back off instead.
+type RetryAfterError struct {
+	RetryAfter time.Duration
+	Err        *Error
+}
+
+func (e *RetryAfterError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("rate limited: retry after %s exceeds the deadline", e.RetryAfter)
+	}
+	return fmt.Sprintf("retry after %s: %s", e.RetryAfter, e.Err)
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	if e.Err == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// chatRequest is a request that posts into the chat of its ChatID field.
+type chatRequest[R any] interface {
+	*R
+	chatIDField() *interface{}
+}
+
+func (r *SendMessageRequest) chatIDField() *interface{}    { return &r.ChatID }
+func (r *SendPhotoRequest) chatIDField() *interface{}      { return &r.ChatID }
+func (r *SendDocumentRequest) chatIDField() *interface{}   { return &r.ChatID }
+func (r *SendVoiceRequest) chatIDField() *interface{}      { return &r.ChatID }
+func (r *SendMediaGroupRequest) chatIDField() *interface{} { return &r.ChatID }
+
+// send calls a Bot API method that posts into a chat, honoring retry_after and
+// re-sending to migrate_to_chat_id when a group was upgraded to a supergroup.
+//
+// req is not modified, a copy is sent to the new chat on migration. The
+// result is then the message in that chat, callers that store the chat ID
+// must update it when the Chat.ID of the result is a different one.
+// Requests that upload files are sent only once, their readers cannot be rewound.
+func send[T any, R any, P chatRequest[R]](ctx context.Context, c *Client, method string, req P) (T, error) {
+	copied := *req
+	req = &copied
+	chatID := req.chatIDField()
+
+	maxAttempts := maxSendAttempts
+	if len(uploads(req)) > 0 {
+		maxAttempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx, limitedChat(ctx, *chatID)); err != nil {
+				var zero T
+				return zero, err
+			}
+		}
+		result, err := call[T](ctx, c, method, req)
+		var apiErr *Error
+		if err == nil || !errors.As(err, &apiErr) {
+			return result, err
+		}
+
+		if newChatID := apiErr.MigrateToChatID(); newChatID != 0 && attempt < maxAttempts {
+			*chatID = newChatID
+			continue
+		}
+
+		wait := apiErr.RetryAfter()
+		if wait <= 0 {
+			return result, err
+		}
+		// Giving up on flood control, the caller (e.g. the state machine) can retry later
+		if attempt >= maxAttempts {
+			return result, &RetryAfterError{RetryAfter: wait, Err: apiErr}
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait+retryDeadlineMargin {
+			return result, &RetryAfterError{RetryAfter: wait, Err: apiErr}
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return result, &RetryAfterError{RetryAfter: wait, Err: apiErr}
+		}
+	}
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,164 @@
+package telegram_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/telegramtest"
+)
+
+func TestSendMessageRetriesAfterFloodControl(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	server.FailNext("sendMessage", telegramtest.TooManyRequests(1))
+
+	start := time.Now()
+	msg, err := server.Client().SendMessage(context.Background(), &telegram.SendMessageRequest{ChatID: 42, Text: "hola"})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if msg.Text != "hola" {
+		t.Errorf("Text = %q, want %q", msg.Text, "hola")
+	}
+	if calls := len(server.CallsTo("sendMessage")); calls != 2 {
+		t.Errorf("sendMessage calls = %d, want 2", calls)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least 1s", elapsed)
+	}
+}
+
+func TestSendMessageGivesUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		deadline time.Duration
+		failures []*telegram.Error
+		calls    int
+	}{
+		{
+			name:     "wait beyond the deadline",
+			deadline: 3 * time.Second,
+			failures: []*telegram.Error{telegramtest.TooManyRequests(30)},
+			calls:    1,
+		},
+		{
+			name:     "last attempt",
+			deadline: time.Minute,
+			failures: []*telegram.Error{telegramtest.TooManyRequests(1), telegramtest.TooManyRequests(1), telegramtest.TooManyRequests(1)},
+			calls:    3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := telegramtest.NewServer()
+			defer server.Close()
+			server.FailNext("sendMessage", tt.failures...)
+			ctx, cancel := context.WithTimeout(context.Background(), tt.deadline)
+			defer cancel()
+
+			_, err := server.Client().SendMessage(ctx, &telegram.SendMessageRequest{ChatID: 42, Text: "hola"})
+			var retryErr *telegram.RetryAfterError
+			if !errors.As(err, &retryErr) {
+				t.Fatalf("SendMessage() error = %v, want a *telegram.RetryAfterError", err)
+			}
+			if retryErr.Err == nil || retryErr.Err.ErrorCode != 429 {
+				t.Errorf("RetryAfterError.Err = %v, want the 429 error", retryErr.Err)
+			}
+			if calls := len(server.CallsTo("sendMessage")); calls != tt.calls {
+				t.Errorf("sendMessage calls = %d, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestSendPhotoUploadIsNotRepeated(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	server.FailNext("sendPhoto", telegramtest.TooManyRequests(1))
+
+	_, err := server.Client().SendPhoto(context.Background(), &telegram.SendPhotoRequest{
+		ChatID: 42,
+		Photo:  telegram.FileFromReader("chart.png", strings.NewReader("png")),
+	})
+	var retryErr *telegram.RetryAfterError
+	if !errors.As(err, &retryErr) || retryErr.RetryAfter != time.Second {
+		t.Fatalf("SendPhoto() error = %v, want a *telegram.RetryAfterError after 1s", err)
+	}
+	if calls := len(server.CallsTo("sendPhoto")); calls != 1 {
+		t.Errorf("sendPhoto calls = %d, want 1", calls)
+	}
+}
+
+func TestSendMessageFollowsMigration(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	const newChatID = -1001234567890
+	server.FailNext("sendMessage", telegramtest.ChatMigrated(newChatID))
+
+	req := &telegram.SendMessageRequest{ChatID: int64(-4242), Text: "hola"}
+	msg, err := server.Client().SendMessage(context.Background(), req)
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if msg.Chat.ID != newChatID {
+		t.Errorf("Chat.ID = %d, want the migrated chat %d", msg.Chat.ID, newChatID)
+	}
+	if req.ChatID != int64(-4242) {
+		t.Errorf("request ChatID = %v, want it unchanged", req.ChatID)
+	}
+
+	calls := server.CallsTo("sendMessage")
+	if len(calls) != 2 {
+		t.Fatalf("sendMessage calls = %d, want 2", len(calls))
+	}
+	var retried struct {
+		ChatID int64 `json:"chat_id"`
+	}
+	if err := calls[1].Decode(&retried); err != nil {
+		t.Fatal(err)
+	}
+	if retried.ChatID != newChatID {
+		t.Errorf("retried chat_id = %d, want %d", retried.ChatID, newChatID)
+	}
+}
+
+func TestSendDocumentUploadParts(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+
+	document := telegram.FileFromReader("report.pdf", strings.NewReader("pdf"))
+	thumbnail := telegram.FileFromReader("thumb.jpg", strings.NewReader("jpg"))
+	before := *document
+	req := &telegram.SendDocumentRequest{ChatID: 42, Document: document, Thumbnail: thumbnail}
+	if _, err := server.Client().SendDocument(context.Background(), req); err != nil {
+		t.Fatalf("SendDocument() error = %v", err)
+	}
+	if *document != before {
+		t.Errorf("Document = %+v, want the request file unchanged", *document)
+	}
+
+	calls := server.CallsTo("sendDocument")
+	if len(calls) != 1 {
+		t.Fatalf("sendDocument calls = %d, want 1", len(calls))
+	}
+	var params struct {
+		Document  string `json:"document"`
+		Thumbnail string `json:"thumbnail"`
+	}
+	if err := calls[0].Decode(&params); err != nil {
+		t.Fatal(err)
+	}
+	for ref, want := range map[string]string{params.Document: "pdf", params.Thumbnail: "jpg"} {
+		part, ok := strings.CutPrefix(ref, "attach://")
+		if !ok || string(calls[0].Files[part]) != want {
+			t.Errorf("%q has %q, want an attached part with %q", ref, calls[0].Files[part], want)
+		}
+	}
+	if params.Document == params.Thumbnail {
+		t.Errorf("document and thumbnail are both %q, want separate parts", params.Document)
+	}
+}
@@ -0,0 +1,290 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf16"
+)
+
+// MaxMessageLength is the maximum length of a message text, in UTF-16 code units.
+const MaxMessageLength = 4096
+
+// TextChunk is a part of a long text together with the entities inside it,
+// with offsets relative to the chunk.
+type TextChunk struct {
+	Text     string
+	Entities []MessageEntity
+}
+
+// SplitText splits text into chunks of at most limit UTF-16 code units.
+//
+// Chunks are cut preferably on paragraph boundaries, then on line boundaries and
+// then on spaces, dropping the separator. Cuts never fall inside an entity or,
+// for MarkdownV2 and HTML texts, inside a formatting span, unless a single span
+// is longer than limit.
+func SplitText(text string, entities []MessageEntity, parseMode string, limit int) []TextChunk {
+	units := utf16.Encode([]rune(text))
+	if len(units) <= limit || limit <= 0 {
+		return []TextChunk{{Text: text, Entities: entities}}
+	}
+
+	var safe []bool
+	switch parseMode {
+	case ParseModeMarkdownV2:
+		safe = markdownV2SafeCuts(units)
+	case ParseModeHTML:
+		safe = htmlSafeCuts(units)
+	default:
+		safe = entitySafeCuts(units, entities)
+	}
+	// allowed reports if the separator units[a:b] can be dropped to cut the text.
+	allowed := func(a, b int) bool {
+		if !safe[a] || !safe[b] {
+			return false
+		}
+		for _, e := range entities {
+			if e.Offset >= a && e.Offset < b {
+				return false
+			}
+		}
+		return true
+	}
+
+	var chunks []TextChunk
+	start := 0
+	for len(units)-start > limit {
+		end, next := findCut(units, start, start+limit, allowed)
+		chunks = append(chunks, newTextChunk(units, entities, start, end))
+		start = next
+	}
+	return append(chunks, newTextChunk(units, entities, start, len(units)))
+}
+
+// findCut returns where the chunk starting at start must end (at most last)
+// and where the next chunk starts.
+func findCut(units []uint16, start, last int, allowed func(a, b int) bool) (end, next int) {
+	separators := []func(i int) int{
+		// Paragraph: blank line
+		func(i int) int {
+			if i+1 < len(units) && units[i] == '\n' && units[i+1] == '\n' {
+				return 2
+			}
+			return 0
+		},
+		// Line
+		func(i int) int {
+			if units[i] == '\n' {
+				return 1
+			}
+			return 0
+		},
+		// Word
+		func(i int) int {
+			if units[i] == ' ' {
+				return 1
+			}
+			return 0
+		},
+	}
+	for _, separator := range separators {
+		for i := last; i > start; i-- {
+			if i >= len(units) {
+				continue
+			}
+			if n := separator(i); n > 0 && allowed(i, i+n) {
+				return i, i + n
+			}
+		}
+	}
+	// Hard cut, avoiding surrogate pairs
+	for i := last; i > start; i-- {
+		if !isLowSurrogate(units[i]) && allowed(i, i) {
+			return i, i
+		}
+	}
+	if isLowSurrogate(units[last]) {
+		last--
+	}
+	return last, last
+}
+
+// newTextChunk builds the chunk units[start:end], keeping the entities inside it.
+// Entities crossing the chunk bounds are clipped.
+func newTextChunk(units []uint16, entities []MessageEntity, start, end int) TextChunk {
+	chunk := TextChunk{Text: string(utf16.Decode(units[start:end]))}
+	for _, e := range entities {
+		from, to := max(e.Offset, start), min(e.Offset+e.Length, end)
+		if from >= to {
+			continue
+		}
+		e.Offset, e.Length = from-start, to-from
+		chunk.Entities = append(chunk.Entities, e)
+	}
+	return chunk
+}
+
+// entitySafeCuts marks the positions that are not strictly inside an entity.
+func entitySafeCuts(units []uint16, entities []MessageEntity) []bool {
+	safe := make([]bool, len(units)+1)
+	for i := range safe {
+		safe[i] = true
+	}
+	for _, e := range entities {
+		for i := e.Offset + 1; i < e.Offset+e.Length && i < len(safe); i++ {
+			safe[i] = false
+		}
+	}
+	return safe
+}
+
+// htmlSafeCuts marks the positions outside every tag, character reference and element.
+func htmlSafeCuts(units []uint16) []bool {
+	safe := make([]bool, len(units)+1)
+	depth, inTag, closing, inReference := 0, false, false, false
+	for i, c := range units {
+		safe[i] = depth == 0 && !inTag && !inReference
+		switch {
+		case inTag:
+			if c == '>' {
+				inTag = false
+				if closing {
+					depth--
+				} else {
+					depth++
+				}
+			}
+		case inReference:
+			inReference = c != ';'
+		case c == '<':
+			inTag, closing = true, i+1 < len(units) && units[i+1] == '/'
+		case c == '&':
+			inReference = true
+		}
+	}
+	safe[len(units)] = true
+	return safe
+}
+
+// markdownV2SafeCuts marks the positions outside every MarkdownV2 formatting span.
+func markdownV2SafeCuts(units []uint16) []bool {
+	safe := make([]bool, len(units)+1)
+	open := map[string]bool{}
+	inPre, inCode, inLinkURL, linkDepth := false, false, false, 0
+	closed := func() bool {
+		for _, o := range open {
+			if o {
+				return false
+			}
+		}
+		return !inPre && !inCode && !inLinkURL && linkDepth == 0
+	}
+	hasPrefix := func(i int, prefix string) bool {
+		if i+len(prefix) > len(units) {
+			return false
+		}
+		for j := 0; j < len(prefix); j++ {
+			if units[i+j] != uint16(prefix[j]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for i := 0; i < len(units); i++ {
+		safe[i] = closed()
+		c := units[i]
+		switch {
+		case c == '\\':
+			i++
+		case inPre:
+			if hasPrefix(i, "```") {
+				inPre = false
+				i += 2
+			}
+		case inCode:
+			inCode = c != '`'
+		case inLinkURL:
+			inLinkURL = c != ')'
+		case hasPrefix(i, "```"):
+			inPre = true
+			i += 2
+		case c == '`':
+			inCode = true
+		case hasPrefix(i, "__"):
+			open["__"] = !open["__"]
+			i++
+		case hasPrefix(i, "||"):
+			open["||"] = !open["||"]
+			i++
+		case c == '_' || c == '*' || c == '~':
+			open[string(rune(c))] = !open[string(rune(c))]
+		case c == '[':
+			linkDepth++
+		case c == ']' && linkDepth > 0:
+			linkDepth--
+			if hasPrefix(i+1, "(") {
+				inLinkURL = true
+				i++
+			}
+		}
+	}
+	safe[len(units)] = true
+	return safe
+}
+
+func isLowSurrogate(u uint16) bool {
+	return u >= 0xDC00 && u < 0xE000
+}
+
+// PartialSendError is returned by SendLongMessage when some chunks were sent
+// before a send failed. Sent is the number of chunks delivered, the first
+// chunk to send when resuming. Repeating the whole send duplicates them, so
+// callers that retry on errors (e.g. a state machine) should treat it as a
+// success or resume from Sent.
+type PartialSendError struct {
+	Sent int
+	Err  error
+}
+
+func (e *PartialSendError) Error() string {
+	return fmt.Sprintf("sent %d chunks of a long message: %s", e.Sent, e.Err)
+}
+
+func (e *PartialSendError) Unwrap() error {
+	return e.Err
+}
+
+// SendLongMessage sends req splitting texts longer than MaxMessageLength into
+// several messages, each one a reply to the previous. The reply markup is
+// attached to the last message. It returns the sent messages in order.
+// When a chunk after the first one fails the error is a *PartialSendError.
+func (c *Client) SendLongMessage(ctx context.Context, req *SendMessageRequest) ([]*Message, error) {
+	chunks := SplitText(req.Text, req.Entities, req.ParseMode, MaxMessageLength)
+	messages := make([]*Message, 0, len(chunks))
+	for i, chunk := range chunks {
+		chunkReq := *req
+		chunkReq.Text, chunkReq.Entities = chunk.Text, chunk.Entities
+		if i > 0 {
+			chunkReq.ReplyParameters = &ReplyParameters{
+				MessageID:                int(messages[i-1].MessageID),
+				AllowSendingWithoutReply: true,
+			}
+		}
+		if i < len(chunks)-1 {
+			chunkReq.ReplyMarkup = nil
+		}
+		if i > 0 && messages[i-1].Chat != nil {
+			// Keep sending to the migrated chat if the group was upgraded
+			chunkReq.ChatID = messages[i-1].Chat.ID
+		}
+		msg, err := c.SendMessage(ctx, &chunkReq)
+		if err != nil && i > 0 {
+			return messages, &PartialSendError{Sent: len(messages), Err: err}
+		}
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
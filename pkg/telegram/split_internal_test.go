@@ -0,0 +1,86 @@
+package telegram
+
+import (
+	"slices"
+	"testing"
+	"unicode/utf16"
+)
+
+// unsafeCuts returns the positions of text that safeCuts marks as unsafe.
+func unsafeCuts(text string, safeCuts func(units []uint16) []bool) []int {
+	var positions []int
+	for i, ok := range safeCuts(utf16.Encode([]rune(text))) {
+		if !ok {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+func TestMarkdownV2SafeCuts(t *testing.T) {
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{`a\.b`, []int{2}},
+		{`a\\b`, []int{2}},
+		{"a *bc* d", []int{3, 4, 5}},
+		{"__ab__", []int{1, 2, 3, 4, 5}},
+		{"`a*b`", []int{1, 2, 3, 4}},
+		{"```go\na\n```", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"[a](b\\)c)", []int{1, 2, 3, 4, 5, 6, 7, 8}},
+		{`*a\*b*`, []int{1, 2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		if got := unsafeCuts(tt.text, markdownV2SafeCuts); !slices.Equal(got, tt.want) {
+			t.Errorf("markdownV2SafeCuts(%q) unsafe at %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLSafeCuts(t *testing.T) {
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"a &amp; b", []int{3, 4, 5, 6}},
+		{"<b>ab</b>", []int{1, 2, 3, 4, 5, 6, 7, 8}},
+		{`x<a href="y z">w</a>`, []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19}},
+		{"<b><i>a</i></b>c", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}},
+	}
+	for _, tt := range tests {
+		if got := unsafeCuts(tt.text, htmlSafeCuts); !slices.Equal(got, tt.want) {
+			t.Errorf("htmlSafeCuts(%q) unsafe at %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFindCut(t *testing.T) {
+	always := func(a, b int) bool { return true }
+	tests := []struct {
+		name        string
+		text        string
+		start, last int
+		allowed     func(a, b int) bool
+		end, next   int
+	}{
+		{"paragraph", "ab\n\ncd\nef gh", 0, 11, always, 2, 4},
+		{"line", "ab cd\nef gh", 0, 10, always, 5, 6},
+		{"word", "ab cd ef", 0, 7, always, 5, 6},
+		{"separator after last", "abcd ef", 0, 3, always, 3, 3},
+		{"from start", "ab cd ef gh", 3, 9, always, 8, 9},
+		{"disallowed separator", "ab cd ef", 0, 7, func(a, b int) bool { return a != 5 }, 2, 3},
+		{"hard cut", "abcdefgh", 0, 5, always, 5, 5},
+		{"hard cut before surrogate pair", "abcd😀ef", 0, 5, always, 4, 4},
+		{"nothing allowed", "abcdefgh", 0, 5, func(a, b int) bool { return false }, 5, 5},
+		{"nothing allowed in surrogate pair", "abcd😀ef", 0, 5, func(a, b int) bool { return false }, 4, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, next := findCut(utf16.Encode([]rune(tt.text)), tt.start, tt.last, tt.allowed)
+			if end != tt.end || next != tt.next {
+				t.Errorf("findCut() = %d, %d, want %d, %d", end, next, tt.end, tt.next)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package telegram_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/telegramtest"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestSendLongMessagePartialSend(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	// The first chunk is delivered, the user blocks the bot before the second one
+	sent := 0
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if sent++; sent == 2 {
+			server.FailNext("sendMessage", telegramtest.BlockedByUser())
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})
+	client := server.Client(telegram.WithHTTPClient(&http.Client{Transport: transport}))
+
+	text := strings.Repeat("a", telegram.MaxMessageLength) + "\n\n" + strings.Repeat("b", 10) + "\n\n" + strings.Repeat("c", telegram.MaxMessageLength)
+	messages, err := client.SendLongMessage(context.Background(), &telegram.SendMessageRequest{ChatID: 42, Text: text})
+
+	var partialErr *telegram.PartialSendError
+	if !errors.As(err, &partialErr) {
+		t.Fatalf("SendLongMessage() error = %v, want a *telegram.PartialSendError", err)
+	}
+	if partialErr.Sent != 1 || len(messages) != 1 {
+		t.Errorf("Sent = %d with %d messages, want 1", partialErr.Sent, len(messages))
+	}
+	var apiErr *telegram.Error
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode != http.StatusForbidden {
+		t.Errorf("wrapped error = %v, want the 403 error", partialErr.Err)
+	}
+}
+
+func TestSendLongMessageFirstChunkFails(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	server.FailNext("sendMessage", telegramtest.BlockedByUser())
+
+	_, err := server.Client().SendLongMessage(context.Background(), &telegram.SendMessageRequest{ChatID: 42, Text: "hola"})
+	var partialErr *telegram.PartialSendError
+	if err == nil || errors.As(err, &partialErr) {
+		t.Errorf("SendLongMessage() error = %v, want the plain API error", err)
+	}
+}
+
+// checkChunks verifies that every chunk fits in limit, that its entities are inside
+// it and that the chunks put back together give text without the separators.
+func checkChunks(t *testing.T, chunks []telegram.TextChunk, limit int, text string) {
+	t.Helper()
+	joined := ""
+	for i, chunk := range chunks {
+		length := telegram.UTF16Len(chunk.Text)
+		if length > limit {
+			t.Errorf("chunk %d has %d UTF-16 units, want at most %d", i, length, limit)
+		}
+		if strings.ContainsRune(chunk.Text, utf8.RuneError) {
+			t.Errorf("chunk %d splits a character: %q", i, chunk.Text)
+		}
+		for _, e := range chunk.Entities {
+			if e.Offset < 0 || e.Offset+e.Length > length {
+				t.Errorf("chunk %d entity %+v is outside the chunk", i, e)
+			}
+		}
+		joined += chunk.Text
+	}
+	if strip := func(s string) string { return strings.NewReplacer("\n", "", " ", "").Replace(s) }; strip(joined) != strip(text) {
+		t.Errorf("chunks do not add up to the text")
+	}
+}
+
+func TestSplitTextShort(t *testing.T) {
+	entities := []telegram.MessageEntity{{Type: telegram.EntityBold, Offset: 0, Length: 4}}
+	chunks := telegram.SplitText("hola", entities, "", telegram.MaxMessageLength)
+	if len(chunks) != 1 || chunks[0].Text != "hola" || len(chunks[0].Entities) != 1 {
+		t.Errorf("SplitText() = %+v, want the text unchanged", chunks)
+	}
+}
+
+func TestSplitTextSeparators(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"paragraph before line", "uno dos\ntres\n\ncuatro cinco", 14, []string{"uno dos\ntres", "cuatro cinco"}},
+		{"line before word", "uno dos\ntres cuatro", 14, []string{"uno dos", "tres cuatro"}},
+		{"word", "uno dos tres cuatro", 10, []string{"uno dos", "tres", "cuatro"}},
+		{"hard cut", "abcdefghijklmnopq", 8, []string{"abcdefgh", "ijklmnop", "q"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, chunk := range telegram.SplitText(tt.text, nil, "", tt.limit) {
+				got = append(got, chunk.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTextLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"no whitespace", strings.Repeat("a", 3*telegram.MaxMessageLength+17)},
+		{"astral characters without whitespace", strings.Repeat("😀", telegram.MaxMessageLength+1)},
+		{"astral characters with spaces", strings.Repeat("😀😀😀 ", telegram.MaxMessageLength/3)},
+		{"lines", strings.Repeat("Gasto de $23.50 en tacos\n", 500)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, limit := range []int{telegram.MaxMessageLength, telegram.MaxMessageLength - 1} {
+				chunks := telegram.SplitText(tt.text, nil, "", limit)
+				if len(chunks) < 2 {
+					t.Fatalf("SplitText() = %d chunks, want several", len(chunks))
+				}
+				checkChunks(t, chunks, limit, tt.text)
+			}
+		})
+	}
+}
+
+func TestSplitTextRebasesEntities(t *testing.T) {
+	text := "uno dos #comida tres\n\ncuatro 😀 #renta fin"
+	entities := []telegram.MessageEntity{
+		{Type: telegram.EntityHashtag, Offset: 8, Length: 7},
+		{Type: telegram.EntityHashtag, Offset: 32, Length: 6},
+		// Longer than the limit, it is clipped in each chunk
+		{Type: telegram.EntityItalic, Offset: 0, Length: 42},
+	}
+	chunks := telegram.SplitText(text, entities, "", 24)
+	checkChunks(t, chunks, 24, text)
+	if len(chunks) != 2 {
+		t.Fatalf("SplitText() = %+v, want 2 chunks", chunks)
+	}
+
+	var hashtags []string
+	for _, chunk := range chunks {
+		for _, e := range chunk.Entities {
+			if e.Type == telegram.EntityHashtag {
+				hashtags = append(hashtags, telegram.EntityText(chunk.Text, e))
+			}
+		}
+	}
+	if want := []string{"#comida", "#renta"}; !reflect.DeepEqual(hashtags, want) {
+		t.Errorf("hashtags = %q, want %q", hashtags, want)
+	}
+	last := chunks[1].Entities
+	if italic := last[len(last)-1]; italic.Type != telegram.EntityItalic || italic.Offset != 0 || italic.Length != telegram.UTF16Len(chunks[1].Text) {
+		t.Errorf("italic entity in the second chunk = %+v, want the whole chunk", italic)
+	}
+}
+
+func TestSplitTextKeepsEntitiesWhole(t *testing.T) {
+	// The only spaces are inside the bold entity, so the text is hard cut after it
+	text := strings.Repeat("a", 10) + "b b b b" + strings.Repeat("c", 10)
+	entities := []telegram.MessageEntity{{Type: telegram.EntityBold, Offset: 10, Length: 7}}
+	chunks := telegram.SplitText(text, entities, "", 20)
+	checkChunks(t, chunks, 20, text)
+	for _, chunk := range chunks {
+		for _, e := range chunk.Entities {
+			if got := telegram.EntityText(chunk.Text, e); got != "b b b b" {
+				t.Errorf("bold entity = %q, want it whole", got)
+			}
+		}
+	}
+}
+
+func TestSplitTextMarkdownV2(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"escapes without whitespace", strings.Repeat(`a\.`, 3000)},
+		{"spans", strings.Repeat("*uno dos* _tres cuatro_ __cinco seis__ ||siete|| ", 200)},
+		{"code and links", strings.Repeat("`x y` [ver ticket](https://x.mx/a\\)) ```go\na b\n``` ", 200)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := telegram.SplitText(tt.text, nil, telegram.ParseModeMarkdownV2, 1000)
+			checkChunks(t, chunks, 1000, tt.text)
+			for i, chunk := range chunks {
+				trailing := len(chunk.Text) - len(strings.TrimRight(chunk.Text, `\`))
+				if trailing%2 == 1 {
+					t.Errorf("chunk %d ends inside an escape: %q", i, chunk.Text[len(chunk.Text)-5:])
+				}
+				for _, marker := range []string{"*", "~", "||", "`"} {
+					if n := strings.Count(strings.ReplaceAll(chunk.Text, `\`+marker, ""), marker); n%2 == 1 {
+						t.Errorf("chunk %d has %d %q markers, want them balanced", i, n, marker)
+					}
+				}
+				if strings.Count(chunk.Text, "[") != strings.Count(chunk.Text, "](") {
+					t.Errorf("chunk %d cuts a link", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitTextHTML(t *testing.T) {
+	text := strings.Repeat(`<b>uno dos</b> tres &amp; <a href="https://x.mx/a b">ver ticket</a> <pre>a b</pre> `, 200)
+	chunks := telegram.SplitText(text, nil, telegram.ParseModeHTML, 1000)
+	checkChunks(t, chunks, 1000, text)
+	for i, chunk := range chunks {
+		if strings.Count(chunk.Text, "<") != strings.Count(chunk.Text, ">") {
+			t.Errorf("chunk %d cuts a tag", i)
+		}
+		for _, tag := range []string{"b", "a", "pre"} {
+			if strings.Count(chunk.Text, "<"+tag+">")+strings.Count(chunk.Text, "<"+tag+" ") != strings.Count(chunk.Text, "</"+tag+">") {
+				t.Errorf("chunk %d cuts a <%s> element", i, tag)
+			}
+		}
+		if strings.Count(chunk.Text, "&") != strings.Count(chunk.Text, "&amp;") {
+			t.Errorf("chunk %d cuts a character reference", i)
+		}
+	}
+}
@@ -0,0 +1,441 @@
+package telegramtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// handle runs a method other than getUpdates, s.mu must be held.
+func (s *Server) handle(call Call) (interface{}, *telegram.Error) {
+	switch call.Method {
+	case "getMe":
+		return Bot, nil
+	case "sendMessage":
+		return s.sendMessage(call)
+	case "editMessageText":
+		return s.editMessageText(call)
+	case "deleteMessage":
+		return s.deleteMessage(call)
+	case "sendPhoto", "sendDocument", "sendVoice":
+		return s.sendMedia(call)
+	case "answerCallbackQuery", "sendChatAction":
+		return true, nil
+	case "getFile":
+		return s.getFile(call)
+	case "setWebhook":
+		return s.setWebhook(call)
+	case "deleteWebhook":
+		return s.deleteWebhook(call)
+	case "setMyCommands", "getMyCommands", "deleteMyCommands":
+		return s.myCommands(call)
+	case "getWebhookInfo":
+		info := s.webhook
+		info.PendingUpdateCount = len(s.updates)
+		return info, nil
+	default:
+		return nil, &telegram.Error{ErrorCode: http.StatusNotFound, Description: "Not Found"}
+	}
+}
+
+func (s *Server) sendMessage(call Call) (interface{}, *telegram.Error) {
+	var req struct {
+		ChatID          json.RawMessage                `json:"chat_id"`
+		Text            string                         `json:"text"`
+		Entities        []telegram.MessageEntity       `json:"entities"`
+		ReplyParameters *telegram.ReplyParameters      `json:"reply_parameters"`
+		ReplyMarkup     *telegram.InlineKeyboardMarkup `json:"reply_markup"`
+	}
+	if err := call.Decode(&req); err != nil {
+		return nil, badRequest(err.Error())
+	}
+	chat, apiErr := parseChat(req.ChatID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return nil, badRequest("message text is empty")
+	}
+	if telegram.UTF16Len(text) > telegram.MaxMessageLength {
+		return nil, badRequest("message is too long")
+	}
+
+	msg := s.newMessage(chat, req.ReplyParameters)
+	msg.Text = text
+	msg.Entities = req.Entities
+	msg.ReplyMarkup = inlineKeyboard(req.ReplyMarkup)
+	return msg, nil
+}
+
+func (s *Server) editMessageText(call Call) (interface{}, *telegram.Error) {
+	var req struct {
+		ChatID          json.RawMessage                `json:"chat_id"`
+		MessageID       int64                          `json:"message_id"`
+		InlineMessageID string                         `json:"inline_message_id"`
+		Text            string                         `json:"text"`
+		Entities        []telegram.MessageEntity       `json:"entities"`
+		ReplyMarkup     *telegram.InlineKeyboardMarkup `json:"reply_markup"`
+	}
+	if err := call.Decode(&req); err != nil {
+		return nil, badRequest(err.Error())
+	}
+	if req.InlineMessageID != "" {
+		return true, nil
+	}
+	chat, apiErr := parseChat(req.ChatID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	msg, ok := s.messages[messageKey{chat.ID, req.MessageID}]
+	if !ok {
+		return nil, badRequest("message to edit not found")
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return nil, badRequest("message text is empty")
+	}
+	if text == msg.Text && sameJSON(inlineKeyboard(req.ReplyMarkup), msg.ReplyMarkup) {
+		return nil, badRequest("message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message")
+	}
+
+	msg.Text = text
+	msg.Entities = req.Entities
+	msg.ReplyMarkup = inlineKeyboard(req.ReplyMarkup)
+	msg.EditDate = time.Now().Unix()
+	return msg, nil
+}
+
+func (s *Server) deleteMessage(call Call) (interface{}, *telegram.Error) {
+	var req struct {
+		ChatID    json.RawMessage `json:"chat_id"`
+		MessageID int64           `json:"message_id"`
+	}
+	if err := call.Decode(&req); err != nil {
+		return nil, badRequest(err.Error())
+	}
+	chat, apiErr := parseChat(req.ChatID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	key := messageKey{chat.ID, req.MessageID}
+	if _, ok := s.messages[key]; !ok {
+		return nil, badRequest("message to delete not found")
+	}
+	delete(s.messages, key)
+	return true, nil
+}
+
+// sendMedia handles sendPhoto, sendDocument and sendVoice. Uploaded files are
+// stored, so they can be resolved with getFile afterwards.
+func (s *Server) sendMedia(call Call) (interface{}, *telegram.Error) {
+	field := strings.ToLower(strings.TrimPrefix(call.Method, "send"))
+	var req struct {
+		ChatID          json.RawMessage                `json:"chat_id"`
+		Caption         string                         `json:"caption"`
+		CaptionEntities []telegram.MessageEntity       `json:"caption_entities"`
+		ReplyParameters *telegram.ReplyParameters      `json:"reply_parameters"`
+		ReplyMarkup     *telegram.InlineKeyboardMarkup `json:"reply_markup"`
+	}
+	if err := call.Decode(&req); err != nil {
+		return nil, badRequest(err.Error())
+	}
+	var params map[string]json.RawMessage
+	call.Decode(&params)
+	var ref string
+	if err := json.Unmarshal(params[field], &ref); err != nil || ref == "" {
+		return nil, badRequest("there is no " + field + " in the request")
+	}
+	chat, apiErr := parseChat(req.ChatID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var stored *storedFile
+	switch {
+	case strings.HasPrefix(ref, "attach://"):
+		content, ok := call.Files[strings.TrimPrefix(ref, "attach://")]
+		if !ok {
+			return nil, badRequest("wrong file identifier/HTTP URL specified")
+		}
+		stored = s.storeFile(field, content)
+	case strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://"):
+		stored = s.storeFile(path.Base(ref), nil)
+	default:
+		if stored = s.files[ref]; stored == nil {
+			return nil, badRequest("wrong file identifier/HTTP URL specified")
+		}
+	}
+
+	msg := s.newMessage(chat, req.ReplyParameters)
+	msg.Caption = req.Caption
+	msg.CaptionEntities = req.CaptionEntities
+	msg.ReplyMarkup = inlineKeyboard(req.ReplyMarkup)
+	file := stored.file
+	switch field {
+	case "photo":
+		msg.Photo = []telegram.PhotoSize{{FileID: file.FileID, FileUniqueID: file.FileUniqueID, FileSize: int(file.FileSize)}}
+	case "document":
+		msg.Document = &telegram.Document{FileID: file.FileID, FileUniqueID: file.FileUniqueID, FileName: path.Base(file.FilePath), FileSize: int(file.FileSize)}
+	case "voice":
+		msg.Voice = &telegram.Voice{FileID: file.FileID, FileUniqueID: file.FileUniqueID, FileSize: int(file.FileSize)}
+	}
+	return msg, nil
+}
+
+func (s *Server) getFile(call Call) (interface{}, *telegram.Error) {
+	var req telegram.GetFileRequest
+	if err := call.Decode(&req); err != nil {
+		return nil, badRequest(err.Error())
+	}
+	stored, ok := s.files[req.FileID]
+	if !ok {
+		return nil, badRequest("invalid file_id")
+	}
+	return stored.file, nil
+}
+
+// getUpdates returns the pending updates after the offset, waiting up to the
+// long polling timeout for new ones.
+func (s *Server) getUpdates(r *http.Request, call Call) (interface{}, *telegram.Error) {
+	var req telegram.GetUpdatesRequest
+	if err := call.Decode(&req); err != nil {
+		return nil, badRequest(err.Error())
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	deadline := time.After(time.Duration(req.Timeout) * time.Second)
+
+	for {
+		s.mu.Lock()
+		if s.webhook.URL != "" {
+			s.mu.Unlock()
+			return nil, &telegram.Error{
+				ErrorCode:   http.StatusConflict,
+				Description: "Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first",
+			}
+		}
+		// Updates before the offset are confirmed and forgotten
+		if req.Offset > 0 {
+			pending := s.updates[:0]
+			for _, update := range s.updates {
+				if update.UpdateID >= req.Offset {
+					pending = append(pending, update)
+				}
+			}
+			s.updates = pending
+		}
+		updates := make([]telegram.Update, 0, limit)
+		for _, update := range s.updates {
+			if len(updates) == limit {
+				break
+			}
+			if allowed(update, req.AllowedUpdates) {
+				updates = append(updates, update)
+			}
+		}
+		arrived := s.updateArrived
+		s.mu.Unlock()
+
+		if len(updates) > 0 || req.Timeout <= 0 {
+			return updates, nil
+		}
+		select {
+		case <-arrived:
+		case <-deadline:
+			return updates, nil
+		case <-r.Context().Done():
+			return updates, nil
+		}
+	}
+}
+
+func (s *Server) setWebhook(call Call) (interface{}, *telegram.Error) {
+	var req telegram.SetWebhookRequest
+	if err := call.Decode(&req); err != nil {
+		return nil, badRequest(err.Error())
+	}
+	if req.URL == "" {
+		return s.deleteWebhook(call)
+	}
+	if u, err := url.Parse(req.URL); err != nil || u.Scheme != "https" {
+		return nil, badRequest("bad webhook: An HTTPS URL must be provided for webhook")
+	}
+	s.webhook = telegram.WebhookInfo{
+		URL:            req.URL,
+		MaxConnections: req.MaxConnections,
+		AllowedUpdates: req.AllowedUpdates,
+	}
+	s.webhookSecret = req.SecretToken
+	if req.DropPendingUpdates {
+		s.updates = nil
+	}
+	return true, nil
+}
+
+func (s *Server) deleteWebhook(call Call) (interface{}, *telegram.Error) {
+	var req telegram.DeleteWebhookRequest
+	if err := call.Decode(&req); err != nil {
+		return nil, badRequest(err.Error())
+	}
+	s.webhook = telegram.WebhookInfo{}
+	s.webhookSecret = ""
+	if req.DropPendingUpdates {
+		s.updates = nil
+	}
+	return true, nil
+}
+
+// myCommands handles setMyCommands, getMyCommands and deleteMyCommands.
+func (s *Server) myCommands(call Call) (interface{}, *telegram.Error) {
+	var req telegram.SetMyCommandsRequest
+	if err := call.Decode(&req); err != nil {
+		return nil, badRequest(err.Error())
+	}
+	key := commandsKey(req.Scope, req.LanguageCode)
+	switch call.Method {
+	case "getMyCommands":
+		return append([]telegram.BotCommand{}, s.commands[key]...), nil
+	case "deleteMyCommands":
+		delete(s.commands, key)
+		return true, nil
+	}
+	if len(req.Commands) > 100 {
+		return nil, badRequest("too many commands specified")
+	}
+	for _, command := range req.Commands {
+		if !validCommand(command.Command) {
+			return nil, badRequest("BOT_COMMAND_INVALID")
+		}
+		if command.Description == "" || len([]rune(command.Description)) > 256 {
+			return nil, badRequest("BOT_COMMAND_DESCRIPTION_INVALID")
+		}
+	}
+	s.commands[key] = req.Commands
+	return true, nil
+}
+
+// newMessage stores a new message sent by the bot to chat, s.mu must be held.
+func (s *Server) newMessage(chat *telegram.Chat, reply *telegram.ReplyParameters) *telegram.Message {
+	s.lastMessageID++
+	bot := Bot
+	msg := &telegram.Message{
+		MessageID: s.lastMessageID,
+		From:      &bot,
+		Chat:      chat,
+		Date:      time.Now().Unix(),
+	}
+	if reply != nil {
+		if replyTo, ok := s.messages[messageKey{chat.ID, int64(reply.MessageID)}]; ok {
+			replyCopy := *replyTo
+			replyCopy.ReplyToMessage = nil
+			msg.ReplyToMessage = &replyCopy
+		}
+	}
+	s.messages[messageKey{chat.ID, msg.MessageID}] = msg
+	return msg
+}
+
+// storeFile stores content with a new file_id, s.mu must be held.
+func (s *Server) storeFile(name string, content []byte) *storedFile {
+	id := itoa(int64(len(s.files) + 1))
+	stored := &storedFile{
+		file: telegram.File{
+			FileID:       "file-" + id,
+			FileUniqueID: "unique-" + id,
+			FileSize:     int64(len(content)),
+			FilePath:     "files/" + id + "/" + path.Base(name),
+		},
+		content: content,
+	}
+	s.files[stored.file.FileID] = stored
+	return stored
+}
+
+// commandsKey identifies the command list of a scope and language.
+func commandsKey(scope *telegram.BotCommandScope, languageCode string) string {
+	if scope == nil {
+		scope = &telegram.BotCommandScope{Type: telegram.BotCommandScopeDefault}
+	}
+	key := scope.Type + "/" + languageCode
+	if scope.ChatID != nil {
+		key += "/" + fmt.Sprint(scope.ChatID)
+	}
+	if scope.UserID != 0 {
+		key += "/" + itoa(scope.UserID)
+	}
+	return key
+}
+
+// validCommand reports if name has 1-32 lowercase letters, digits and underscores.
+func validCommand(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseChat resolves a chat_id parameter, integer or "@username", to the
+// chat telegram.ChatFromID describes.
+func parseChat(raw json.RawMessage) (*telegram.Chat, *telegram.Error) {
+	var id int64
+	if err := json.Unmarshal(raw, &id); err == nil && id != 0 {
+		return telegram.ChatFromID(id), nil
+	}
+	var username string
+	if err := json.Unmarshal(raw, &username); err == nil && username != "" && username != "@" {
+		if chat := telegram.ChatFromID(username); chat.ID != 0 || strings.HasPrefix(username, "@") {
+			return chat, nil
+		}
+	}
+	return nil, badRequest("chat not found")
+}
+
+// allowed reports if update is one of kinds, empty kinds allows every kind.
+func allowed(update telegram.Update, kinds []telegram.UpdateKind) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, kind := range kinds {
+		if update.Kind() == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// inlineKeyboard drops empty markups, which only happen when another markup type was sent.
+func inlineKeyboard(markup *telegram.InlineKeyboardMarkup) *telegram.InlineKeyboardMarkup {
+	if markup == nil || len(markup.InlineKeyboard) == 0 {
+		return nil
+	}
+	return markup
+}
+
+func sameJSON(a, b interface{}) bool {
+	encodedA, _ := json.Marshal(a)
+	encodedB, _ := json.Marshal(b)
+	return string(encodedA) == string(encodedB)
+}
+
+func badRequest(description string) *telegram.Error {
+	return &telegram.Error{ErrorCode: http.StatusBadRequest, Description: "Bad Request: " + description}
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,372 @@
+// Package telegramtest provides an in-process fake of the Telegram Bot API to
+// test bots offline.
+//
+//	server := telegramtest.NewServer()
+//	defer server.Close()
+//	client := server.Client()
+//
+//	server.FailNext("sendMessage", telegramtest.TooManyRequests(3))
+//	msg, err := client.SendMessage(ctx, &telegram.SendMessageRequest{ChatID: 42, Text: "hola"})
+//	calls := server.CallsTo("sendMessage") // both attempts
+//
+// The server keeps the sent messages, files, pending updates and webhook
+// settings in memory, so multi step flows (send, edit, download) behave like
+// the real API. Only the methods used by this repository are implemented,
+// any other method fails with 404.
+package telegramtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// Token is the bot token accepted by the server, requests with any other token fail with 401.
+const Token = "123456:TEST-TOKEN"
+
+// Bot is the user returned by getMe and set as the sender of the messages sent by the bot.
+var Bot = telegram.User{
+	ID:        123456,
+	IsBot:     true,
+	FirstName: "Test Bot",
+	Username:  "test_bot",
+}
+
+// Call is a request received by the server.
+type Call struct {
+	Method string
+	// Params is the JSON body of the request. Multipart form fields are
+	// converted to a JSON object of strings, except JSON objects and arrays.
+	Params json.RawMessage
+	// Files holds the content of the uploaded multipart files by part name.
+	Files map[string][]byte
+}
+
+// Decode unmarshals the parameters of the call into v, e.g. a *telegram.SendMessageRequest.
+// Multipart string fields are decoded as numbers or booleans when v expects them.
+func (c Call) Decode(v interface{}) error {
+	params := c.Params
+	for retries := 0; ; retries++ {
+		err := json.Unmarshal(params, v)
+		var typeErr *json.UnmarshalTypeError
+		if c.Files == nil || retries > 100 || !errors.As(err, &typeErr) || typeErr.Value != "string" {
+			return err
+		}
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(params, &fields) != nil {
+			return err
+		}
+		var value string
+		if json.Unmarshal(fields[typeErr.Field], &value) != nil || !json.Valid([]byte(value)) {
+			return err
+		}
+		fields[typeErr.Field] = json.RawMessage(value)
+		params, _ = json.Marshal(fields)
+	}
+}
+
+// Server is a fake Telegram Bot API server. It is safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the server, use it with telegram.WithBaseURL.
+	URL string
+
+	httpServer *httptest.Server
+
+	mu            sync.Mutex
+	calls         []Call
+	failures      map[string][]*telegram.Error
+	updates       []telegram.Update
+	lastUpdateID  int64
+	updateArrived chan struct{} // Closed and replaced when an update is injected
+	lastMessageID int64
+	messages      map[messageKey]*telegram.Message
+	files         map[string]*storedFile
+	webhook       telegram.WebhookInfo
+	webhookSecret string
+	commands      map[string][]telegram.BotCommand
+}
+
+type messageKey struct {
+	chatID    int64
+	messageID int64
+}
+
+type storedFile struct {
+	file    telegram.File
+	content []byte
+}
+
+// NewServer starts a fake server, the caller must Close it.
+func NewServer() *Server {
+	s := &Server{
+		failures:      make(map[string][]*telegram.Error),
+		updateArrived: make(chan struct{}),
+		messages:      make(map[messageKey]*telegram.Message),
+		files:         make(map[string]*storedFile),
+		commands:      make(map[string][]telegram.BotCommand),
+	}
+	s.httpServer = httptest.NewServer(s)
+	s.URL = s.httpServer.URL
+	return s
+}
+
+// Close shuts down the server, blocking until all outstanding requests complete.
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// Client creates a telegram.Client that talks to the server with Token.
+func (s *Server) Client(opts ...telegram.ClientOption) *telegram.Client {
+	opts = append([]telegram.ClientOption{
+		telegram.WithBaseURL(s.URL),
+		telegram.WithHTTPClient(s.httpServer.Client()),
+	}, opts...)
+	return telegram.NewClient(Token, opts...)
+}
+
+// Calls returns every request received, in order.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns the requests received for method, in order.
+func (s *Server) CallsTo(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var calls []Call
+	for _, call := range s.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// FailNext makes the next calls to method fail with errs, one error per call.
+// Failed calls are recorded too.
+func (s *Server) FailNext(method string, errs ...*telegram.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], errs...)
+}
+
+// TooManyRequests is the flood control error, the client must wait retryAfter seconds.
+func TooManyRequests(retryAfter int) *telegram.Error {
+	return &telegram.Error{
+		ErrorCode:   http.StatusTooManyRequests,
+		Description: "Too Many Requests: retry after " + itoa(int64(retryAfter)),
+		Parameters:  &telegram.Parameters{RetryAfter: retryAfter},
+	}
+}
+
+// CantParseEntities is the error returned for malformed MarkdownV2 or HTML text.
+func CantParseEntities(byteOffset int) *telegram.Error {
+	return &telegram.Error{
+		ErrorCode:   http.StatusBadRequest,
+		Description: "Bad Request: can't parse entities: Can't find end of the entity starting at byte offset " + itoa(int64(byteOffset)),
+	}
+}
+
+// BlockedByUser is the error returned when the user blocked the bot.
+func BlockedByUser() *telegram.Error {
+	return &telegram.Error{
+		ErrorCode:   http.StatusForbidden,
+		Description: "Forbidden: bot was blocked by the user",
+	}
+}
+
+// ChatMigrated is the error returned when a group was upgraded to the supergroup newChatID.
+func ChatMigrated(newChatID int64) *telegram.Error {
+	return &telegram.Error{
+		ErrorCode:   http.StatusBadRequest,
+		Description: "Bad Request: group chat was upgraded to a supergroup chat",
+		Parameters:  &telegram.Parameters{MigrateToChatID: newChatID},
+	}
+}
+
+// Message returns a copy of a message sent by the bot or injected in an update.
+func (s *Server) Message(chatID, messageID int64) (telegram.Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, ok := s.messages[messageKey{chatID, messageID}]
+	if !ok {
+		return telegram.Message{}, false
+	}
+	return *msg, true
+}
+
+// AddFile stores content as a file that can be resolved with getFile and
+// downloaded, and returns its file_id.
+func (s *Server) AddFile(name string, content []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.storeFile(name, content).file.FileID
+}
+
+// Webhook returns the current webhook settings and its secret token.
+func (s *Server) Webhook() (telegram.WebhookInfo, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := s.webhook
+	info.PendingUpdateCount = len(s.updates)
+	return info, s.webhookSecret
+}
+
+// Commands returns the command menu set with setMyCommands for a scope type
+// (e.g. telegram.BotCommandScopeAllPrivateChats) and language.
+func (s *Server) Commands(scopeType, languageCode string) []telegram.BotCommand {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]telegram.BotCommand(nil), s.commands[commandsKey(&telegram.BotCommandScope{Type: scopeType}, languageCode)]...)
+}
+
+// ServeHTTP implements the Bot API routes /bot<token>/<method> and /file/bot<token>/<path>.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if path, ok := strings.CutPrefix(r.URL.Path, "/file/bot"+Token+"/"); ok {
+		s.serveFile(w, path)
+		return
+	}
+	path, ok := strings.CutPrefix(r.URL.Path, "/bot")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	token, method, _ := strings.Cut(path, "/")
+	if token != Token {
+		writeError(w, &telegram.Error{ErrorCode: http.StatusUnauthorized, Description: "Unauthorized"})
+		return
+	}
+
+	call, err := readCall(r, method)
+	if err != nil {
+		writeError(w, &telegram.Error{ErrorCode: http.StatusBadRequest, Description: "Bad Request: " + err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+	if failures := s.failures[method]; len(failures) > 0 {
+		s.failures[method] = failures[1:]
+		s.mu.Unlock()
+		writeError(w, failures[0])
+		return
+	}
+	s.mu.Unlock()
+
+	var result interface{}
+	var apiErr *telegram.Error
+	if method == "getUpdates" {
+		// Long polling waits without holding the lock
+		result, apiErr = s.getUpdates(r, call)
+	} else {
+		s.mu.Lock()
+		result, apiErr = s.handle(call)
+		// The result may point to stored messages that later calls modify,
+		// encode it before releasing the lock
+		if apiErr == nil {
+			result = snapshot(result)
+		}
+		s.mu.Unlock()
+	}
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	writeJSON(w, http.StatusOK, telegram.APIResponse[interface{}]{OK: true, Result: result})
+}
+
+// readCall decodes the JSON or multipart/form-data parameters of a request.
+func readCall(r *http.Request, method string) (Call, error) {
+	call := Call{Method: method, Params: json.RawMessage("{}")}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return call, err
+		}
+		if len(bytes.TrimSpace(body)) > 0 {
+			call.Params = body
+		}
+		return call, nil
+	}
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return call, err
+	}
+	fields := make(map[string]json.RawMessage, len(r.MultipartForm.Value))
+	for name, values := range r.MultipartForm.Value {
+		value := []byte(values[0])
+		if !json.Valid(value) || (value[0] != '{' && value[0] != '[') {
+			value, _ = json.Marshal(values[0])
+		}
+		fields[name] = value
+	}
+	call.Params, _ = json.Marshal(fields)
+
+	call.Files = make(map[string][]byte, len(r.MultipartForm.File))
+	for name, headers := range r.MultipartForm.File {
+		file, err := headers[0].Open()
+		if err != nil {
+			return call, err
+		}
+		content, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return call, err
+		}
+		call.Files[name] = content
+	}
+	return call, nil
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, path string) {
+	s.mu.Lock()
+	var content []byte
+	found := false
+	for _, stored := range s.files {
+		if stored.file.FilePath == path {
+			content, found = stored.content, true
+			break
+		}
+	}
+	s.mu.Unlock()
+	if !found {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Length", itoa(int64(len(content))))
+	w.Write(content)
+}
+
+// snapshot encodes v so it can be written after the lock is released.
+func snapshot(v interface{}) interface{} {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	return json.RawMessage(encoded)
+}
+
+func writeError(w http.ResponseWriter, apiErr *telegram.Error) {
+	writeJSON(w, apiErr.ErrorCode, telegram.APIResponse[interface{}]{
+		ErrorCode:   apiErr.ErrorCode,
+		Description: apiErr.Description,
+		Parameters:  apiErr.Parameters,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,177 @@
+package telegramtest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/bot"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram/telegramtest"
+)
+
+func TestSendStoresMessages(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+
+	sent, err := client.SendMessage(ctx, &telegram.SendMessageRequest{ChatID: int64(-4012345678), Text: "hola"})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if sent.Chat.Type != telegram.ChatTypeGroup || sent.From.ID != telegramtest.Bot.ID {
+		t.Errorf("sent message = %+v, want a group message from the bot", sent)
+	}
+
+	if _, err := client.EditMessageText(ctx, &telegram.EditMessageTextRequest{ChatID: sent.Chat.ID, MessageID: sent.MessageID, Text: "adiós"}); err != nil {
+		t.Fatalf("EditMessageText() error = %v", err)
+	}
+	stored, ok := server.Message(sent.Chat.ID, sent.MessageID)
+	if !ok || stored.Text != "adiós" || stored.EditDate == 0 {
+		t.Errorf("Message() = %+v, %t, want the edited message", stored, ok)
+	}
+
+	var apiErr *telegram.Error
+	_, err = client.SendMessage(ctx, &telegram.SendMessageRequest{ChatID: "not a chat", Text: "hola"})
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode != http.StatusBadRequest {
+		t.Errorf("SendMessage() to an invalid chat error = %v, want 400", err)
+	}
+}
+
+func TestFailNext(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	server.FailNext("sendMessage", telegramtest.BlockedByUser(), telegramtest.CantParseEntities(3))
+	client := server.Client()
+
+	for _, want := range []int{http.StatusForbidden, http.StatusBadRequest} {
+		_, err := client.SendMessage(context.Background(), &telegram.SendMessageRequest{ChatID: 42, Text: "hola"})
+		var apiErr *telegram.Error
+		if !errors.As(err, &apiErr) || apiErr.ErrorCode != want {
+			t.Errorf("SendMessage() error = %v, want %d", err, want)
+		}
+	}
+	if _, err := client.SendMessage(context.Background(), &telegram.SendMessageRequest{ChatID: 42, Text: "hola"}); err != nil {
+		t.Errorf("SendMessage() after the failures error = %v", err)
+	}
+	if calls := server.CallsTo("sendMessage"); len(calls) != 3 {
+		t.Errorf("sendMessage calls = %d, want 3 (failed calls are recorded)", len(calls))
+	}
+}
+
+func TestRetryAfterFloodControl(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	server.FailNext("sendMessage", telegramtest.TooManyRequests(1))
+
+	msg, err := server.Client().SendMessage(context.Background(), &telegram.SendMessageRequest{ChatID: 42, Text: "hola"})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if _, ok := server.Message(42, msg.MessageID); !ok {
+		t.Errorf("the retried message %d is not stored", msg.MessageID)
+	}
+	if calls := server.CallsTo("sendMessage"); len(calls) != 2 {
+		t.Errorf("sendMessage calls = %d, want 2", len(calls))
+	}
+}
+
+func TestChatMigration(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	const group, supergroup = int64(-4012345678), int64(-1004012345678)
+	server.FailNext("sendMessage", telegramtest.ChatMigrated(supergroup))
+
+	msg, err := server.Client().SendMessage(context.Background(), &telegram.SendMessageRequest{ChatID: group, Text: "hola"})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if msg.Chat.ID != supergroup || msg.Chat.Type != telegram.ChatTypeSupergroup {
+		t.Errorf("Chat = %+v, want the supergroup %d", msg.Chat, supergroup)
+	}
+	if _, ok := server.Message(supergroup, msg.MessageID); !ok {
+		t.Errorf("message %d is not stored in the supergroup", msg.MessageID)
+	}
+}
+
+func TestHandlerReplies(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	router := bot.NewRouter(client, telegramtest.Bot.Username)
+	router.Handle(bot.Command{
+		Name:    "gasto",
+		MinArgs: 1,
+		Handler: func(ctx context.Context, req *bot.Request) error {
+			// Stop polling once the command is handled
+			defer cancel()
+			return req.Reply(ctx, "Gasto de "+req.Arg(0)+" registrado")
+		},
+	})
+	update := server.InjectMessage(42, "/gasto@"+telegramtest.Bot.Username+" 150 tacos")
+
+	poller := telegram.NewPoller(client, router)
+	poller.Timeout = time.Second
+	if err := poller.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	calls := server.CallsTo("sendMessage")
+	if len(calls) != 1 {
+		t.Fatalf("sendMessage calls = %d, want 1", len(calls))
+	}
+	var reply struct {
+		ChatID int64  `json:"chat_id"`
+		Text   string `json:"text"`
+	}
+	if err := calls[0].Decode(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.ChatID != update.Message.Chat.ID || reply.Text != "Gasto de 150 registrado" {
+		t.Errorf("reply = %+v, want %q in chat %d", reply, "Gasto de 150 registrado", update.Message.Chat.ID)
+	}
+	if pending := server.PendingUpdates(); len(pending) != 0 {
+		t.Errorf("PendingUpdates() = %d updates, want the handled update confirmed", len(pending))
+	}
+}
+
+// TestConcurrentEdits checks that each response shows its own edit, not one
+// made by a concurrent call after the message was stored.
+func TestConcurrentEdits(t *testing.T) {
+	server := telegramtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	ctx := context.Background()
+	sent, err := client.SendMessage(ctx, &telegram.SendMessageRequest{ChatID: 42, Text: "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 10 {
+				text := strconv.Itoa(i*10 + j + 1)
+				edited, err := client.EditMessageText(ctx, &telegram.EditMessageTextRequest{ChatID: sent.Chat.ID, MessageID: sent.MessageID, Text: text})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if edited.Text != text {
+					t.Errorf("edited Text = %q, want %q", edited.Text, text)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,107 @@
+package telegramtest
+
+import (
+	"strings"
+	"time"
+
+	"github.com/betofloresbaca/expenses-manager/pkg/telegram"
+)
+
+// User is the sender of the updates created by InjectMessage and InjectCallback.
+var User = telegram.User{
+	ID:           42,
+	FirstName:    "Test",
+	Username:     "test_user",
+	LanguageCode: "es",
+}
+
+// InjectUpdate queues update to be returned by getUpdates, waking up pending
+// long polling requests. A zero UpdateID is set to the next identifier.
+// Messages in the update are stored, so the bot can reply to them or edit them.
+func (s *Server) InjectUpdate(update telegram.Update) telegram.Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if update.UpdateID == 0 {
+		update.UpdateID = s.lastUpdateID + 1
+	}
+	s.lastUpdateID = max(s.lastUpdateID, update.UpdateID)
+
+	for _, msg := range []*telegram.Message{update.Message, update.EditedMessage, update.ChannelPost, update.EditedChannelPost} {
+		if msg == nil || msg.Chat == nil {
+			continue
+		}
+		if msg.MessageID == 0 {
+			s.lastMessageID++
+			msg.MessageID = s.lastMessageID
+		}
+		s.lastMessageID = max(s.lastMessageID, msg.MessageID)
+		stored := *msg
+		s.messages[messageKey{msg.Chat.ID, msg.MessageID}] = &stored
+	}
+
+	s.updates = append(s.updates, update)
+	close(s.updateArrived)
+	s.updateArrived = make(chan struct{})
+	return update
+}
+
+// InjectMessage queues a text message sent by User in chatID. A leading
+// "/command" gets its bot_command entity, like Telegram does.
+func (s *Server) InjectMessage(chatID int64, text string) telegram.Update {
+	from := User
+	if chatID > 0 {
+		from.ID = chatID
+	}
+	msg := &telegram.Message{
+		From: &from,
+		Chat: telegram.ChatFromID(chatID),
+		Date: time.Now().Unix(),
+		Text: text,
+	}
+	if msg.Chat.Type == telegram.ChatTypePrivate {
+		msg.Chat.FirstName = from.FirstName
+		msg.Chat.Username = from.Username
+	}
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		msg.Entities = []telegram.MessageEntity{{
+			Type:   telegram.EntityBotCommand,
+			Offset: 0,
+			Length: telegram.UTF16Len(command),
+		}}
+	}
+	return s.InjectUpdate(telegram.Update{Message: msg})
+}
+
+// InjectCallback queues a callback query with data, as if User pressed an
+// inline button of the message messageID sent to chatID.
+func (s *Server) InjectCallback(chatID, messageID int64, data string) telegram.Update {
+	s.mu.Lock()
+	var msg *telegram.Message
+	if stored, ok := s.messages[messageKey{chatID, messageID}]; ok {
+		msgCopy := *stored
+		msg = &msgCopy
+	} else {
+		msg = &telegram.Message{MessageID: messageID, Chat: telegram.ChatFromID(chatID)}
+	}
+	s.mu.Unlock()
+
+	from := User
+	if chatID > 0 {
+		from.ID = chatID
+	}
+	return s.InjectUpdate(telegram.Update{CallbackQuery: &telegram.CallbackQuery{
+		ID:           "callback-" + itoa(messageID) + "-" + itoa(time.Now().UnixNano()),
+		From:         &from,
+		Message:      msg,
+		ChatInstance: itoa(chatID),
+		Data:         data,
+	}})
+}
+
+// PendingUpdates returns the updates not yet confirmed with a getUpdates offset.
+func (s *Server) PendingUpdates() []telegram.Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]telegram.Update(nil), s.updates...)
+}